@@ -10,10 +10,17 @@ import (
 	"time"
 
 	"pack-calculator/internal/adapters/cache"
+	"pack-calculator/internal/adapters/jwks"
 	"pack-calculator/internal/adapters/repository"
+	"pack-calculator/internal/adapters/retry"
+	"pack-calculator/internal/adapters/sinks"
+	"pack-calculator/internal/adapters/webhook"
 	"pack-calculator/internal/app"
 	"pack-calculator/internal/config"
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
 	httptransport "pack-calculator/internal/transport/http"
+	"pack-calculator/pkg/codec"
 	"pack-calculator/pkg/logger"
 )
 
@@ -26,23 +33,104 @@ func main() {
 		os.Exit(1)
 	}
 
-	repo, err := repository.NewPostgresRepository(cfg.DB.DSN())
+	repo, err := repository.NewPostgresRepository(cfg.DB.DSN(), cfg.DB.ReplicaDSNs()...)
 	if err != nil {
 		log.Error("Failed to initialize repository", "error", err)
 		os.Exit(1)
 	}
 	defer repo.Close()
 
-	redisCache, err := cache.NewRedisCache(cfg.Redis.Addr(), cfg.Redis.Password, cfg.Redis.DB)
+	appCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	policy := retryPolicy(cfg.Retry)
+	caches, err := setupCache(appCtx, cfg, policy)
 	if err != nil {
 		log.Error("Failed to initialize cache", "error", err)
 		os.Exit(1)
 	}
-	defer redisCache.Close()
+	defer caches.close()
 
 	calculationService := app.NewCalculationService()
-	packService := app.NewPackService(repo, redisCache, calculationService)
-	handler := httptransport.NewHandler(packService)
+	packSizesCache := cache.NewTyped[[]int](caches.cache, cacheCodec(cfg.Cache.Codec), cfg.Cache.KeyPrefix)
+	packService := app.NewPackService(retry.NewRepository(repo, policy), packSizesCache, calculationService, app.PackServiceOptions{
+		CacheEnabled:     cfg.Cache.Enabled,
+		CacheTTL:         cfg.Cache.TTL,
+		KeyPrefix:        cfg.Cache.KeyPrefix,
+		EarlyRefreshBeta: cfg.Cache.EarlyRefreshBeta,
+		Policy: domain.PackSizePolicy{
+			MaxSizes:        cfg.Policy.MaxSizes,
+			MinRatio:        cfg.Policy.MinRatio,
+			ForbidMultiples: cfg.Policy.ForbidMultiples,
+			RequiredSizes:   cfg.Policy.RequiredSizes,
+		},
+	}).WithChangeNotifier(repository.NewPostgresNotifier(repo, cfg.DB.DSN(), cfg.DB.ChangeChannel))
+	if caches.tiered != nil {
+		packService.OnPackSizesChanged(func(domain.PackSizesChanged) {
+			caches.tiered.Purge()
+		})
+	}
+	webhooks := setupWebhooks(cfg.Webhooks, repo)
+	var extraSinks []ports.EventSink
+	if webhooks != nil {
+		extraSinks = append(extraSinks, webhooks)
+		if cfg.Webhooks.CalculationEvents {
+			packService.OnPacksCalculated(func(event domain.PacksCalculated) {
+				if err := webhooks.PublishPacksCalculated(context.Background(), event); err != nil {
+					log.Warn("Failed to publish calculation webhook", "error", err)
+				}
+			})
+		}
+		go webhooks.Run(appCtx)
+	}
+	if dispatcher := setupOutbox(cfg.Outbox, repo, extraSinks...); dispatcher != nil {
+		packService.OnPackSizesChanged(func(domain.PackSizesChanged) {
+			dispatcher.Wake()
+		})
+		go dispatcher.Run(appCtx)
+	}
+	go packService.Watch(appCtx)
+	handler := httptransport.NewHandler(packService, caches.healthChecks...).
+		WithRecommender(app.NewRecommender(calculationService, app.DefaultRecommenderOptions())).
+		WithDeprecation(httptransport.Deprecation{Since: cfg.Server.LegacyDeprecatedAt, Sunset: cfg.Server.LegacySunset}).
+		WithCORS(httptransport.CORSOptions{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			ExposedHeaders:   cfg.CORS.ExposedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		})
+	if webhooks != nil {
+		handler.WithWebhooks(webhooks)
+	}
+	if cfg.Auth.Enabled {
+		handler.WithAPIKeys(setupAPIKeys(cfg.Auth, repo))
+		if cfg.Auth.JWT.Enabled() {
+			tokens, err := setupTokens(cfg.Auth.JWT)
+			if err != nil {
+				log.Error("Failed to set up bearer tokens", "error", err)
+				os.Exit(1)
+			}
+			handler.WithBearerAuth(tokens)
+		}
+	}
+	if cfg.RateLimit.Enabled {
+		limiter, closeLimiter, err := setupRateLimiter(cfg, caches)
+		if err != nil {
+			log.Error("Failed to set up rate limiting", "error", err)
+			os.Exit(1)
+		}
+		defer closeLimiter()
+		handler.WithRateLimiter(limiter)
+	}
+	if cfg.Idempotency.Enabled {
+		handler.WithIdempotency(httptransport.NewIdempotency(idempotencyStore(cfg, caches), httptransport.IdempotencyOptions{
+			TTL:         cfg.Idempotency.TTL,
+			LockTimeout: cfg.Idempotency.LockTimeout,
+			ClientIP:    httptransport.NewClientIP(cfg.RateLimit.Proxies()),
+		}))
+	}
 	router := httptransport.SetupRoutes(handler)
 
 	server := &http.Server{
@@ -63,6 +151,7 @@ func main() {
 	<-quit
 
 	log.Info("Shutting down server...")
+	stopBackground()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -74,3 +163,244 @@ func main() {
 
 	log.Info("Server exited")
 }
+
+// setupWebhooks returns the webhook service, or nil when webhooks are
+// disabled.
+func setupWebhooks(cfg config.WebhooksConfig, repo *repository.PostgresRepository) *app.WebhookService {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	return app.NewWebhookService(repository.NewPostgresWebhookStore(repo), webhook.NewHTTPSender(webhook.SenderOptions{
+		Timeout:              cfg.Timeout,
+		AllowPrivateNetworks: cfg.AllowPrivate,
+	}), app.WebhookOptions{
+		PollInterval:   cfg.PollInterval,
+		BatchSize:      cfg.BatchSize,
+		MaxAttempts:    cfg.MaxAttempts,
+		Lease:          cfg.Lease,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+	})
+}
+
+func setupAPIKeys(cfg config.AuthConfig, repo *repository.PostgresRepository) *app.APIKeyService {
+	anonymousRole := cfg.AnonymousRole
+	if anonymousRole == config.AuthRoleNone {
+		anonymousRole = ""
+	}
+
+	return app.NewAPIKeyService(repository.NewPostgresAPIKeyStore(repo), app.APIKeyOptions{
+		AnonymousRole: anonymousRole,
+		BootstrapKey:  cfg.BootstrapKey,
+		CacheTTL:      cfg.KeyCacheTTL,
+	})
+}
+
+func setupTokens(cfg config.JWTConfig) (*app.TokenService, error) {
+	var keys ports.KeySet
+	if cfg.JWKSFile != "" {
+		fileKeys, err := jwks.NewFileKeySet(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = fileKeys
+	} else {
+		options := jwks.DefaultRemoteKeySetOptions()
+		options.Refresh = cfg.JWKSRefresh
+		keys = jwks.NewRemoteKeySet(cfg.JWKSURL, options)
+	}
+
+	options := app.DefaultTokenOptions()
+	options.Issuer = cfg.Issuer
+	options.Audience = cfg.Audience
+	options.RoleClaim = cfg.RoleClaim
+	options.TenantClaim = cfg.TenantClaim
+	options.RoleMap = cfg.Roles()
+	options.Leeway = cfg.Leeway
+	return app.NewTokenService(keys, options), nil
+}
+
+// setupRateLimiter counts in memory or in Redis; Redis falls back to memory
+// while the circuit breaker is open. It shares the cache's Redis client and
+// only opens its own when the cache is disabled; the returned func closes
+// that connection.
+func setupRateLimiter(cfg *config.Config, caches *cacheStack) (*httptransport.RateLimiter, func(), error) {
+	rl := cfg.RateLimit
+	options := httptransport.DefaultRateLimitOptions()
+	anonymous, err := config.ParseRate(rl.Anonymous)
+	if err != nil {
+		return nil, nil, fmt.Errorf("RATE_LIMIT_ANONYMOUS: %w", err)
+	}
+	authenticated, err := config.ParseRate(rl.Authenticated)
+	if err != nil {
+		return nil, nil, fmt.Errorf("RATE_LIMIT_AUTHENTICATED: %w", err)
+	}
+	options.Anonymous = httptransport.Rate(anonymous)
+	options.Authenticated = httptransport.Rate(authenticated)
+	options.Clients = make(map[string]httptransport.Rate, len(rl.Clients))
+	for subject, rate := range rl.ClientRates() {
+		options.Clients[subject] = httptransport.Rate(rate)
+	}
+	for _, route := range rl.RouteRates() {
+		options.Routes = append(options.Routes, httptransport.RouteRate{
+			Method: route.Method,
+			Path:   route.Path,
+			Rate:   httptransport.Rate(route.Rate),
+		})
+	}
+	options.ClientIP = httptransport.NewClientIP(rl.Proxies())
+
+	memory := cache.NewMemoryRateCounter()
+	if rl.Backend == config.RateLimitBackendMemory {
+		return httptransport.NewRateLimiter(memory, options), func() {}, nil
+	}
+	redisCache, closeRedis := caches.redis, func() {}
+	if redisCache == nil {
+		client, err := cache.NewLazyRedisCache(cfg.Redis)
+		if err != nil {
+			return nil, nil, err
+		}
+		redisCache, closeRedis = client, func() { client.Close() }
+	}
+	counter := cache.NewFallbackRateCounter(
+		cache.NewRedisRateCounter(redisCache, cacheKeyPrefix(cfg.Cache, "ratelimit")),
+		memory,
+		cache.NewCircuitBreaker(cfg.Cache.FailureThreshold, cfg.Cache.RetryInterval),
+	)
+	return httptransport.NewRateLimiter(counter, options), closeRedis, nil
+}
+
+// cacheKeyPrefix returns the Redis key prefix of name, under the configured
+// key prefix if there is one.
+func cacheKeyPrefix(cfg config.CacheConfig, name string) string {
+	if cfg.KeyPrefix == "" {
+		return name + ":"
+	}
+	return cfg.KeyPrefix + ":" + name + ":"
+}
+
+// setupOutbox returns the dispatcher for the configured sinks plus extra, or
+// nil when there are none.
+func setupOutbox(cfg config.OutboxConfig, repo *repository.PostgresRepository, extra ...ports.EventSink) *app.OutboxDispatcher {
+	if len(cfg.Sinks) == 0 && len(extra) == 0 {
+		return nil
+	}
+
+	eventSinks := append([]ports.EventSink(nil), extra...)
+	for _, name := range cfg.Sinks {
+		switch name {
+		case config.OutboxSinkLog:
+			eventSinks = append(eventSinks, sinks.NewLogSink())
+		case config.OutboxSinkWebhook:
+			eventSinks = append(eventSinks, sinks.NewWebhookSink(cfg.WebhookURL, cfg.WebhookTimeout))
+		case config.OutboxSinkFile:
+			eventSinks = append(eventSinks, sinks.NewFileSink(cfg.FilePath))
+		}
+	}
+
+	return app.NewOutboxDispatcher(repository.NewPostgresOutbox(repo), eventSinks, app.OutboxOptions{
+		PollInterval: cfg.PollInterval,
+		BatchSize:    cfg.BatchSize,
+		MaxAttempts:  cfg.MaxAttempts,
+		Lease:        cfg.Lease,
+		Retention:    cfg.Retention,
+	})
+}
+
+func retryPolicy(cfg config.RetryConfig) retry.Policy {
+	return retry.Policy{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+		Multiplier:     cfg.Multiplier,
+		Jitter:         cfg.Jitter,
+	}
+}
+
+func cacheCodec(name string) ports.Codec {
+	if name == config.CacheCodecMsgpack {
+		return codec.Msgpack{}
+	}
+	return codec.JSON{}
+}
+
+// idempotencyStore shares idempotency keys through Redis when the cache is
+// enabled, so retries reaching another instance are caught too. While the
+// circuit breaker is open they are checked per instance.
+func idempotencyStore(cfg *config.Config, caches *cacheStack) ports.IdempotencyStore {
+	memory := cache.NewMemoryIdempotencyStore()
+	if caches.redis == nil {
+		logger.Default().Warn("Cache disabled, idempotency keys are only checked per instance")
+		return memory
+	}
+	return cache.NewFallbackIdempotencyStore(
+		cache.NewRedisIdempotencyStore(caches.redis, cacheKeyPrefix(cfg.Cache, "idempotency")),
+		memory,
+		cache.NewCircuitBreaker(cfg.Cache.FailureThreshold, cfg.Cache.RetryInterval),
+	)
+}
+
+// cacheStack is the cache wiring selected by configuration.
+type cacheStack struct {
+	cache        ports.Cache
+	redis        *cache.RedisCache
+	tiered       *cache.TieredCache
+	healthChecks []ports.HealthChecker
+	close        func()
+}
+
+// setupCache builds Redis behind a circuit breaker, optionally fronted by an
+// in-process tier. Unless the cache is required, an unreachable Redis only
+// puts the service in degraded mode.
+func setupCache(ctx context.Context, cfg *config.Config, policy retry.Policy) (*cacheStack, error) {
+	log := logger.Default()
+
+	if !cfg.Cache.Enabled {
+		log.Info("Cache disabled")
+		return &cacheStack{cache: cache.NewNoopCache(), close: func() {}}, nil
+	}
+
+	redisCache, err := cache.NewLazyRedisCache(cfg.Redis)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retries absorb brief blips; only failures that outlast them count
+	// towards opening the circuit breaker.
+	resilientCache := cache.NewResilientCache(retry.NewCache(redisCache, policy), redisCache, cache.ResilientOptions{
+		FailureThreshold: cfg.Cache.FailureThreshold,
+		RetryInterval:    cfg.Cache.RetryInterval,
+	})
+	pingCtx, cancelPing := context.WithTimeout(ctx, 5*time.Second)
+	err = resilientCache.Probe(pingCtx)
+	cancelPing()
+	if err != nil {
+		if cfg.Cache.Required {
+			redisCache.Close()
+			return nil, err
+		}
+		log.Warn("Cache unavailable, starting in degraded mode", "error", err)
+	}
+	go resilientCache.Run(ctx)
+
+	stack := &cacheStack{
+		cache:        resilientCache,
+		redis:        redisCache,
+		healthChecks: []ports.HealthChecker{resilientCache},
+		close:        func() { redisCache.Close() },
+	}
+
+	if cfg.Cache.Local {
+		stack.tiered = cache.NewTieredCache(
+			cache.NewMemoryCache(cfg.Cache.LocalSize),
+			resilientCache,
+			cache.NewRedisInvalidator(redisCache, cfg.Cache.InvalidationChannel),
+			cache.TieredOptions{LocalTTL: cfg.Cache.LocalTTL},
+		)
+		go stack.tiered.Run(ctx)
+		stack.cache = stack.tiered
+	}
+
+	return stack, nil
+}
@@ -0,0 +1,160 @@
+// Command devissuer is a stand-in token issuer for local development and
+// tests. It signs JWTs for whatever subject, roles and tenant it is asked for,
+// so it must never be reachable from production.
+//
+// It serves its key set at /.well-known/jwks.json (point AUTH_JWKS_URL
+// there, or write it to a file with -jwks-out for AUTH_JWKS_FILE) and issues
+// tokens at /token:
+//
+//	curl -d sub=alice -d roles=admin -d tenant=acme localhost:9000/token
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"pack-calculator/pkg/jwt"
+	"pack-calculator/pkg/logger"
+)
+
+const maxTokenTTL = 24 * time.Hour
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9000", "iss claim; set AUTH_JWT_ISSUER to the same value")
+	audience := flag.String("audience", "pack-calculator", "aud claim; set AUTH_JWT_AUDIENCE to the same value")
+	keyFile := flag.String("key", "devissuer-key.pem", "signing key, created if missing")
+	jwksOut := flag.String("jwks-out", "", "also write the key set to this file")
+	flag.Parse()
+
+	log := logger.Default()
+
+	key, err := loadOrCreateKey(*keyFile)
+	if err != nil {
+		log.Error("Failed to load signing key", "error", err)
+		os.Exit(1)
+	}
+	kid := keyID(&key.PublicKey)
+	keySet, err := jwt.MarshalJWKS([]jwt.Key{{ID: kid, Alg: "ES256", Public: &key.PublicKey}})
+	if err != nil {
+		log.Error("Failed to encode key set", "error", err)
+		os.Exit(1)
+	}
+	if *jwksOut != "" {
+		if err := os.WriteFile(*jwksOut, keySet, 0o644); err != nil {
+			log.Error("Failed to write key set", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(keySet)
+	})
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":   *issuer,
+			"jwks_uri": strings.TrimSuffix(*issuer, "/") + "/.well-known/jwks.json",
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		subject := r.FormValue("sub")
+		if subject == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "sub is required"})
+			return
+		}
+		ttl := time.Hour
+		if value := r.FormValue("ttl"); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed <= 0 || parsed > maxTokenTTL {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ttl must be a duration up to 24h"})
+				return
+			}
+			ttl = parsed
+		}
+
+		now := time.Now()
+		claims := map[string]any{
+			"iss": *issuer,
+			"aud": *audience,
+			"sub": subject,
+			"iat": now.Unix(),
+			"exp": now.Add(ttl).Unix(),
+		}
+		if roles := r.FormValue("roles"); roles != "" {
+			claims["roles"] = strings.Split(roles, ",")
+		}
+		if tenant := r.FormValue("tenant"); tenant != "" {
+			claims["tenant"] = tenant
+		}
+
+		token, err := jwt.Sign(jwt.Header{Alg: "ES256", Kid: kid}, claims, key)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"access_token": token,
+			"token_type":   "Bearer",
+			"expires_in":   int(ttl.Seconds()),
+		})
+	})
+
+	log.Info("Development token issuer starting", "addr", *addr, "issuer", *issuer, "audience", *audience, "kid", kid)
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		log.Error("Issuer failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func loadOrCreateKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		pemData := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+		return key, os.WriteFile(path, pemData, 0o600)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+// keyID derives a stable kid from the public key.
+func keyID(public *ecdsa.PublicKey) string {
+	der, _ := x509.MarshalPKIXPublicKey(public)
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:8])
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
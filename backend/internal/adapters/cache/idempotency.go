@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemoryIdempotencyStore keeps outcomes in process, so only retries that
+// reach the same instance are caught.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]idempotencyEntry
+	now     func() time.Time
+	sweptAt time.Time
+}
+
+type idempotencyEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]idempotencyEntry), now: time.Now}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key string, pending []byte, ttl time.Duration) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	if entry, ok := s.entries[key]; ok && s.now().Before(entry.expires) {
+		return entry.value, false, nil
+	}
+	s.entries[key] = idempotencyEntry{value: pending, expires: s.now().Add(ttl)}
+	return nil, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = idempotencyEntry{value: value, expires: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep drops expired entries, at most once a second.
+func (s *MemoryIdempotencyStore) sweep() {
+	now := s.now()
+	if now.Sub(s.sweptAt) < time.Second {
+		return
+	}
+	s.sweptAt = now
+	for key, entry := range s.entries {
+		if !now.Before(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// RedisIdempotencyStore shares outcomes through Redis. Reservations use SET
+// NX GET, so they need Redis 7.
+type RedisIdempotencyStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisIdempotencyStore(c *RedisCache, prefix string) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: c.client, prefix: prefix}
+}
+
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, key string, pending []byte, ttl time.Duration) ([]byte, bool, error) {
+	stored, err := s.client.SetArgs(ctx, s.prefix+key, pending, redis.SetArgs{Mode: "NX", TTL: ttl, Get: true}).Bytes()
+	if err == redis.Nil {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to reserve idempotency key")
+	}
+	return stored, false, nil
+}
+
+func (s *RedisIdempotencyStore) Complete(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.prefix+key, value, ttl).Err(); err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to store idempotent response")
+	}
+	return nil
+}
+
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, s.prefix+key).Err(); err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to release idempotency key")
+	}
+	return nil
+}
+
+// FallbackIdempotencyStore uses primary until it keeps failing, then fallback
+// until the circuit breaker lets a trial call through, so a Redis outage
+// costs no timeouts and retries are still caught per instance.
+type FallbackIdempotencyStore struct {
+	primary  ports.IdempotencyStore
+	fallback ports.IdempotencyStore
+	breaker  *CircuitBreaker
+}
+
+func NewFallbackIdempotencyStore(primary, fallback ports.IdempotencyStore, breaker *CircuitBreaker) *FallbackIdempotencyStore {
+	return &FallbackIdempotencyStore{primary: primary, fallback: fallback, breaker: breaker}
+}
+
+func (s *FallbackIdempotencyStore) Reserve(ctx context.Context, key string, pending []byte, ttl time.Duration) ([]byte, bool, error) {
+	if s.breaker.Allow() {
+		stored, reserved, err := s.primary.Reserve(ctx, key, pending, ttl)
+		if s.settled(ctx, err) {
+			return stored, reserved, err
+		}
+	}
+	return s.fallback.Reserve(ctx, key, pending, ttl)
+}
+
+func (s *FallbackIdempotencyStore) Complete(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if s.breaker.Allow() {
+		if err := s.primary.Complete(ctx, key, value, ttl); s.settled(ctx, err) {
+			return err
+		}
+	}
+	return s.fallback.Complete(ctx, key, value, ttl)
+}
+
+func (s *FallbackIdempotencyStore) Release(ctx context.Context, key string) error {
+	if s.breaker.Allow() {
+		if err := s.primary.Release(ctx, key); s.settled(ctx, err) {
+			return err
+		}
+	}
+	return s.fallback.Release(ctx, key)
+}
+
+// settled reports the outcome of a primary call to the breaker and returns
+// whether it stands: it succeeded, or failed through the caller's own
+// context, which says nothing about Redis. Otherwise fallback is used.
+func (s *FallbackIdempotencyStore) settled(ctx context.Context, err error) bool {
+	switch {
+	case err == nil:
+		s.breaker.Success()
+		return true
+	case callerGaveUp(ctx, err):
+		s.breaker.Abandon()
+		return true
+	default:
+		s.breaker.Failure()
+		logger.Default().Warn("Idempotency store failed, using memory", "error", err)
+		return false
+	}
+}
+
+var (
+	_ ports.IdempotencyStore = (*MemoryIdempotencyStore)(nil)
+	_ ports.IdempotencyStore = (*RedisIdempotencyStore)(nil)
+	_ ports.IdempotencyStore = (*FallbackIdempotencyStore)(nil)
+)
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	now := time.Unix(1_700_000_000, 0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, reserved, _ := store.Reserve(ctx, "k", []byte("pending"), time.Minute); !reserved {
+		t.Fatal("Reserve() of a new key was not reserved")
+	}
+	if stored, reserved, _ := store.Reserve(ctx, "k", []byte("other"), time.Minute); reserved || string(stored) != "pending" {
+		t.Errorf("Reserve() of a taken key = %q, %v; want pending, false", stored, reserved)
+	}
+
+	store.Complete(ctx, "k", []byte("done"), time.Hour)
+	now = now.Add(30 * time.Minute)
+	if stored, _, _ := store.Reserve(ctx, "k", []byte("other"), time.Minute); string(stored) != "done" {
+		t.Errorf("Reserve() after Complete() = %q, want done", stored)
+	}
+
+	now = now.Add(time.Hour)
+	if _, reserved, _ := store.Reserve(ctx, "k", []byte("again"), time.Minute); !reserved {
+		t.Error("Reserve() of an expired key was not reserved")
+	}
+	store.Release(ctx, "k")
+	if _, reserved, _ := store.Reserve(ctx, "k", []byte("again"), time.Minute); !reserved {
+		t.Error("Reserve() of a released key was not reserved")
+	}
+}
+
+type failingIdempotencyStore struct {
+	calls int
+}
+
+func (f *failingIdempotencyStore) Reserve(ctx context.Context, key string, pending []byte, ttl time.Duration) ([]byte, bool, error) {
+	f.calls++
+	return nil, false, errors.New("connection refused")
+}
+
+func (f *failingIdempotencyStore) Complete(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	f.calls++
+	return errors.New("connection refused")
+}
+
+func (f *failingIdempotencyStore) Release(ctx context.Context, key string) error {
+	f.calls++
+	return errors.New("connection refused")
+}
+
+func TestFallbackIdempotencyStore_UsesMemoryWhilePrimaryFails(t *testing.T) {
+	primary := &failingIdempotencyStore{}
+	store := NewFallbackIdempotencyStore(primary, NewMemoryIdempotencyStore(), NewCircuitBreaker(2, time.Hour))
+	ctx := context.Background()
+
+	if _, reserved, err := store.Reserve(ctx, "a", []byte("pending"), time.Minute); err != nil || !reserved {
+		t.Fatalf("Reserve() = %v, %v; want reserved in memory", reserved, err)
+	}
+	if err := store.Complete(ctx, "a", []byte("done"), time.Hour); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if stored, reserved, err := store.Reserve(ctx, "a", []byte("pending"), time.Minute); err != nil || reserved || string(stored) != "done" {
+		t.Errorf("Reserve() of a completed key = %q, %v, %v; want done", stored, reserved, err)
+	}
+	if primary.calls != 2 {
+		t.Errorf("primary calls = %d, want 2 before the breaker opens", primary.calls)
+	}
+}
+
+func TestFallbackIdempotencyStore_CancelledCallsLeaveBreakerClosed(t *testing.T) {
+	primary := &failingIdempotencyStore{}
+	breaker := NewCircuitBreaker(1, time.Hour)
+	store := NewFallbackIdempotencyStore(primary, NewMemoryIdempotencyStore(), breaker)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < 3; i++ {
+		if _, reserved, err := store.Reserve(ctx, "a", []byte("pending"), time.Minute); err == nil || reserved {
+			t.Fatalf("Reserve() = %v, %v; want the primary's error, not a reservation in memory", reserved, err)
+		}
+	}
+	if primary.calls != 3 || breaker.State() != BreakerClosed {
+		t.Errorf("primary calls = %d, breaker %s; want 3, closed", primary.calls, breaker.State())
+	}
+}
+
+func TestRedisIdempotencyStore(t *testing.T) {
+	cache := setupTestRedis(t)
+	defer cache.Close()
+	store := NewRedisIdempotencyStore(cache, "test:idempotency:")
+	ctx := context.Background()
+
+	if _, reserved, err := store.Reserve(ctx, "k", []byte("pending"), time.Minute); err != nil || !reserved {
+		t.Fatalf("Reserve() of a new key = %v, %v; want reserved", reserved, err)
+	}
+	if stored, reserved, err := store.Reserve(ctx, "k", []byte("other"), time.Minute); err != nil || reserved || string(stored) != "pending" {
+		t.Errorf("Reserve() of a taken key = %q, %v, %v; want pending", stored, reserved, err)
+	}
+
+	if err := store.Complete(ctx, "k", []byte("done"), time.Hour); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if ttl := cache.client.PTTL(ctx, "test:idempotency:k").Val(); ttl <= time.Minute {
+		t.Errorf("TTL after Complete() = %v, want the response TTL", ttl)
+	}
+
+	if err := store.Release(ctx, "k"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, reserved, _ := store.Reserve(ctx, "k", []byte("again"), time.Minute); !reserved {
+		t.Error("Reserve() of a released key was not reserved")
+	}
+}
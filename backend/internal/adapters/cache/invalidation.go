@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Invalidator broadcasts cache key invalidations to every application instance.
+type Invalidator interface {
+	Publish(ctx context.Context, key string) error
+	// Subscribe calls handle for each invalidated key until ctx is cancelled.
+	Subscribe(ctx context.Context, handle func(key string)) error
+}
+
+type invalidationMessage struct {
+	Origin string `json:"origin"`
+	Key    string `json:"key"`
+}
+
+// RedisInvalidator publishes invalidations over Redis pub/sub.
+type RedisInvalidator struct {
+	client  redis.UniversalClient
+	channel string
+	origin  string
+	logger  *slog.Logger
+}
+
+func NewRedisInvalidator(c *RedisCache, channel string) *RedisInvalidator {
+	return &RedisInvalidator{
+		client:  c.client,
+		channel: channel,
+		origin:  newInstanceID(),
+		logger:  logger.Default(),
+	}
+}
+
+func (i *RedisInvalidator) Publish(ctx context.Context, key string) error {
+	data, err := json.Marshal(invalidationMessage{Origin: i.origin, Key: key})
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to marshal invalidation")
+	}
+
+	if err := i.client.Publish(ctx, i.channel, data).Err(); err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to publish invalidation")
+	}
+	return nil
+}
+
+// Subscribe ignores messages published by this instance, since the local
+// entry has already been dropped by the caller. The go-redis subscription
+// reconnects on its own when Redis becomes reachable again.
+func (i *RedisInvalidator) Subscribe(ctx context.Context, handle func(key string)) error {
+	sub := i.client.Subscribe(ctx, i.channel)
+	defer sub.Close()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var payload invalidationMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &payload); err != nil {
+				i.logger.Warn("Ignoring malformed invalidation", "error", err)
+				continue
+			}
+			if payload.Origin == i.origin {
+				continue
+			}
+			handle(payload.Key)
+		}
+	}
+}
+
+func newInstanceID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
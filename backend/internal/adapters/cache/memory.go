@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// MemoryCache is an in-process LRU cache with per-entry expiry.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	now      func() time.Time
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	version   int
+	expiresAt time.Time
+}
+
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &MemoryCache{
+		capacity: capacity,
+		now:      time.Now,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.lookup(key)
+	if !ok {
+		return nil, pkgerrors.ErrNotFound
+	}
+	return copyBytes(entry.value), nil
+}
+
+func (c *MemoryCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if entry, ok := c.lookup(key); ok {
+			values[key] = copyBytes(entry.value)
+		}
+	}
+	return values, nil
+}
+
+// Set stores value for ttl; a ttl of 0 or less never expires.
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.store(&memoryEntry{key: key, value: copyBytes(value)}, ttl)
+	return nil
+}
+
+func (c *MemoryCache) SetMany(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, value := range items {
+		c.store(&memoryEntry{key: key, value: copyBytes(value)}, ttl)
+	}
+	return nil
+}
+
+func (c *MemoryCache) GetVersion(ctx context.Context, key string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.lookup(key)
+	if !ok {
+		return 0, pkgerrors.ErrNotFound
+	}
+	return entry.version, nil
+}
+
+func (c *MemoryCache) AdvanceVersion(ctx context.Context, key string, version int, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.lookup(key); ok && entry.version > version {
+		return nil
+	}
+	c.store(&memoryEntry{key: key, version: version}, ttl)
+	return nil
+}
+
+// lookup returns the live entry for key, dropping it if expired.
+func (c *MemoryCache) lookup(key string) (*memoryEntry, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry, true
+}
+
+func (c *MemoryCache) store(entry *memoryEntry, ttl time.Duration) {
+	if ttl > 0 {
+		entry.expiresAt = c.now().Add(ttl)
+	}
+
+	if elem, ok := c.entries[entry.key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.entries[key]; ok {
+			c.removeElement(elem)
+		}
+	}
+	return nil
+}
+
+func (c *MemoryCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *MemoryCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*memoryEntry).key)
+}
+
+func copyBytes(value []byte) []byte {
+	if value == nil {
+		return nil
+	}
+	out := make([]byte, len(value))
+	copy(out, value)
+	return out
+}
+
+var _ ports.Cache = (*MemoryCache)(nil)
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+func TestMemoryCache_LRUEviction(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(2)
+
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+	// Touch "a" so "b" becomes least recently used.
+	if _, err := c.Get(ctx, "a"); err != nil {
+		t.Fatalf("Get(a) error = %v", err)
+	}
+	c.Set(ctx, "c", []byte("3"), time.Minute)
+
+	if _, err := c.Get(ctx, "b"); !errors.Is(err, pkgerrors.ErrNotFound) {
+		t.Errorf("Get(b) error = %v, want ErrNotFound after eviction", err)
+	}
+	if _, err := c.Get(ctx, "a"); err != nil {
+		t.Errorf("Get(a) error = %v, want hit", err)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestMemoryCache_TTL(t *testing.T) {
+	now := time.Now()
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "key", []byte("250,500"), 5*time.Second)
+	if _, err := c.Get(ctx, "key"); err != nil {
+		t.Fatalf("Get() error = %v, want hit", err)
+	}
+
+	now = now.Add(5 * time.Second)
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, pkgerrors.ErrNotFound) {
+		t.Errorf("Get() after expiry error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryCache_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+	value := []byte("250,500")
+	c.Set(ctx, "key", value, time.Minute)
+	value[0] = 'x'
+
+	got, _ := c.Get(ctx, "key")
+	got[1] = 'x'
+
+	again, _ := c.Get(ctx, "key")
+	if string(again) != "250,500" {
+		t.Errorf("Get() = %q, want 250,500", again)
+	}
+}
+
+func TestMemoryCache_AdvanceVersion(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+
+	if _, err := c.GetVersion(ctx, "pointer"); !errors.Is(err, pkgerrors.ErrNotFound) {
+		t.Fatalf("GetVersion() error = %v, want ErrNotFound", err)
+	}
+
+	c.AdvanceVersion(ctx, "pointer", 5, time.Minute)
+	c.AdvanceVersion(ctx, "pointer", 3, time.Minute)
+
+	got, err := c.GetVersion(ctx, "pointer")
+	if err != nil || got != 5 {
+		t.Errorf("GetVersion() = %d, %v, want 5", got, err)
+	}
+
+	c.AdvanceVersion(ctx, "pointer", 6, time.Minute)
+	if got, _ := c.GetVersion(ctx, "pointer"); got != 6 {
+		t.Errorf("GetVersion() = %d, want 6", got)
+	}
+}
+
+func TestMemoryCache_Many(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+
+	c.SetMany(ctx, map[string][]byte{"a": []byte("1"), "b": []byte("2")}, time.Minute)
+	got, _ := c.GetMany(ctx, []string{"a", "b", "c"})
+	if len(got) != 2 || string(got["a"]) != "1" || string(got["b"]) != "2" {
+		t.Errorf("GetMany() = %v, want a and b", got)
+	}
+
+	c.Delete(ctx, "a", "b")
+	if c.Len() != 0 {
+		t.Errorf("Len() after Delete = %d, want 0", c.Len())
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// NoopCache never stores anything; every Get is a miss.
+type NoopCache struct{}
+
+func NewNoopCache() *NoopCache {
+	return &NoopCache{}
+}
+
+func (c *NoopCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, pkgerrors.ErrNotFound
+}
+
+func (c *NoopCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	return map[string][]byte{}, nil
+}
+
+func (c *NoopCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (c *NoopCache) SetMany(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	return nil
+}
+
+func (c *NoopCache) Delete(ctx context.Context, keys ...string) error {
+	return nil
+}
+
+func (c *NoopCache) GetVersion(ctx context.Context, key string) (int, error) {
+	return 0, pkgerrors.ErrNotFound
+}
+
+func (c *NoopCache) AdvanceVersion(ctx context.Context, key string, version int, ttl time.Duration) error {
+	return nil
+}
+
+var _ ports.Cache = (*NoopCache)(nil)
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemoryRateCounter counts requests in process, so each instance enforces
+// limits on its own.
+type MemoryRateCounter struct {
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	now     func() time.Time
+	sweptAt time.Time
+}
+
+type rateWindow struct {
+	start             time.Time
+	length            time.Duration
+	current, previous int
+}
+
+func NewMemoryRateCounter() *MemoryRateCounter {
+	return &MemoryRateCounter{windows: make(map[string]*rateWindow), now: time.Now}
+}
+
+func (c *MemoryRateCounter) Increment(ctx context.Context, key string, window time.Time, length time.Duration, n int) (int, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sweep()
+	w, ok := c.windows[key]
+	switch {
+	case !ok:
+		w = &rateWindow{start: window, length: length}
+		c.windows[key] = w
+	case w.start.Equal(window):
+	case w.start.Add(length).Equal(window):
+		w.start, w.previous, w.current = window, w.current, 0
+	default:
+		w.start, w.previous, w.current = window, 0, 0
+	}
+	w.current += n
+	return w.current, w.previous, nil
+}
+
+// sweep drops keys idle for two windows, at most once a second.
+func (c *MemoryRateCounter) sweep() {
+	now := c.now()
+	if now.Sub(c.sweptAt) < time.Second {
+		return
+	}
+	c.sweptAt = now
+	for key, w := range c.windows {
+		if now.Sub(w.start) >= 2*w.length {
+			delete(c.windows, key)
+		}
+	}
+}
+
+// RedisRateCounter shares counts through Redis. Each window is a key that
+// expires once it can no longer be the previous window.
+type RedisRateCounter struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisRateCounter(c *RedisCache, prefix string) *RedisRateCounter {
+	return &RedisRateCounter{client: c.client, prefix: prefix}
+}
+
+func (c *RedisRateCounter) Increment(ctx context.Context, key string, window time.Time, length time.Duration, n int) (int, int, error) {
+	// The hash tag keeps both windows of a key in one cluster slot.
+	base := c.prefix + "{" + key + "}:"
+	currentKey := base + strconv.FormatInt(window.Unix(), 10)
+	previousKey := base + strconv.FormatInt(window.Add(-length).Unix(), 10)
+
+	var incr *redis.IntCmd
+	var prev *redis.StringCmd
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.IncrBy(ctx, currentKey, int64(n))
+		pipe.PExpire(ctx, currentKey, 2*length)
+		prev = pipe.Get(ctx, previousKey)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return 0, 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to count request")
+	}
+
+	previous, err := prev.Int()
+	if err == redis.Nil {
+		previous = 0
+	} else if err != nil {
+		return 0, 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to count request")
+	}
+	return int(incr.Val()), previous, nil
+}
+
+// FallbackRateCounter uses primary until it keeps failing, then counts with
+// fallback until the circuit breaker lets a trial call through. Limits stay
+// enforced per instance while Redis is down.
+type FallbackRateCounter struct {
+	primary  ports.RateCounter
+	fallback ports.RateCounter
+	breaker  *CircuitBreaker
+}
+
+func NewFallbackRateCounter(primary, fallback ports.RateCounter, breaker *CircuitBreaker) *FallbackRateCounter {
+	return &FallbackRateCounter{primary: primary, fallback: fallback, breaker: breaker}
+}
+
+func (c *FallbackRateCounter) Increment(ctx context.Context, key string, window time.Time, length time.Duration, n int) (int, int, error) {
+	if c.breaker.Allow() {
+		current, previous, err := c.primary.Increment(ctx, key, window, length, n)
+		if err == nil {
+			c.breaker.Success()
+			return current, previous, nil
+		}
+		if callerGaveUp(ctx, err) {
+			c.breaker.Abandon()
+			return 0, 0, err
+		}
+		c.breaker.Failure()
+		logger.Default().Warn("Rate counter failed, counting in memory", "error", err)
+	}
+	return c.fallback.Increment(ctx, key, window, length, n)
+}
+
+var (
+	_ ports.RateCounter = (*MemoryRateCounter)(nil)
+	_ ports.RateCounter = (*RedisRateCounter)(nil)
+	_ ports.RateCounter = (*FallbackRateCounter)(nil)
+)
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateCounter_RollsWindows(t *testing.T) {
+	counter := NewMemoryRateCounter()
+	ctx := context.Background()
+	start := time.Unix(1_700_000_000, 0).Truncate(time.Minute)
+
+	for want := 1; want <= 3; want++ {
+		current, previous, _ := counter.Increment(ctx, "k", start, time.Minute, 1)
+		if current != want || previous != 0 {
+			t.Fatalf("Increment() = %d, %d; want %d, 0", current, previous, want)
+		}
+	}
+
+	current, previous, _ := counter.Increment(ctx, "k", start.Add(time.Minute), time.Minute, 1)
+	if current != 1 || previous != 3 {
+		t.Errorf("next window Increment() = %d, %d; want 1, 3", current, previous)
+	}
+
+	// A skipped window leaves nothing to carry over.
+	current, previous, _ = counter.Increment(ctx, "k", start.Add(3*time.Minute), time.Minute, 1)
+	if current != 1 || previous != 0 {
+		t.Errorf("after a gap Increment() = %d, %d; want 1, 0", current, previous)
+	}
+
+	if current, _, _ := counter.Increment(ctx, "other", start, time.Minute, 1); current != 1 {
+		t.Errorf("other key Increment() = %d, want 1", current)
+	}
+}
+
+func TestMemoryRateCounter_SweepsIdleKeys(t *testing.T) {
+	counter := NewMemoryRateCounter()
+	now := time.Unix(1_700_000_000, 0)
+	counter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	counter.Increment(ctx, "idle", now.Truncate(time.Minute), time.Minute, 1)
+	now = now.Add(3 * time.Minute)
+	counter.Increment(ctx, "active", now.Truncate(time.Minute), time.Minute, 1)
+
+	if _, ok := counter.windows["idle"]; ok {
+		t.Error("idle key was not swept")
+	}
+}
+
+type failingRateCounter struct {
+	calls int
+}
+
+func (f *failingRateCounter) Increment(ctx context.Context, key string, window time.Time, length time.Duration, n int) (int, int, error) {
+	f.calls++
+	return 0, 0, errors.New("connection refused")
+}
+
+func TestFallbackRateCounter_CountsInMemoryWhilePrimaryFails(t *testing.T) {
+	primary := &failingRateCounter{}
+	counter := NewFallbackRateCounter(primary, NewMemoryRateCounter(), NewCircuitBreaker(2, time.Hour))
+	ctx := context.Background()
+	window := time.Now().Truncate(time.Minute)
+
+	for want := 1; want <= 4; want++ {
+		current, _, err := counter.Increment(ctx, "k", window, time.Minute, 1)
+		if err != nil || current != want {
+			t.Fatalf("Increment() = %d, %v; want %d, nil", current, err, want)
+		}
+	}
+	if primary.calls != 2 {
+		t.Errorf("primary calls = %d, want 2 before the breaker opens", primary.calls)
+	}
+}
+
+func TestFallbackRateCounter_CancelledCallsLeaveBreakerClosed(t *testing.T) {
+	primary := &failingRateCounter{}
+	breaker := NewCircuitBreaker(1, time.Hour)
+	counter := NewFallbackRateCounter(primary, NewMemoryRateCounter(), breaker)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := counter.Increment(ctx, "k", time.Now().Truncate(time.Minute), time.Minute, 1); err == nil {
+			t.Fatal("Increment() error = nil, want the primary's error")
+		}
+	}
+	if primary.calls != 3 || breaker.State() != BreakerClosed {
+		t.Errorf("primary calls = %d, breaker %s; want 3, closed", primary.calls, breaker.State())
+	}
+}
+
+func TestRedisRateCounter_Increment(t *testing.T) {
+	cache := setupTestRedis(t)
+	defer cache.Close()
+	counter := NewRedisRateCounter(cache, "test:ratelimit:")
+	ctx := context.Background()
+	window := time.Now().Truncate(time.Minute)
+
+	counter.Increment(ctx, "k", window, time.Minute, 1)
+	current, previous, err := counter.Increment(ctx, "k", window, time.Minute, 2)
+	if err != nil || current != 3 || previous != 0 {
+		t.Fatalf("Increment() = %d, %d, %v; want 3, 0, nil", current, previous, err)
+	}
+
+	current, previous, err = counter.Increment(ctx, "k", window.Add(time.Minute), time.Minute, 1)
+	if err != nil || current != 1 || previous != 3 {
+		t.Errorf("next window Increment() = %d, %d, %v; want 1, 3, nil", current, previous, err)
+	}
+
+	ttl := cache.client.PTTL(ctx, "test:ratelimit:{k}:"+strconv.FormatInt(window.Unix(), 10)).Val()
+	if ttl <= 0 || ttl > 2*time.Minute {
+		t.Errorf("window TTL = %v, want up to two windows", ttl)
+	}
+}
@@ -2,10 +2,11 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
+	"pack-calculator/internal/config"
 	"pack-calculator/internal/ports"
 	pkgerrors "pack-calculator/pkg/errors"
 
@@ -13,31 +14,58 @@ import (
 )
 
 type RedisCache struct {
-	client *redis.Client
+	client redis.UniversalClient
 }
 
-func NewRedisCache(addr string, password string, db int) (*RedisCache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       db,
-	})
+// NewRedisCache connects to Redis and fails if the server does not answer PING.
+func NewRedisCache(cfg config.RedisConfig) (*RedisCache, error) {
+	c, err := NewLazyRedisCache(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Ping(context.Background()); err != nil {
+		c.Close()
+		return nil, err
+	}
 
-	ctx := context.Background()
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	return c, nil
+}
+
+// NewLazyRedisCache builds the client without contacting the server, so the
+// application can start while Redis is down and connect once it comes back.
+func NewLazyRedisCache(cfg config.RedisConfig) (*RedisCache, error) {
+	opts, err := universalOptions(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis configuration: %w", err)
+	}
+
+	var client redis.UniversalClient
+	switch cfg.Mode {
+	case config.RedisModeSentinel:
+		client = redis.NewFailoverClient(opts.Failover())
+	case config.RedisModeCluster:
+		client = redis.NewClusterClient(opts.Cluster())
+	default:
+		client = redis.NewClient(opts.Simple())
 	}
 
 	return &RedisCache{client: client}, nil
 }
 
+func (c *RedisCache) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
 func (c *RedisCache) Close() error {
 	return c.client.Close()
 }
 
-func (c *RedisCache) Get(key string) ([]int, error) {
-	ctx := context.Background()
-	val, err := c.client.Get(ctx, key).Result()
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.client.Get(ctx, key).Bytes()
 	if err == redis.Nil {
 		return nil, pkgerrors.ErrNotFound
 	}
@@ -45,35 +73,127 @@ func (c *RedisCache) Get(key string) ([]int, error) {
 		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to get from cache")
 	}
 
-	var sizes []int
-	if err := json.Unmarshal([]byte(val), &sizes); err != nil {
-		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to unmarshal cache value")
+	return val, nil
+}
+
+// GetMany pipelines one GET per key rather than issuing MGET, so keys may
+// hash to different slots in cluster mode. Missing keys are omitted.
+func (c *RedisCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	cmds := make([]*redis.StringCmd, len(keys))
+	pipe := c.client.Pipeline()
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to get from cache")
 	}
 
-	return sizes, nil
+	for i, cmd := range cmds {
+		val, err := cmd.Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to get from cache")
+		}
+		values[keys[i]] = val
+	}
+
+	return values, nil
 }
 
-func (c *RedisCache) Set(key string, value []int, ttl int) error {
-	ctx := context.Background()
-	data, err := json.Marshal(value)
-	if err != nil {
-		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to marshal cache value")
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to set cache")
 	}
 
-	if err := c.client.Set(ctx, key, data, time.Duration(ttl)*time.Second).Err(); err != nil {
+	return nil
+}
+
+func (c *RedisCache) SetMany(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	pipe := c.client.Pipeline()
+	for key, value := range items {
+		pipe.Set(ctx, key, value, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
 		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to set cache")
 	}
 
 	return nil
 }
 
-func (c *RedisCache) Delete(key string) error {
-	ctx := context.Background()
-	if err := c.client.Del(ctx, key).Err(); err != nil {
+// Delete removes keys with one DEL per key so it stays valid in cluster mode.
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := c.client.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
 		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to delete from cache")
 	}
 
 	return nil
 }
 
+func (c *RedisCache) GetVersion(ctx context.Context, key string) (int, error) {
+	val, err := c.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return 0, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		return 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to get version from cache")
+	}
+
+	version, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to parse cached version")
+	}
+
+	return version, nil
+}
+
+// advanceVersionScript sets KEYS[1] to ARGV[1] unless it holds a greater
+// version; writing the same version refreshes the TTL. ARGV[2] is the TTL in
+// seconds; 0 means no expiry.
+var advanceVersionScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]))
+local version = tonumber(ARGV[1])
+if current ~= nil and current > version then
+	return 0
+end
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'EX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+func (c *RedisCache) AdvanceVersion(ctx context.Context, key string, version int, ttl time.Duration) error {
+	seconds := int(ttl / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	if err := advanceVersionScript.Run(ctx, c.client, []string{key}, version, seconds).Err(); err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to advance cached version")
+	}
+
+	return nil
+}
+
 var _ ports.Cache = (*RedisCache)(nil)
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"pack-calculator/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// universalOptions translates the Redis configuration into go-redis options.
+// When a URL is configured its address, credentials, database and TLS
+// settings are used as the base, with the remaining fields filling the gaps.
+func universalOptions(cfg config.RedisConfig) (*redis.UniversalOptions, error) {
+	opts := &redis.UniversalOptions{
+		Addrs:            cfg.Addresses(),
+		MasterName:       cfg.MasterName,
+		SentinelUsername: cfg.SentinelUsername,
+		SentinelPassword: cfg.SentinelPassword,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		DB:               cfg.DB,
+	}
+
+	if cfg.URL != "" {
+		if err := applyURL(opts, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig, err := tlsConfig(cfg.TLS, opts.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	return opts, nil
+}
+
+// applyURL overrides opts with the parts cfg.URL actually contains, so
+// credentials and the database given separately survive a URL without them.
+func applyURL(opts *redis.UniversalOptions, cfg config.RedisConfig) error {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return fmt.Errorf("failed to parse REDIS_URL: %w", err)
+	}
+
+	if cfg.Mode == config.RedisModeCluster {
+		parsed, err := redis.ParseClusterURL(cfg.URL)
+		if err != nil {
+			return fmt.Errorf("failed to parse REDIS_URL: %w", err)
+		}
+		opts.Addrs = parsed.Addrs
+		applyURLCredentials(opts, u, parsed.Username, parsed.Password)
+		if parsed.TLSConfig != nil {
+			opts.TLSConfig = parsed.TLSConfig
+		}
+		return nil
+	}
+
+	parsed, err := redis.ParseURL(cfg.URL)
+	if err != nil {
+		return fmt.Errorf("failed to parse REDIS_URL: %w", err)
+	}
+	opts.Addrs = []string{parsed.Addr}
+	applyURLCredentials(opts, u, parsed.Username, parsed.Password)
+	if strings.Trim(u.Path, "/") != "" || u.Query().Has("db") {
+		opts.DB = parsed.DB
+	}
+	if parsed.TLSConfig != nil {
+		opts.TLSConfig = parsed.TLSConfig
+	}
+	return nil
+}
+
+func applyURLCredentials(opts *redis.UniversalOptions, u *url.URL, username, password string) {
+	if u.User == nil {
+		return
+	}
+	if username != "" {
+		opts.Username = username
+	}
+	if _, ok := u.User.Password(); ok {
+		opts.Password = password
+	}
+}
+
+func tlsConfig(cfg config.TLSConfig, base *tls.Config) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base != nil {
+		tlsCfg = base.Clone()
+	}
+
+	if cfg.ServerName != "" {
+		tlsCfg.ServerName = cfg.ServerName
+	}
+	tlsCfg.InsecureSkipVerify = cfg.InsecureSkipVerify
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"pack-calculator/internal/config"
+	"pack-calculator/pkg/codec"
 	pkgerrors "pack-calculator/pkg/errors"
 )
 
@@ -14,7 +16,11 @@ func setupTestRedis(t *testing.T) *RedisCache {
 		t.Skip("Skipping integration test")
 	}
 
-	cache, err := NewRedisCache("localhost:6379", "", 0)
+	cache, err := NewRedisCache(config.RedisConfig{
+		Mode: config.RedisModeSingle,
+		Host: "localhost",
+		Port: 6379,
+	})
 	if err != nil {
 		t.Skipf("Skipping test: failed to connect to Redis: %v", err)
 	}
@@ -29,9 +35,10 @@ func setupTestRedis(t *testing.T) *RedisCache {
 func TestRedisCache_Get(t *testing.T) {
 	cache := setupTestRedis(t)
 	defer cache.Close()
+	ctx := context.Background()
 
 	t.Run("key not found returns ErrNotFound", func(t *testing.T) {
-		_, err := cache.Get("nonexistent")
+		_, err := cache.Get(ctx, "nonexistent")
 		if err == nil {
 			t.Error("Get() error = nil, want ErrNotFound")
 		}
@@ -42,14 +49,14 @@ func TestRedisCache_Get(t *testing.T) {
 
 	t.Run("get existing key", func(t *testing.T) {
 		key := "test:get"
-		value := []int{250, 500, 1000}
+		value := []byte("250,500,1000")
 
-		err := cache.Set(key, value, 60)
+		err := cache.Set(ctx, key, value, time.Minute)
 		if err != nil {
 			t.Fatalf("Set() error = %v", err)
 		}
 
-		got, err := cache.Get(key)
+		got, err := cache.Get(ctx, key)
 		if err != nil {
 			t.Errorf("Get() error = %v", err)
 		}
@@ -62,17 +69,18 @@ func TestRedisCache_Get(t *testing.T) {
 func TestRedisCache_Set(t *testing.T) {
 	cache := setupTestRedis(t)
 	defer cache.Close()
+	ctx := context.Background()
 
 	t.Run("set and get", func(t *testing.T) {
 		key := "test:set"
-		value := []int{100, 200, 300}
+		value := []byte("100,200,300")
 
-		err := cache.Set(key, value, 60)
+		err := cache.Set(ctx, key, value, time.Minute)
 		if err != nil {
 			t.Errorf("Set() error = %v", err)
 		}
 
-		got, err := cache.Get(key)
+		got, err := cache.Get(ctx, key)
 		if err != nil {
 			t.Errorf("Get() error = %v", err)
 		}
@@ -83,9 +91,9 @@ func TestRedisCache_Set(t *testing.T) {
 
 	t.Run("set with TTL", func(t *testing.T) {
 		key := "test:ttl"
-		value := []int{1, 2, 3}
+		value := []byte("1,2,3")
 
-		err := cache.Set(key, value, 1)
+		err := cache.Set(ctx, key, value, time.Second)
 		if err != nil {
 			t.Fatalf("Set() error = %v", err)
 		}
@@ -93,7 +101,7 @@ func TestRedisCache_Set(t *testing.T) {
 		// Wait for expiration
 		time.Sleep(2 * time.Second)
 
-		_, err = cache.Get(key)
+		_, err = cache.Get(ctx, key)
 		if err == nil {
 			t.Error("Get() after expiration error = nil, want error")
 		}
@@ -103,29 +111,30 @@ func TestRedisCache_Set(t *testing.T) {
 func TestRedisCache_Delete(t *testing.T) {
 	cache := setupTestRedis(t)
 	defer cache.Close()
+	ctx := context.Background()
 
 	t.Run("delete existing key", func(t *testing.T) {
 		key := "test:delete"
-		value := []int{250, 500}
+		value := []byte("250,500")
 
-		err := cache.Set(key, value, 60)
+		err := cache.Set(ctx, key, value, time.Minute)
 		if err != nil {
 			t.Fatalf("Set() error = %v", err)
 		}
 
-		err = cache.Delete(key)
+		err = cache.Delete(ctx, key)
 		if err != nil {
 			t.Errorf("Delete() error = %v", err)
 		}
 
-		_, err = cache.Get(key)
+		_, err = cache.Get(ctx, key)
 		if err == nil {
 			t.Error("Get() after delete error = nil, want error")
 		}
 	})
 
 	t.Run("delete nonexistent key", func(t *testing.T) {
-		err := cache.Delete("nonexistent")
+		err := cache.Delete(ctx, "nonexistent")
 		if err != nil {
 			t.Errorf("Delete() nonexistent key error = %v, want nil", err)
 		}
@@ -134,7 +143,11 @@ func TestRedisCache_Delete(t *testing.T) {
 
 func TestRedisCache_ErrorWrapping(t *testing.T) {
 	t.Run("invalid address returns error", func(t *testing.T) {
-		_, err := NewRedisCache("invalid:6379", "", 0)
+		_, err := NewRedisCache(config.RedisConfig{
+			Mode: config.RedisModeSingle,
+			Host: "invalid",
+			Port: 6379,
+		})
 		if err == nil {
 			t.Error("NewRedisCache() error = nil, want error")
 		}
@@ -146,9 +159,9 @@ func TestRedisCache_ErrorWrapping(t *testing.T) {
 
 		// Force an error by using invalid data
 		ctx := context.Background()
-		cache.client.Set(ctx, "invalid", "not json", time.Hour)
+		cache.client.Set(ctx, "json:invalid", "not json", time.Hour)
 
-		_, err := cache.Get("invalid")
+		_, err := NewTyped[[]int](cache, codec.JSON{}, "").Get(ctx, "invalid")
 		if err != nil {
 			// Error should be wrapped with ErrCache
 			if !errors.Is(err, pkgerrors.ErrCache) {
@@ -157,3 +170,55 @@ func TestRedisCache_ErrorWrapping(t *testing.T) {
 		}
 	})
 }
+
+func TestRedisCache_AdvanceVersion(t *testing.T) {
+	cache := setupTestRedis(t)
+	defer cache.Close()
+	ctx := context.Background()
+
+	key := "test:version"
+	if _, err := cache.GetVersion(ctx, key); !errors.Is(err, pkgerrors.ErrNotFound) {
+		t.Fatalf("GetVersion() error = %v, want ErrNotFound", err)
+	}
+
+	if err := cache.AdvanceVersion(ctx, key, 5, time.Minute); err != nil {
+		t.Fatalf("AdvanceVersion() error = %v", err)
+	}
+	if err := cache.AdvanceVersion(ctx, key, 4, time.Minute); err != nil {
+		t.Fatalf("AdvanceVersion() error = %v", err)
+	}
+
+	got, err := cache.GetVersion(ctx, key)
+	if err != nil {
+		t.Fatalf("GetVersion() error = %v", err)
+	}
+	if got != 5 {
+		t.Errorf("GetVersion() = %d, want 5", got)
+	}
+}
+
+func TestRedisCache_ManyAcrossKeys(t *testing.T) {
+	cache := setupTestRedis(t)
+	defer cache.Close()
+	ctx := context.Background()
+
+	items := map[string][]byte{"test:many:a": []byte("a"), "test:many:b": []byte("b")}
+	if err := cache.SetMany(ctx, items, time.Minute); err != nil {
+		t.Fatalf("SetMany() error = %v", err)
+	}
+
+	got, err := cache.GetMany(ctx, []string{"test:many:a", "test:many:b", "test:many:missing"})
+	if err != nil {
+		t.Fatalf("GetMany() error = %v", err)
+	}
+	if len(got) != 2 || string(got["test:many:a"]) != "a" || string(got["test:many:b"]) != "b" {
+		t.Errorf("GetMany() = %v, want a and b only", got)
+	}
+
+	if err := cache.Delete(ctx, "test:many:a", "test:many:b"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got, _ := cache.GetMany(ctx, []string{"test:many:a", "test:many:b"}); len(got) != 0 {
+		t.Errorf("GetMany() after delete = %v, want empty", got)
+	}
+}
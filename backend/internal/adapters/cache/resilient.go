@@ -0,0 +1,398 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/logger"
+)
+
+// Pinger is implemented by caches that can report server reachability.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type ResilientOptions struct {
+	// FailureThreshold is the number of consecutive errors that open the breaker.
+	FailureThreshold int
+	// RetryInterval is how long the breaker stays open and how often the
+	// background loop probes the primary cache while it is unavailable.
+	RetryInterval time.Duration
+}
+
+// ResilientCache guards a primary cache with a circuit breaker. While the
+// breaker is open calls are served by a no-op fallback, so a dead Redis costs
+// nothing on the request path, and a background loop probes the primary until
+// it recovers. Deletes and version advances issued during an outage are
+// replayed before the primary is used again, whether recovery is noticed by
+// the probe or by a request, so invalidations are not lost. Errors caused by
+// the caller's own context are returned as they are and leave the breaker
+// alone, so disconnecting clients cannot open it.
+type ResilientCache struct {
+	primary  ports.Cache
+	pinger   Pinger
+	fallback ports.Cache
+	breaker  *CircuitBreaker
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu              sync.Mutex
+	lastErr         error
+	pendingDeletes  map[string]struct{}
+	pendingVersions map[string]pendingVersion
+}
+
+type pendingVersion struct {
+	version int
+	ttl     time.Duration
+}
+
+func NewResilientCache(primary ports.Cache, pinger Pinger, opts ResilientOptions) *ResilientCache {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 3
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 10 * time.Second
+	}
+
+	return &ResilientCache{
+		primary:         primary,
+		pinger:          pinger,
+		fallback:        NewNoopCache(),
+		breaker:         NewCircuitBreaker(opts.FailureThreshold, opts.RetryInterval),
+		interval:        opts.RetryInterval,
+		logger:          logger.Default(),
+		pendingDeletes:  make(map[string]struct{}),
+		pendingVersions: make(map[string]pendingVersion),
+	}
+}
+
+func (c *ResilientCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if !c.allow(ctx) {
+		return c.fallback.Get(ctx, key)
+	}
+
+	value, err := c.primary.Get(ctx, key)
+	if c.gaveUp(ctx, err) {
+		return nil, err
+	}
+	if err != nil && !errors.Is(err, pkgerrors.ErrNotFound) {
+		c.recordFailure(err)
+		return c.fallback.Get(ctx, key)
+	}
+
+	c.recordSuccess()
+	return value, err
+}
+
+func (c *ResilientCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	if !c.allow(ctx) {
+		return c.fallback.GetMany(ctx, keys)
+	}
+
+	values, err := c.primary.GetMany(ctx, keys)
+	if c.gaveUp(ctx, err) {
+		return nil, err
+	}
+	if err != nil {
+		c.recordFailure(err)
+		return c.fallback.GetMany(ctx, keys)
+	}
+
+	c.recordSuccess()
+	return values, nil
+}
+
+func (c *ResilientCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if !c.allow(ctx) {
+		return c.fallback.Set(ctx, key, value, ttl)
+	}
+
+	err := c.primary.Set(ctx, key, value, ttl)
+	if c.gaveUp(ctx, err) {
+		return err
+	}
+	if err != nil {
+		c.recordFailure(err)
+		return c.fallback.Set(ctx, key, value, ttl)
+	}
+
+	c.recordSuccess()
+	return nil
+}
+
+func (c *ResilientCache) SetMany(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	if !c.allow(ctx) {
+		return c.fallback.SetMany(ctx, items, ttl)
+	}
+
+	err := c.primary.SetMany(ctx, items, ttl)
+	if c.gaveUp(ctx, err) {
+		return err
+	}
+	if err != nil {
+		c.recordFailure(err)
+		return c.fallback.SetMany(ctx, items, ttl)
+	}
+
+	c.recordSuccess()
+	return nil
+}
+
+func (c *ResilientCache) Delete(ctx context.Context, keys ...string) error {
+	if !c.allow(ctx) {
+		c.deferDelete(keys...)
+		return c.fallback.Delete(ctx, keys...)
+	}
+
+	err := c.primary.Delete(ctx, keys...)
+	if c.gaveUp(ctx, err) {
+		// The delete may not have happened; replay it rather than lose it.
+		c.deferDelete(keys...)
+		return err
+	}
+	if err != nil {
+		c.recordFailure(err)
+		c.deferDelete(keys...)
+		return c.fallback.Delete(ctx, keys...)
+	}
+
+	c.recordSuccess()
+	return nil
+}
+
+func (c *ResilientCache) GetVersion(ctx context.Context, key string) (int, error) {
+	if !c.allow(ctx) {
+		return c.fallback.GetVersion(ctx, key)
+	}
+
+	version, err := c.primary.GetVersion(ctx, key)
+	if c.gaveUp(ctx, err) {
+		return 0, err
+	}
+	if err != nil && !errors.Is(err, pkgerrors.ErrNotFound) {
+		c.recordFailure(err)
+		return c.fallback.GetVersion(ctx, key)
+	}
+
+	c.recordSuccess()
+	return version, err
+}
+
+func (c *ResilientCache) AdvanceVersion(ctx context.Context, key string, version int, ttl time.Duration) error {
+	if !c.allow(ctx) {
+		c.deferVersion(key, version, ttl)
+		return c.fallback.AdvanceVersion(ctx, key, version, ttl)
+	}
+
+	err := c.primary.AdvanceVersion(ctx, key, version, ttl)
+	if c.gaveUp(ctx, err) {
+		c.deferVersion(key, version, ttl)
+		return err
+	}
+	if err != nil {
+		c.recordFailure(err)
+		c.deferVersion(key, version, ttl)
+		return c.fallback.AdvanceVersion(ctx, key, version, ttl)
+	}
+
+	c.recordSuccess()
+	return nil
+}
+
+// Probe pings the primary cache and trips the breaker if it is unreachable.
+// On success any writes deferred during an outage are replayed.
+func (c *ResilientCache) Probe(ctx context.Context) error {
+	err := c.pinger.Ping(ctx)
+	if err == nil {
+		err = c.replayDeletes(ctx)
+	}
+	if err == nil {
+		err = c.replayVersions(ctx)
+	}
+	if err != nil {
+		c.setLastErr(err)
+		c.breaker.Trip()
+		return err
+	}
+
+	if c.breaker.State() != BreakerClosed {
+		c.logger.Info("Cache recovered, leaving degraded mode")
+	}
+	c.recordSuccess()
+	return nil
+}
+
+// Run probes the primary cache in the background while it is unavailable.
+// It returns when ctx is cancelled.
+func (c *ResilientCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if c.breaker.State() == BreakerClosed && !c.hasPending() {
+				continue
+			}
+			probeCtx, cancel := context.WithTimeout(ctx, c.interval)
+			if err := c.Probe(probeCtx); err != nil {
+				c.logger.Warn("Cache still unavailable", "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// allow reports whether the primary may be used, first replaying writes
+// deferred during an outage; a read must not see entries the outage failed
+// to invalidate.
+func (c *ResilientCache) allow(ctx context.Context) bool {
+	if !c.breaker.Allow() {
+		return false
+	}
+	if !c.hasPending() {
+		return true
+	}
+	err := c.replayDeletes(ctx)
+	if err == nil {
+		err = c.replayVersions(ctx)
+	}
+	if c.gaveUp(ctx, err) {
+		return false
+	}
+	if err != nil {
+		c.recordFailure(err)
+		return false
+	}
+	return true
+}
+
+func (c *ResilientCache) hasPending() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.pendingDeletes) > 0 || len(c.pendingVersions) > 0
+}
+
+func (c *ResilientCache) Name() string {
+	return "cache"
+}
+
+// Check reports an error while the cache is running in degraded mode.
+func (c *ResilientCache) Check(ctx context.Context) error {
+	if c.breaker.State() == BreakerClosed {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lastErr != nil {
+		return pkgerrors.WrapWithDomain(c.lastErr, pkgerrors.ErrCache, "cache unavailable")
+	}
+	return pkgerrors.Wrap(pkgerrors.ErrCache, "cache unavailable")
+}
+
+// gaveUp reports whether err came from the caller's own context. Such errors
+// say nothing about the primary, so the breaker is left as it was.
+func (c *ResilientCache) gaveUp(ctx context.Context, err error) bool {
+	if !callerGaveUp(ctx, err) {
+		return false
+	}
+	c.breaker.Abandon()
+	return true
+}
+
+func (c *ResilientCache) recordFailure(err error) {
+	c.setLastErr(err)
+
+	wasClosed := c.breaker.State() == BreakerClosed
+	c.breaker.Failure()
+	if wasClosed && c.breaker.State() == BreakerOpen {
+		c.logger.Warn("Cache circuit opened, entering degraded mode", "error", err)
+	}
+}
+
+func (c *ResilientCache) recordSuccess() {
+	c.breaker.Success()
+	c.setLastErr(nil)
+}
+
+func (c *ResilientCache) setLastErr(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastErr = err
+}
+
+func (c *ResilientCache) deferDelete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		c.pendingDeletes[key] = struct{}{}
+	}
+}
+
+func (c *ResilientCache) replayDeletes(ctx context.Context) error {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.pendingDeletes))
+	for key := range c.pendingDeletes {
+		keys = append(keys, key)
+	}
+	c.mu.Unlock()
+
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := c.primary.Delete(ctx, keys...); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	for _, key := range keys {
+		delete(c.pendingDeletes, key)
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *ResilientCache) deferVersion(key string, version int, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pending, ok := c.pendingVersions[key]; ok && pending.version >= version {
+		return
+	}
+	c.pendingVersions[key] = pendingVersion{version: version, ttl: ttl}
+}
+
+func (c *ResilientCache) replayVersions(ctx context.Context) error {
+	c.mu.Lock()
+	pending := make(map[string]pendingVersion, len(c.pendingVersions))
+	for key, value := range c.pendingVersions {
+		pending[key] = value
+	}
+	c.mu.Unlock()
+
+	for key, value := range pending {
+		if err := c.primary.AdvanceVersion(ctx, key, value.version, value.ttl); err != nil {
+			return err
+		}
+		c.mu.Lock()
+		if c.pendingVersions[key] == value {
+			delete(c.pendingVersions, key)
+		}
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+var _ ports.Cache = (*ResilientCache)(nil)
@@ -0,0 +1,298 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+type fakeCache struct {
+	data     map[string][]byte
+	versions map[string]int
+	err      error
+	calls    int
+	deleted  []string
+	pingErr  error
+	pingHits int
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{data: make(map[string][]byte), versions: make(map[string]int)}
+}
+
+func (f *fakeCache) Get(ctx context.Context, key string) ([]byte, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	value, ok := f.data[key]
+	if !ok {
+		return nil, pkgerrors.ErrNotFound
+	}
+	return value, nil
+}
+
+func (f *fakeCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	values := make(map[string][]byte)
+	for _, key := range keys {
+		if value, ok := f.data[key]; ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func (f *fakeCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	f.data[key] = value
+	return nil
+}
+
+func (f *fakeCache) SetMany(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	for key, value := range items {
+		f.data[key] = value
+	}
+	return nil
+}
+
+func (f *fakeCache) Delete(ctx context.Context, keys ...string) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	for _, key := range keys {
+		f.deleted = append(f.deleted, key)
+		delete(f.data, key)
+	}
+	return nil
+}
+
+func (f *fakeCache) GetVersion(ctx context.Context, key string) (int, error) {
+	f.calls++
+	if f.err != nil {
+		return 0, f.err
+	}
+	version, ok := f.versions[key]
+	if !ok {
+		return 0, pkgerrors.ErrNotFound
+	}
+	return version, nil
+}
+
+func (f *fakeCache) AdvanceVersion(ctx context.Context, key string, version int, ttl time.Duration) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	if current, ok := f.versions[key]; !ok || current < version {
+		f.versions[key] = version
+	}
+	return nil
+}
+
+func (f *fakeCache) Ping(ctx context.Context) error {
+	f.pingHits++
+	return f.pingErr
+}
+
+func TestResilientCache_OpensAfterThreshold(t *testing.T) {
+	ctx := context.Background()
+	primary := newFakeCache()
+	primary.err = errors.New("connection refused")
+	c := NewResilientCache(primary, primary, ResilientOptions{FailureThreshold: 2, RetryInterval: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get(ctx, "key"); !errors.Is(err, pkgerrors.ErrNotFound) {
+			t.Fatalf("Get() error = %v, want ErrNotFound from fallback", err)
+		}
+	}
+	if primary.calls != 2 {
+		t.Fatalf("primary calls = %d, want 2", primary.calls)
+	}
+
+	// Breaker is open: further calls must not reach the primary.
+	c.Get(ctx, "key")
+	c.Set(ctx, "key", []byte("1"), time.Minute)
+	if primary.calls != 2 {
+		t.Errorf("primary calls after open = %d, want 2", primary.calls)
+	}
+	if err := c.Check(ctx); !errors.Is(err, pkgerrors.ErrCache) {
+		t.Errorf("Check() error = %v, want ErrCache", err)
+	}
+}
+
+func TestResilientCache_MissIsNotFailure(t *testing.T) {
+	ctx := context.Background()
+	primary := newFakeCache()
+	c := NewResilientCache(primary, primary, ResilientOptions{FailureThreshold: 1, RetryInterval: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get(ctx, "missing"); !errors.Is(err, pkgerrors.ErrNotFound) {
+			t.Fatalf("Get() error = %v, want ErrNotFound", err)
+		}
+	}
+	if primary.calls != 3 {
+		t.Errorf("primary calls = %d, want 3", primary.calls)
+	}
+	if err := c.Check(ctx); err != nil {
+		t.Errorf("Check() error = %v, want nil", err)
+	}
+}
+
+func TestResilientCache_CancelledCallsLeaveBreakerClosed(t *testing.T) {
+	primary := newFakeCache()
+	primary.err = context.Canceled
+	c := NewResilientCache(primary, primary, ResilientOptions{FailureThreshold: 1, RetryInterval: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get(ctx, "key"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Get() error = %v, want context.Canceled", err)
+		}
+		if err := c.Set(ctx, "key", []byte("1"), time.Minute); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Set() error = %v, want context.Canceled", err)
+		}
+	}
+
+	if state := c.breaker.State(); state != BreakerClosed {
+		t.Errorf("breaker state = %s, want closed", state)
+	}
+	if err := c.Check(context.Background()); err != nil {
+		t.Errorf("Check() error = %v, want nil", err)
+	}
+
+	primary.err = nil
+	if _, err := c.Get(context.Background(), "key"); !errors.Is(err, pkgerrors.ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound from the primary", err)
+	}
+	if primary.calls != 7 {
+		t.Errorf("primary calls = %d, want 7", primary.calls)
+	}
+}
+
+func TestResilientCache_ProbeRecoversAndReplaysDeletes(t *testing.T) {
+	ctx := context.Background()
+	primary := newFakeCache()
+	primary.pingErr = errors.New("connection refused")
+	c := NewResilientCache(primary, primary, ResilientOptions{FailureThreshold: 1, RetryInterval: time.Hour})
+
+	if err := c.Probe(ctx); err == nil {
+		t.Fatal("Probe() error = nil, want error")
+	}
+
+	// Writes while degraded are deferred rather than lost.
+	if err := c.Delete(ctx, "pack-sizes:active"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	c.AdvanceVersion(ctx, "pack-sizes:current", 3, time.Minute)
+	c.AdvanceVersion(ctx, "pack-sizes:current", 2, time.Minute)
+	if len(primary.deleted) != 0 || len(primary.versions) != 0 {
+		t.Fatalf("primary written while degraded: deleted %v, versions %v", primary.deleted, primary.versions)
+	}
+
+	primary.pingErr = nil
+	if err := c.Probe(ctx); err != nil {
+		t.Fatalf("Probe() error = %v, want nil", err)
+	}
+	if len(primary.deleted) != 1 || primary.deleted[0] != "pack-sizes:active" {
+		t.Errorf("primary deleted = %v, want [pack-sizes:active]", primary.deleted)
+	}
+	if primary.versions["pack-sizes:current"] != 3 {
+		t.Errorf("primary version = %d, want 3", primary.versions["pack-sizes:current"])
+	}
+	if err := c.Check(ctx); err != nil {
+		t.Errorf("Check() after recovery error = %v, want nil", err)
+	}
+
+	primary.data["key"] = []byte("250")
+	got, err := c.Get(ctx, "key")
+	if err != nil || string(got) != "250" {
+		t.Errorf("Get() after recovery = %v, %v", got, err)
+	}
+}
+
+func TestResilientCache_RequestRecoveryReplaysDeletes(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	primary := newFakeCache()
+	primary.data["pack-sizes:active"] = []byte("stale")
+	c := NewResilientCache(primary, primary, ResilientOptions{FailureThreshold: 1, RetryInterval: time.Minute})
+	c.breaker.now = func() time.Time { return now }
+
+	primary.err = errors.New("connection refused")
+	c.Delete(ctx, "pack-sizes:active")
+	c.AdvanceVersion(ctx, "pack-sizes:current", 3, time.Minute)
+	if c.breaker.State() != BreakerOpen {
+		t.Fatalf("State() = %v, want open", c.breaker.State())
+	}
+
+	// Redis is back and the cooldown is over, but the probe has not run: the
+	// first request's trial call closes the breaker.
+	primary.err = nil
+	now = now.Add(time.Minute)
+	if _, err := c.Get(ctx, "pack-sizes:active"); !errors.Is(err, pkgerrors.ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound once the deferred delete is replayed", err)
+	}
+	if c.breaker.State() != BreakerClosed {
+		t.Errorf("State() = %v, want closed", c.breaker.State())
+	}
+	if len(primary.deleted) != 1 || primary.deleted[0] != "pack-sizes:active" {
+		t.Errorf("primary deleted = %v, want [pack-sizes:active]", primary.deleted)
+	}
+	if primary.versions["pack-sizes:current"] != 3 {
+		t.Errorf("primary version = %d, want 3", primary.versions["pack-sizes:current"])
+	}
+	if c.hasPending() {
+		t.Error("writes still pending after recovery")
+	}
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	if b.Allow() {
+		t.Fatal("Allow() = true while open, want false")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.Allow() {
+		t.Fatal("Allow() = false after cooldown, want trial call")
+	}
+	if b.Allow() {
+		t.Error("Allow() = true for second concurrent trial, want false")
+	}
+
+	b.Failure()
+	if b.State() != BreakerOpen {
+		t.Errorf("State() = %v after failed trial, want open", b.State())
+	}
+
+	now = now.Add(time.Minute)
+	b.Allow()
+	b.Abandon()
+	if b.State() != BreakerHalfOpen || !b.Allow() {
+		t.Errorf("State() = %v after abandoned trial, want half-open with a new trial allowed", b.State())
+	}
+	b.Success()
+	if b.State() != BreakerClosed {
+		t.Errorf("State() = %v after successful trial, want closed", b.State())
+	}
+}
@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"pack-calculator/internal/ports"
+	"pack-calculator/pkg/logger"
+)
+
+type TieredOptions struct {
+	// LocalTTL caps how long an entry lives in the local tier. It also bounds
+	// staleness when an invalidation message is lost, e.g. during a Redis outage.
+	LocalTTL time.Duration
+}
+
+// TieredCache serves reads from an in-process cache and falls back to a
+// shared remote cache. Deletes and version advances are broadcast so every
+// instance drops its local copy.
+type TieredCache struct {
+	local       *MemoryCache
+	remote      ports.Cache
+	invalidator Invalidator
+	localTTL    time.Duration
+	logger      *slog.Logger
+}
+
+func NewTieredCache(local *MemoryCache, remote ports.Cache, invalidator Invalidator, opts TieredOptions) *TieredCache {
+	localTTL := opts.LocalTTL
+	if localTTL <= 0 {
+		localTTL = 30 * time.Second
+	}
+
+	return &TieredCache{
+		local:       local,
+		remote:      remote,
+		invalidator: invalidator,
+		localTTL:    localTTL,
+		logger:      logger.Default(),
+	}
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, err := c.local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	value, err := c.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.local.Set(ctx, key, value, c.localTTL)
+	return value, nil
+}
+
+// GetMany only asks the remote tier for keys missing locally.
+func (c *TieredCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	values, _ := c.local.GetMany(ctx, keys)
+	if len(values) == len(keys) {
+		return values, nil
+	}
+
+	missing := make([]string, 0, len(keys)-len(values))
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+
+	remote, err := c.remote.GetMany(ctx, missing)
+	if err != nil {
+		return values, err
+	}
+
+	c.local.SetMany(ctx, remote, c.localTTL)
+	for key, value := range remote {
+		values[key] = value
+	}
+	return values, nil
+}
+
+func (c *TieredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := c.remote.Set(ctx, key, value, ttl)
+	c.local.Set(ctx, key, value, c.ttlFor(ttl))
+	return err
+}
+
+func (c *TieredCache) SetMany(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	err := c.remote.SetMany(ctx, items, ttl)
+	c.local.SetMany(ctx, items, c.ttlFor(ttl))
+	return err
+}
+
+func (c *TieredCache) Delete(ctx context.Context, keys ...string) error {
+	c.local.Delete(ctx, keys...)
+	err := c.remote.Delete(ctx, keys...)
+	for _, key := range keys {
+		c.broadcast(ctx, key)
+	}
+	return err
+}
+
+func (c *TieredCache) broadcast(ctx context.Context, key string) {
+	if c.invalidator == nil {
+		return
+	}
+	if err := c.invalidator.Publish(ctx, key); err != nil {
+		c.logger.Warn("Failed to broadcast cache invalidation", "error", err, "key", key)
+	}
+}
+
+func (c *TieredCache) GetVersion(ctx context.Context, key string) (int, error) {
+	if version, err := c.local.GetVersion(ctx, key); err == nil {
+		return version, nil
+	}
+
+	version, err := c.remote.GetVersion(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	c.local.AdvanceVersion(ctx, key, version, c.localTTL)
+	return version, nil
+}
+
+func (c *TieredCache) AdvanceVersion(ctx context.Context, key string, version int, ttl time.Duration) error {
+	err := c.remote.AdvanceVersion(ctx, key, version, ttl)
+	c.local.AdvanceVersion(ctx, key, version, c.ttlFor(ttl))
+	c.broadcast(ctx, key)
+	return err
+}
+
+// Purge drops every entry from the local tier.
+func (c *TieredCache) Purge() {
+	c.local.Purge()
+}
+
+// Run applies invalidations from other instances until ctx is cancelled.
+func (c *TieredCache) Run(ctx context.Context) {
+	if c.invalidator == nil {
+		return
+	}
+
+	for {
+		err := c.invalidator.Subscribe(ctx, func(key string) {
+			c.local.Delete(ctx, key)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			c.logger.Warn("Cache invalidation subscription failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (c *TieredCache) ttlFor(ttl time.Duration) time.Duration {
+	if ttl > 0 && ttl < c.localTTL {
+		return ttl
+	}
+	return c.localTTL
+}
+
+var _ ports.Cache = (*TieredCache)(nil)
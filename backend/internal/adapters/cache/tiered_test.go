@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// memoryBus is an in-process stand-in for Redis pub/sub shared by instances.
+type memoryBus struct {
+	mu          sync.Mutex
+	subscribers []chan string
+}
+
+func (b *memoryBus) Publish(ctx context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range b.subscribers {
+		ch <- key
+	}
+	return nil
+}
+
+func (b *memoryBus) Subscribe(ctx context.Context, handle func(key string)) error {
+	ch := make(chan string, 16)
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, ch)
+	b.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case key := <-ch:
+			handle(key)
+		}
+	}
+}
+
+func (b *memoryBus) subscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+func TestTieredCache_LocalHitSkipsRemote(t *testing.T) {
+	ctx := context.Background()
+	remote := newFakeCache()
+	remote.data["key"] = []byte("250,500")
+	c := NewTieredCache(NewMemoryCache(10), remote, nil, TieredOptions{LocalTTL: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		got, err := c.Get(ctx, "key")
+		if err != nil || string(got) != "250,500" {
+			t.Fatalf("Get() = %v, %v", got, err)
+		}
+	}
+	if remote.calls != 1 {
+		t.Errorf("remote calls = %d, want 1", remote.calls)
+	}
+}
+
+func TestTieredCache_MissPropagates(t *testing.T) {
+	ctx := context.Background()
+	remote := newFakeCache()
+	c := NewTieredCache(NewMemoryCache(10), remote, nil, TieredOptions{LocalTTL: time.Minute})
+
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, pkgerrors.ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTieredCache_DeleteInvalidatesOtherInstances(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := &memoryBus{}
+	remote := newFakeCache()
+	remote.data["pack-sizes:active"] = []byte("250,500")
+
+	instanceA := NewTieredCache(NewMemoryCache(10), remote, bus, TieredOptions{LocalTTL: time.Minute})
+	instanceB := NewTieredCache(NewMemoryCache(10), remote, bus, TieredOptions{LocalTTL: time.Minute})
+	go instanceA.Run(ctx)
+	go instanceB.Run(ctx)
+	for bus.subscriberCount() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	instanceA.Get(ctx, "pack-sizes:active")
+	instanceB.Get(ctx, "pack-sizes:active")
+	if instanceB.local.Len() != 1 {
+		t.Fatalf("instance B local entries = %d, want 1", instanceB.local.Len())
+	}
+
+	if err := instanceA.Delete(ctx, "pack-sizes:active"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for instanceB.local.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("instance B kept its local copy after invalidation")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := instanceB.Get(ctx, "pack-sizes:active"); !errors.Is(err, pkgerrors.ErrNotFound) {
+		t.Errorf("instance B Get() error = %v, want ErrNotFound", err)
+	}
+}
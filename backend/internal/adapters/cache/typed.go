@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// Typed adds serialization on top of a byte-oriented cache. Values are keyed
+// under the codec's name, placed after the key prefix so the prefix stays the
+// first segment: prefix:codec:name. Instances running different codecs
+// against one Redis, as during a rolling deploy, then miss each other's
+// entries rather than failing to decode them. Version pointers hold no
+// encoded value, so they are shared by all codecs and passed through
+// unchanged.
+type Typed[T any] struct {
+	store  ports.Cache
+	codec  ports.Codec
+	prefix string
+}
+
+// NewTyped wraps store with codec. prefix is the key prefix callers put in
+// front of their keys; empty means none.
+func NewTyped[T any](store ports.Cache, codec ports.Codec, prefix string) *Typed[T] {
+	return &Typed[T]{store: store, codec: codec, prefix: prefix}
+}
+
+func (c *Typed[T]) Get(ctx context.Context, key string) (T, error) {
+	var value T
+	data, err := c.store.Get(ctx, c.key(key))
+	if err != nil {
+		return value, err
+	}
+
+	if err := c.codec.Unmarshal(data, &value); err != nil {
+		return value, pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to unmarshal cache value")
+	}
+	return value, nil
+}
+
+// GetMany skips entries that fail to decode, treating them as misses.
+func (c *Typed[T]) GetMany(ctx context.Context, keys []string) (map[string]T, error) {
+	found, err := c.store.GetMany(ctx, c.keys(keys))
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]T, len(found))
+	for _, key := range keys {
+		data, ok := found[c.key(key)]
+		if !ok {
+			continue
+		}
+		var value T
+		if err := c.codec.Unmarshal(data, &value); err != nil {
+			continue
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func (c *Typed[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to marshal cache value")
+	}
+	return c.store.Set(ctx, c.key(key), data, ttl)
+}
+
+func (c *Typed[T]) SetMany(ctx context.Context, items map[string]T, ttl time.Duration) error {
+	encoded := make(map[string][]byte, len(items))
+	for key, value := range items {
+		data, err := c.codec.Marshal(value)
+		if err != nil {
+			return pkgerrors.WrapWithDomain(err, pkgerrors.ErrCache, "failed to marshal cache value")
+		}
+		encoded[c.key(key)] = data
+	}
+	return c.store.SetMany(ctx, encoded, ttl)
+}
+
+func (c *Typed[T]) Delete(ctx context.Context, keys ...string) error {
+	return c.store.Delete(ctx, c.keys(keys)...)
+}
+
+func (c *Typed[T]) GetVersion(ctx context.Context, key string) (int, error) {
+	return c.store.GetVersion(ctx, key)
+}
+
+func (c *Typed[T]) AdvanceVersion(ctx context.Context, key string, version int, ttl time.Duration) error {
+	return c.store.AdvanceVersion(ctx, key, version, ttl)
+}
+
+// key places a value key under the codec's name, after the key prefix.
+func (c *Typed[T]) key(key string) string {
+	if c.prefix != "" {
+		if name, ok := strings.CutPrefix(key, c.prefix+":"); ok {
+			return c.prefix + ":" + c.codec.Name() + ":" + name
+		}
+	}
+	return c.codec.Name() + ":" + key
+}
+
+func (c *Typed[T]) keys(keys []string) []string {
+	encoded := make([]string, len(keys))
+	for i, key := range keys {
+		encoded[i] = c.key(key)
+	}
+	return encoded
+}
+
+var _ ports.TypedCache[[]int] = (*Typed[[]int])(nil)
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/ports"
+	"pack-calculator/pkg/codec"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+func TestTyped_RoundTrip(t *testing.T) {
+	for _, c := range []ports.Codec{codec.JSON{}, codec.Msgpack{}} {
+		t.Run(c.Name(), func(t *testing.T) {
+			ctx := context.Background()
+			typed := NewTyped[[]int](NewMemoryCache(10), c, "")
+
+			if err := typed.Set(ctx, "a", []int{250, 500}, time.Minute); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			got, err := typed.Get(ctx, "a")
+			if err != nil || !reflect.DeepEqual(got, []int{250, 500}) {
+				t.Errorf("Get() = %v, %v, want [250 500]", got, err)
+			}
+
+			typed.SetMany(ctx, map[string][]int{"b": {1}, "c": {2, 3}}, time.Minute)
+			many, err := typed.GetMany(ctx, []string{"a", "b", "c", "missing"})
+			if err != nil || len(many) != 3 || !reflect.DeepEqual(many["c"], []int{2, 3}) {
+				t.Errorf("GetMany() = %v, %v", many, err)
+			}
+
+			typed.Delete(ctx, "a")
+			if _, err := typed.Get(ctx, "a"); !errors.Is(err, pkgerrors.ErrNotFound) {
+				t.Errorf("Get() after Delete error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestTyped_DecodeErrorIsCacheError(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCache(10)
+	store.Set(ctx, "json:bad", []byte("not json"), time.Minute)
+
+	typed := NewTyped[[]int](store, codec.JSON{}, "")
+	if _, err := typed.Get(ctx, "bad"); !errors.Is(err, pkgerrors.ErrCache) {
+		t.Errorf("Get() error = %v, want ErrCache", err)
+	}
+	if many, err := typed.GetMany(ctx, []string{"bad"}); err != nil || len(many) != 0 {
+		t.Errorf("GetMany() = %v, %v, want undecodable entry skipped", many, err)
+	}
+}
+
+func TestTyped_CodecsDoNotShareEntries(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCache(10)
+	asJSON := NewTyped[[]int](store, codec.JSON{}, "")
+	asMsgpack := NewTyped[[]int](store, codec.Msgpack{}, "")
+
+	asJSON.Set(ctx, "sizes", []int{250}, time.Minute)
+	if _, err := asMsgpack.Get(ctx, "sizes"); !errors.Is(err, pkgerrors.ErrNotFound) {
+		t.Errorf("Get() with another codec error = %v, want ErrNotFound", err)
+	}
+
+	asMsgpack.Set(ctx, "sizes", []int{500}, time.Minute)
+	if got, err := asJSON.Get(ctx, "sizes"); err != nil || !reflect.DeepEqual(got, []int{250}) {
+		t.Errorf("Get() = %v, %v, want the JSON entry untouched", got, err)
+	}
+}
+
+func TestTyped_KeyLayout(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCache(10)
+	typed := NewTyped[[]int](store, codec.Msgpack{}, "pack-calculator")
+
+	typed.Set(ctx, "pack-calculator:pack-sizes:v1", []int{250}, time.Minute)
+	if _, err := store.Get(ctx, "pack-calculator:msgpack:pack-sizes:v1"); err != nil {
+		t.Errorf("value not stored under prefix:codec:name: %v", err)
+	}
+
+	typed.AdvanceVersion(ctx, "pack-calculator:pack-sizes:current", 1, time.Minute)
+	if version, err := store.GetVersion(ctx, "pack-calculator:pack-sizes:current"); err != nil || version != 1 {
+		t.Errorf("GetVersion() = %d, %v, want the pointer stored unchanged", version, err)
+	}
+}
@@ -0,0 +1,228 @@
+// Package jwks provides the key sets bearer tokens are verified against: a
+// JSON Web Key Set read from a file, or fetched from an issuer's URL.
+package jwks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/jwt"
+	"pack-calculator/pkg/logger"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// maxKeySetSize bounds how much of a key set document is read.
+const maxKeySetSize = 1 << 20
+
+func find(keys []jwt.Key, kid string) (jwt.Key, bool) {
+	if kid == "" {
+		if len(keys) == 1 {
+			return keys[0], true
+		}
+		return jwt.Key{}, false
+	}
+	for _, key := range keys {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+	return jwt.Key{}, false
+}
+
+// fileCheckInterval is how often FileKeySet looks for a changed file.
+const fileCheckInterval = 5 * time.Second
+
+// FileKeySet reads a key set from a file and rereads it when the file
+// changes, so keys can be rotated without a restart.
+type FileKeySet struct {
+	path string
+	now  func() time.Time
+
+	mu        sync.Mutex
+	keys      []jwt.Key
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// NewFileKeySet fails if the file is missing or not a valid key set.
+func NewFileKeySet(path string) (*FileKeySet, error) {
+	s := &FileKeySet{path: path, now: time.Now}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key set: %w", err)
+	}
+	if err := s.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+	s.checkedAt = s.now()
+	return s, nil
+}
+
+func (s *FileKeySet) load(modTime time.Time) error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read key set: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxKeySetSize))
+	if err != nil {
+		return fmt.Errorf("failed to read key set: %w", err)
+	}
+	keys, err := jwt.ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	s.keys, s.modTime = keys, modTime
+	return nil
+}
+
+func (s *FileKeySet) Key(ctx context.Context, kid string) (jwt.Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := s.now(); now.Sub(s.checkedAt) >= fileCheckInterval {
+		s.checkedAt = now
+		if info, err := os.Stat(s.path); err == nil && !info.ModTime().Equal(s.modTime) {
+			// A bad edit keeps the previous keys in service.
+			if err := s.load(info.ModTime()); err != nil {
+				logger.Default().Warn("Failed to reload key set, keeping previous keys", "path", s.path, "error", err)
+			}
+		}
+	}
+
+	if key, ok := find(s.keys, kid); ok {
+		return key, nil
+	}
+	return jwt.Key{}, pkgerrors.ErrNotFound
+}
+
+// RemoteKeySetOptions configures RemoteKeySet.
+type RemoteKeySetOptions struct {
+	// Refresh is how long fetched keys are used before fetching again.
+	Refresh time.Duration
+	// MinRefetch limits how often an unknown key id triggers a fetch, so
+	// tokens with made-up ids cannot flood the issuer.
+	MinRefetch time.Duration
+	Timeout    time.Duration
+}
+
+func DefaultRemoteKeySetOptions() RemoteKeySetOptions {
+	return RemoteKeySetOptions{
+		Refresh:    5 * time.Minute,
+		MinRefetch: 10 * time.Second,
+		Timeout:    5 * time.Second,
+	}
+}
+
+// RemoteKeySet fetches a key set from an issuer's jwks_uri. Keys are fetched
+// on first use, refreshed periodically and when a token names an unknown
+// key. If a refresh fails the previous keys stay in service.
+type RemoteKeySet struct {
+	url     string
+	client  *http.Client
+	options RemoteKeySetOptions
+	now     func() time.Time
+	fetches singleflight.Group
+
+	mu        sync.Mutex
+	keys      []jwt.Key
+	fetchedAt time.Time
+	// attemptedAt and fetchErr describe the last fetch attempt.
+	attemptedAt time.Time
+	fetchErr    error
+}
+
+func NewRemoteKeySet(url string, options RemoteKeySetOptions) *RemoteKeySet {
+	return &RemoteKeySet{
+		url:     url,
+		client:  &http.Client{Timeout: options.Timeout},
+		options: options,
+		now:     time.Now,
+	}
+}
+
+func (s *RemoteKeySet) Key(ctx context.Context, kid string) (jwt.Key, error) {
+	s.mu.Lock()
+	keys, fetchedAt, attemptedAt, fetchErr := s.keys, s.fetchedAt, s.attemptedAt, s.fetchErr
+	s.mu.Unlock()
+
+	now := s.now()
+	key, found := find(keys, kid)
+	stale := fetchedAt.IsZero() || now.Sub(fetchedAt) >= s.options.Refresh
+	if found && !stale {
+		return key, nil
+	}
+
+	if attemptedAt.IsZero() || now.Sub(attemptedAt) >= s.options.MinRefetch {
+		// The fetch outlives a caller that gives up, so the others can use it.
+		result, err, _ := s.fetches.Do("jwks", func() (interface{}, error) {
+			return s.fetch(context.WithoutCancel(ctx))
+		})
+		if err == nil {
+			keys, fetchErr = result.([]jwt.Key), nil
+		} else {
+			fetchErr = err
+			if keys != nil {
+				logger.Default().Warn("Failed to refresh key set, keeping previous keys", "url", s.url, "error", err)
+			}
+		}
+	}
+
+	if keys == nil && fetchErr != nil {
+		return jwt.Key{}, fetchErr
+	}
+	if key, ok := find(keys, kid); ok {
+		return key, nil
+	}
+	return jwt.Key{}, pkgerrors.ErrNotFound
+}
+
+func (s *RemoteKeySet) fetch(ctx context.Context) ([]jwt.Key, error) {
+	keys, err := s.download(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attemptedAt, s.fetchErr = s.now(), err
+	if err != nil {
+		return nil, err
+	}
+	s.keys, s.fetchedAt = keys, s.attemptedAt
+	return keys, nil
+}
+
+func (s *RemoteKeySet) download(ctx context.Context) ([]jwt.Key, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build key set request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("key set request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("key set request responded with status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxKeySetSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key set: %w", err)
+	}
+	return jwt.ParseJWKS(data)
+}
+
+var (
+	_ ports.KeySet = (*FileKeySet)(nil)
+	_ ports.KeySet = (*RemoteKeySet)(nil)
+)
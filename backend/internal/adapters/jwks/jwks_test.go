@@ -0,0 +1,152 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/jwt"
+)
+
+func keySetJSON(t *testing.T, kids ...string) []byte {
+	t.Helper()
+	keys := make([]jwt.Key, 0, len(kids))
+	for _, kid := range kids {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, jwt.Key{ID: kid, Alg: "ES256", Public: &key.PublicKey})
+	}
+	data, err := jwt.MarshalJWKS(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestFileKeySet_ReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, keySetJSON(t, "old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewFileKeySet(path)
+	if err != nil {
+		t.Fatalf("NewFileKeySet() error = %v", err)
+	}
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := keys.Key(ctx, "old"); err != nil {
+		t.Fatalf("Key(old) error = %v", err)
+	}
+	if _, err := keys.Key(ctx, ""); err != nil {
+		t.Errorf("Key(\"\") error = %v, want the only key", err)
+	}
+
+	if err := os.WriteFile(path, keySetJSON(t, "new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, now, now.Add(time.Minute))
+	now = now.Add(fileCheckInterval)
+	if _, err := keys.Key(ctx, "new"); err != nil {
+		t.Errorf("Key(new) after rotation error = %v", err)
+	}
+	if _, err := keys.Key(ctx, "old"); !errors.Is(err, pkgerrors.ErrNotFound) {
+		t.Errorf("Key(old) after rotation error = %v, want ErrNotFound", err)
+	}
+
+	// A broken file keeps the previous keys.
+	os.WriteFile(path, []byte("{"), 0o644)
+	os.Chtimes(path, now, now.Add(2*time.Minute))
+	now = now.Add(fileCheckInterval)
+	if _, err := keys.Key(ctx, "new"); err != nil {
+		t.Errorf("Key(new) after a bad edit error = %v", err)
+	}
+}
+
+func TestNewFileKeySet_RejectsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if _, err := NewFileKeySet(path); err == nil {
+		t.Error("NewFileKeySet() accepted a missing file")
+	}
+	os.WriteFile(path, []byte("not json"), 0o644)
+	if _, err := NewFileKeySet(path); err == nil {
+		t.Error("NewFileKeySet() accepted an invalid key set")
+	}
+}
+
+func TestRemoteKeySet_FetchesAndRefetches(t *testing.T) {
+	var fetches atomic.Int32
+	body := keySetJSON(t, "a")
+	fail := atomic.Bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	keys := NewRemoteKeySet(server.URL, DefaultRemoteKeySetOptions())
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := keys.Key(ctx, "a"); err != nil {
+		t.Fatalf("Key(a) error = %v", err)
+	}
+	keys.Key(ctx, "a")
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetches = %d, want 1 while keys are fresh", n)
+	}
+
+	// Unknown ids refetch, but no more than once per MinRefetch.
+	keys.Key(ctx, "b")
+	keys.Key(ctx, "b")
+	if _, err := keys.Key(ctx, "b"); !errors.Is(err, pkgerrors.ErrNotFound) {
+		t.Errorf("Key(b) error = %v, want ErrNotFound", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetches = %d, want 1 within MinRefetch of the last fetch", n)
+	}
+	now = now.Add(DefaultRemoteKeySetOptions().MinRefetch)
+	keys.Key(ctx, "b")
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("fetches = %d, want 2 after MinRefetch", n)
+	}
+
+	// A failed refresh keeps the previous keys in service.
+	fail.Store(true)
+	now = now.Add(DefaultRemoteKeySetOptions().Refresh)
+	if _, err := keys.Key(ctx, "a"); err != nil {
+		t.Errorf("Key(a) with the issuer down error = %v", err)
+	}
+}
+
+func TestRemoteKeySet_UnavailableWithoutKeys(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	keys := NewRemoteKeySet(server.URL, DefaultRemoteKeySetOptions())
+	for range 2 {
+		if _, err := keys.Key(context.Background(), "a"); err == nil || errors.Is(err, pkgerrors.ErrNotFound) {
+			t.Errorf("Key() error = %v, want the fetch error", err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// PostgresAPIKeyStore keeps API keys on the primary, so a revoked key stops
+// working without waiting for replication.
+type PostgresAPIKeyStore struct {
+	db *sql.DB
+}
+
+func NewPostgresAPIKeyStore(repo *PostgresRepository) *PostgresAPIKeyStore {
+	return &PostgresAPIKeyStore{db: repo.db}
+}
+
+const apiKeyColumns = "id, name, prefix, key_hash, role, created_at, revoked_at"
+
+func scanAPIKey(row rowScanner) (domain.APIKey, error) {
+	var key domain.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &key.Role, &key.CreatedAt, &key.RevokedAt)
+	return key, err
+}
+
+func (s *PostgresAPIKeyStore) CreateAPIKey(ctx context.Context, key domain.APIKey) (domain.APIKey, error) {
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, role)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+apiKeyColumns,
+		key.Name, key.Prefix, key.Hash, key.Role)
+
+	created, err := scanAPIKey(row)
+	if err != nil {
+		return domain.APIKey{}, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to create api key")
+	}
+	return created, nil
+}
+
+func (s *PostgresAPIKeyStore) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to list api keys")
+	}
+	defer rows.Close()
+
+	keys := []domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to scan api key")
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to list api keys")
+	}
+	return keys, nil
+}
+
+func (s *PostgresAPIKeyStore) GetActiveAPIKey(ctx context.Context, hash string) (domain.APIKey, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL", hash)
+
+	key, err := scanAPIKey(row)
+	if err == sql.ErrNoRows {
+		return domain.APIKey{}, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		return domain.APIKey{}, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to get api key")
+	}
+	return key, nil
+}
+
+func (s *PostgresAPIKeyStore) RevokeAPIKey(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to revoke api key")
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return pkgerrors.ErrNotFound
+	}
+	return nil
+}
+
+var _ ports.APIKeyStore = (*PostgresAPIKeyStore)(nil)
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_outbox_deliveries_due;
+DROP TABLE IF EXISTS outbox_deliveries;
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One row per event and sink. Rows are created by the dispatcher for every
+-- configured sink, so adding a sink later delivers the existing backlog.
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+    event_id BIGINT NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    sink TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    PRIMARY KEY (event_id, sink)
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_deliveries_due ON outbox_deliveries(next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_outbox_events_created_at;
+DROP TABLE IF EXISTS outbox_cursors;
@@ -0,0 +1,8 @@
+-- Per-sink high-water mark: every event up to event_id has its delivery row,
+-- so scheduling only scans the events after it.
+CREATE TABLE IF NOT EXISTS outbox_cursors (
+    sink TEXT PRIMARY KEY,
+    event_id BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_created_at ON outbox_events(created_at);
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const DefaultChangeChannel = "pack_sizes_changed"
+
+// PostgresNotifier publishes change events with NOTIFY and receives them with
+// LISTEN on a dedicated connection, so every replica sharing the database sees
+// every update without depending on Redis.
+type PostgresNotifier struct {
+	repo    *PostgresRepository
+	dsn     string
+	channel string
+	logger  *slog.Logger
+}
+
+func NewPostgresNotifier(repo *PostgresRepository, dsn string, channel string) *PostgresNotifier {
+	if channel == "" {
+		channel = DefaultChangeChannel
+	}
+	return &PostgresNotifier{
+		repo:    repo,
+		dsn:     dsn,
+		channel: channel,
+		logger:  logger.Default(),
+	}
+}
+
+func (n *PostgresNotifier) Publish(ctx context.Context, event domain.PackSizesChanged) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to marshal change event")
+	}
+
+	if _, err := n.repo.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", n.channel, string(payload)); err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to publish change event")
+	}
+	return nil
+}
+
+func (n *PostgresNotifier) Subscribe(ctx context.Context, handle func(domain.PackSizesChanged)) error {
+	conn, err := pgx.Connect(ctx, n.dsn)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to connect listener")
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{n.channel}.Sanitize()); err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to listen for change events")
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to receive change event")
+		}
+
+		var event domain.PackSizesChanged
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			n.logger.Warn("Ignoring malformed change event", "error", err, "payload", notification.Payload)
+			continue
+		}
+		handle(event)
+	}
+}
+
+var _ ports.ChangeNotifier = (*PostgresNotifier)(nil)
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// insertOutboxEvent records event in tx, so it is stored if and only if the
+// change it describes commits.
+func insertOutboxEvent(ctx context.Context, tx *sql.Tx, event domain.PackSizesChanged) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to marshal outbox event")
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO outbox_events (event_type, payload, created_at) VALUES ($1, $2, $3)",
+		domain.EventPackSizesChanged, string(payload), event.ChangedAt)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to insert outbox event")
+	}
+	return nil
+}
+
+// outboxSettle bounds how long an event may commit after it was created.
+// Events commit out of id order, so a sink's mark only passes events older
+// than this; a later commit with a lower id would otherwise be skipped.
+const outboxSettle = 5 * time.Minute
+
+// PostgresOutbox tracks outbox deliveries in the outbox_deliveries table. It
+// always uses the primary, and claims rows with SKIP LOCKED so several
+// dispatchers can share the work.
+type PostgresOutbox struct {
+	db *sql.DB
+}
+
+func NewPostgresOutbox(repo *PostgresRepository) *PostgresOutbox {
+	return &PostgresOutbox{db: repo.db}
+}
+
+func (o *PostgresOutbox) ClaimDeliveries(ctx context.Context, sinks []string, limit int, lease time.Duration) ([]domain.OutboxDelivery, error) {
+	if len(sinks) == 0 || limit <= 0 {
+		return nil, nil
+	}
+
+	if err := o.schedule(ctx, sinks); err != nil {
+		return nil, err
+	}
+
+	rows, err := o.db.QueryContext(ctx, `
+		WITH due AS (
+			SELECT event_id, sink
+			FROM outbox_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW() AND sink = ANY($1::text[])
+			ORDER BY event_id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE outbox_deliveries d
+		SET attempts = d.attempts + 1,
+			next_attempt_at = NOW() + make_interval(secs => $3)
+		FROM due, outbox_events e
+		WHERE d.event_id = due.event_id AND d.sink = due.sink AND e.id = d.event_id
+		RETURNING e.id, e.event_type, e.payload, e.created_at, d.sink, d.attempts
+	`, sinks, limit, lease.Seconds())
+	if err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to claim outbox deliveries")
+	}
+	defer rows.Close()
+
+	var deliveries []domain.OutboxDelivery
+	for rows.Next() {
+		var d domain.OutboxDelivery
+		var payload []byte
+		if err := rows.Scan(&d.Event.ID, &d.Event.Type, &payload, &d.Event.CreatedAt, &d.Sink, &d.Attempt); err != nil {
+			return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to scan outbox delivery")
+		}
+		d.Event.Payload = payload
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to read outbox deliveries")
+	}
+
+	return deliveries, nil
+}
+
+// schedule creates delivery rows for events after each sink's mark, then
+// advances the mark to just before the first event that may still be
+// passed by an uncommitted one. A new sink starts at zero and so receives
+// the retained backlog.
+func (o *PostgresOutbox) schedule(ctx context.Context, sinks []string) error {
+	_, err := o.db.ExecContext(ctx, `
+		INSERT INTO outbox_cursors (sink)
+		SELECT unnest($1::text[])
+		ON CONFLICT DO NOTHING
+	`, sinks)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to create outbox cursors")
+	}
+
+	// One statement, so the rows inserted and the mark advanced see the
+	// same events. Locking the cursors serializes concurrent dispatchers.
+	_, err = o.db.ExecContext(ctx, `
+		WITH cursors AS (
+			SELECT sink, event_id
+			FROM outbox_cursors
+			WHERE sink = ANY($1::text[])
+			FOR UPDATE
+		), scheduled AS (
+			INSERT INTO outbox_deliveries (event_id, sink)
+			SELECT e.id, c.sink
+			FROM cursors c
+			JOIN outbox_events e ON e.id > c.event_id
+			ON CONFLICT DO NOTHING
+		), marks AS (
+			SELECT c.sink, COALESCE(
+				MIN(e.id) FILTER (WHERE e.created_at >= NOW() - make_interval(secs => $2)) - 1,
+				MAX(e.id)
+			) AS event_id
+			FROM cursors c
+			JOIN outbox_events e ON e.id > c.event_id
+			GROUP BY c.sink
+		)
+		UPDATE outbox_cursors c
+		SET event_id = m.event_id
+		FROM marks m
+		WHERE c.sink = m.sink AND m.event_id > c.event_id
+	`, sinks, outboxSettle.Seconds())
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to schedule outbox deliveries")
+	}
+	return nil
+}
+
+func (o *PostgresOutbox) MarkDelivered(ctx context.Context, eventID int64, sink string) error {
+	_, err := o.db.ExecContext(ctx, `
+		UPDATE outbox_deliveries
+		SET status = 'delivered', delivered_at = NOW(), last_error = NULL
+		WHERE event_id = $1 AND sink = $2
+	`, eventID, sink)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to mark outbox delivery delivered")
+	}
+	return nil
+}
+
+func (o *PostgresOutbox) MarkFailed(ctx context.Context, eventID int64, sink string, cause error, retryAt time.Time, final bool) error {
+	status := "pending"
+	if final {
+		status = "failed"
+	}
+
+	_, err := o.db.ExecContext(ctx, `
+		UPDATE outbox_deliveries
+		SET status = $3, last_error = $4, next_attempt_at = $5
+		WHERE event_id = $1 AND sink = $2
+	`, eventID, sink, status, cause.Error(), retryAt)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to record outbox delivery failure")
+	}
+	return nil
+}
+
+func (o *PostgresOutbox) Prune(ctx context.Context, sinks []string, before time.Time, limit int) (int, error) {
+	// Only events every sink has scheduled and none still has pending;
+	// deleting an event removes its deliveries with it.
+	result, err := o.db.ExecContext(ctx, `
+		DELETE FROM outbox_events
+		WHERE id IN (
+			SELECT e.id
+			FROM outbox_events e
+			WHERE e.created_at < $2
+				AND e.id <= (SELECT COALESCE(MIN(event_id), 0) FROM outbox_cursors WHERE sink = ANY($1::text[]))
+				AND NOT EXISTS (
+					SELECT 1 FROM outbox_deliveries d
+					WHERE d.event_id = e.id AND d.sink = ANY($1::text[]) AND d.status = 'pending'
+				)
+			ORDER BY e.id
+			LIMIT $3
+		)
+	`, sinks, before, limit)
+	if err != nil {
+		return 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to prune outbox events")
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to prune outbox events")
+	}
+	return int(n), nil
+}
+
+var _ ports.OutboxStore = (*PostgresOutbox)(nil)
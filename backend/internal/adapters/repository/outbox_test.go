@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/domain"
+)
+
+func TestPostgresOutbox_CreateRecordsEvent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	dsn := "host=localhost port=5432 user=packcalc password=packcalc dbname=packcalc_test sslmode=disable"
+	repo, err := NewPostgresRepository(dsn)
+	if err != nil {
+		t.Skipf("Skipping test: failed to connect to database: %v", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	repo.db.ExecContext(ctx, "DELETE FROM outbox_events")
+	repo.db.ExecContext(ctx, "DELETE FROM outbox_cursors")
+	outbox := NewPostgresOutbox(repo)
+
+	version, err := repo.Create(ctx, []int{250, 500})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	claimed, err := outbox.ClaimDeliveries(ctx, []string{"log"}, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDeliveries() error = %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].Event.Type != domain.EventPackSizesChanged || claimed[0].Attempt != 1 {
+		t.Fatalf("ClaimDeliveries() = %+v, want one first attempt", claimed)
+	}
+	var event domain.PackSizesChanged
+	if err := json.Unmarshal(claimed[0].Event.Payload, &event); err != nil || event.Version != version {
+		t.Errorf("payload = %s, want version %d", claimed[0].Event.Payload, version)
+	}
+
+	// Leased deliveries are not handed out twice.
+	if again, _ := outbox.ClaimDeliveries(ctx, []string{"log"}, 10, time.Minute); len(again) != 0 {
+		t.Errorf("second ClaimDeliveries() = %+v, want none while leased", again)
+	}
+
+	id := claimed[0].Event.ID
+	if err := outbox.MarkFailed(ctx, id, "log", errors.New("boom"), time.Now().Add(-time.Second), false); err != nil {
+		t.Fatalf("MarkFailed() error = %v", err)
+	}
+	retried, _ := outbox.ClaimDeliveries(ctx, []string{"log"}, 10, time.Minute)
+	if len(retried) != 1 || retried[0].Attempt != 2 {
+		t.Fatalf("ClaimDeliveries() after failure = %+v, want attempt 2", retried)
+	}
+
+	if err := outbox.MarkDelivered(ctx, id, "log"); err != nil {
+		t.Fatalf("MarkDelivered() error = %v", err)
+	}
+	var status string
+	repo.db.QueryRowContext(ctx, "SELECT status FROM outbox_deliveries WHERE event_id = $1 AND sink = 'log'", id).Scan(&status)
+	if status != "delivered" {
+		t.Errorf("status = %q, want delivered", status)
+	}
+
+	// The event is kept until it has settled past the sink's mark.
+	if n, err := outbox.Prune(ctx, []string{"log"}, time.Now().Add(time.Hour), 10); err != nil || n != 0 {
+		t.Errorf("Prune() before the mark = %d, %v, want 0", n, err)
+	}
+	repo.db.ExecContext(ctx, "UPDATE outbox_events SET created_at = NOW() - INTERVAL '1 hour' WHERE id = $1", id)
+	outbox.ClaimDeliveries(ctx, []string{"log"}, 10, time.Minute)
+	if n, err := outbox.Prune(ctx, []string{"log"}, time.Now(), 10); err != nil || n != 1 {
+		t.Errorf("Prune() = %d, %v, want the delivered event deleted", n, err)
+	}
+}
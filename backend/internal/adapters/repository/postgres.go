@@ -4,56 +4,128 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"pack-calculator/internal/domain"
 	"pack-calculator/internal/ports"
+	"pack-calculator/pkg/consistency"
 	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/logger"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
+// PostgresRepository writes to the primary and spreads reads across replicas,
+// falling back to the primary when a replica fails. Reads in a consistency
+// session that has already written go to the primary.
 type PostgresRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	replicas []*sql.DB
+	next     atomic.Uint64
+	logger   *slog.Logger
 }
 
-func NewPostgresRepository(dsn string) (*PostgresRepository, error) {
+// NewPostgresRepository connects to the primary at dsn and fails if it is
+// unreachable. Replicas are optional: one that is down at startup is only
+// skipped by the fallback until it recovers.
+func NewPostgresRepository(dsn string, replicaDSNs ...string) (*PostgresRepository, error) {
 	db, err := sql.Open("pgx", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &PostgresRepository{db: db}, nil
+	repo := &PostgresRepository{db: db, logger: logger.Default()}
+	for i, replicaDSN := range replicaDSNs {
+		replica, err := sql.Open("pgx", replicaDSN)
+		if err != nil {
+			repo.Close()
+			return nil, fmt.Errorf("failed to open replica %d: %w", i, err)
+		}
+		if err := replica.Ping(); err != nil {
+			repo.logger.Warn("Replica unavailable, reads will fall back to the primary", "replica", i, "error", err)
+		}
+		repo.replicas = append(repo.replicas, replica)
+	}
+
+	return repo, nil
 }
 
 func (r *PostgresRepository) Close() error {
-	return r.db.Close()
+	err := r.db.Close()
+	for _, replica := range r.replicas {
+		replica.Close()
+	}
+	return err
 }
 
-func (r *PostgresRepository) GetAllActive() ([]int, error) {
-	ctx := context.Background()
+// readers returns the databases to try for a read, in order: the next
+// replica in rotation followed by the primary, or only the primary when the
+// session has written and must read its own writes.
+func (r *PostgresRepository) readers(ctx context.Context) []*sql.DB {
+	if len(r.replicas) == 0 || consistency.Wrote(ctx) {
+		return []*sql.DB{r.db}
+	}
+
+	replica := r.replicas[(r.next.Add(1)-1)%uint64(len(r.replicas))]
+	return []*sql.DB{replica, r.db}
+}
+
+// read runs query against each reader until one succeeds. sql.ErrNoRows is
+// an answer, not a failure, and is returned as is.
+func (r *PostgresRepository) read(ctx context.Context, query func(db *sql.DB) error) error {
+	var err error
+	for i, db := range r.readers(ctx) {
+		if i > 0 {
+			r.logger.Warn("Replica read failed, falling back to the primary", "error", err)
+		}
+		err = query(db)
+		if err == nil || err == sql.ErrNoRows || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (r *PostgresRepository) GetAllActive(ctx context.Context) (domain.PackSizeSet, error) {
 	query := `
-		SELECT sizes 
+		SELECT version, sizes 
 		FROM pack_sizes 
 		WHERE is_active = true 
 		ORDER BY version DESC 
 		LIMIT 1
 	`
 
+	var version int
 	var arrayStr string
-	err := r.db.QueryRowContext(ctx, query).Scan(&arrayStr)
+	err := r.read(ctx, func(db *sql.DB) error {
+		return db.QueryRowContext(ctx, query).Scan(&version, &arrayStr)
+	})
 	if err == sql.ErrNoRows {
-		return []int{}, nil
+		return domain.PackSizeSet{Sizes: []int{}}, nil
 	}
 	if err != nil {
-		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to get active pack sizes")
+		return domain.PackSizeSet{}, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to get active pack sizes")
 	}
 
-	// Parse PostgreSQL array format: {1,2,3} or {1, 2, 3}
+	sizes, err := parseIntArray(arrayStr)
+	if err != nil {
+		return domain.PackSizeSet{}, err
+	}
+
+	return domain.PackSizeSet{Version: version, Sizes: sizes}, nil
+}
+
+// parseIntArray parses PostgreSQL array format: {1,2,3} or {1, 2, 3}
+func parseIntArray(arrayStr string) ([]int, error) {
 	arrayStr = strings.Trim(arrayStr, "{}")
 	if arrayStr == "" {
 		return []int{}, nil
@@ -76,11 +148,10 @@ func (r *PostgresRepository) GetAllActive() ([]int, error) {
 	return sizes, nil
 }
 
-func (r *PostgresRepository) Create(sizes []int) error {
-	ctx := context.Background()
+func (r *PostgresRepository) Create(ctx context.Context, sizes []int) (int, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to begin transaction")
+		return 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to begin transaction")
 	}
 	defer tx.Rollback()
 
@@ -89,13 +160,13 @@ func (r *PostgresRepository) Create(sizes []int) error {
 	var maxVersion int
 	err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM pack_sizes").Scan(&maxVersion)
 	if err != nil {
-		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to get max version")
+		return 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to get max version")
 	}
 
 	updateQuery := "UPDATE pack_sizes SET is_active = false WHERE is_active = true"
 	_, err = tx.ExecContext(ctx, updateQuery)
 	if err != nil {
-		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to deactivate old versions")
+		return 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to deactivate old versions")
 	}
 
 	insertQuery := `
@@ -110,16 +181,31 @@ func (r *PostgresRepository) Create(sizes []int) error {
 	}
 	arrayStr := "{" + strings.Join(arrayParts, ",") + "}"
 	
-	_, err = tx.ExecContext(ctx, insertQuery, maxVersion+1, arrayStr)
+	version := maxVersion + 1
+	_, err = tx.ExecContext(ctx, insertQuery, version, arrayStr)
 	if err != nil {
-		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to insert new pack sizes")
+		return 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to insert new pack sizes")
+	}
+
+	principal, _ := domain.PrincipalFrom(ctx)
+	if err := insertOutboxEvent(ctx, tx, domain.PackSizesChanged{
+		Version:   version,
+		Sizes:     sizes,
+		ChangedAt: time.Now().UTC(),
+		ChangedBy: principal.Subject,
+		Tenant:    principal.Tenant,
+	}); err != nil {
+		return 0, err
 	}
 
+	// Mark before committing: if the commit outcome is unknown, later reads
+	// must still go to the primary.
+	consistency.MarkWrite(ctx)
 	if err := tx.Commit(); err != nil {
-		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to commit transaction")
+		return 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to commit transaction")
 	}
 
-	return nil
+	return version, nil
 }
 
 var _ ports.PackSizeRepository = (*PostgresRepository)(nil)
@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"testing"
 
+	"pack-calculator/pkg/consistency"
 	pkgerrors "pack-calculator/pkg/errors"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -24,10 +27,11 @@ func TestPostgresRepository_GetAllActive(t *testing.T) {
 	defer repo.Close()
 
 	t.Run("empty database returns empty slice", func(t *testing.T) {
-		sizes, err := repo.GetAllActive()
+		active, err := repo.GetAllActive(context.Background())
 		if err != nil {
 			t.Errorf("GetAllActive() error = %v, want nil", err)
 		}
+		sizes := active.Sizes
 		if sizes == nil {
 			t.Error("GetAllActive() returned nil, want empty slice")
 		}
@@ -51,17 +55,17 @@ func TestPostgresRepository_Create(t *testing.T) {
 
 	t.Run("create pack sizes successfully", func(t *testing.T) {
 		sizes := []int{250, 500, 1000}
-		err := repo.Create(sizes)
+		_, err := repo.Create(context.Background(), sizes)
 		if err != nil {
 			t.Errorf("Create() error = %v, want nil", err)
 		}
 
 		// Verify it was created
-		active, err := repo.GetAllActive()
+		active, err := repo.GetAllActive(context.Background())
 		if err != nil {
 			t.Errorf("GetAllActive() error = %v", err)
 		}
-		if len(active) != len(sizes) {
+		if len(active.Sizes) != len(sizes) {
 			t.Errorf("GetAllActive() = %v, want %v", active, sizes)
 		}
 	})
@@ -70,23 +74,29 @@ func TestPostgresRepository_Create(t *testing.T) {
 		oldSizes := []int{250, 500}
 		newSizes := []int{100, 200, 300}
 
-		err := repo.Create(oldSizes)
+		oldVersion, err := repo.Create(context.Background(), oldSizes)
 		if err != nil {
 			t.Fatalf("Create() error = %v", err)
 		}
 
-		err = repo.Create(newSizes)
+		newVersion, err := repo.Create(context.Background(), newSizes)
 		if err != nil {
 			t.Fatalf("Create() error = %v", err)
 		}
+		if newVersion != oldVersion+1 {
+			t.Errorf("Create() version = %d, want %d", newVersion, oldVersion+1)
+		}
 
-		active, err := repo.GetAllActive()
+		active, err := repo.GetAllActive(context.Background())
 		if err != nil {
 			t.Errorf("GetAllActive() error = %v", err)
 		}
-		if len(active) != len(newSizes) {
+		if len(active.Sizes) != len(newSizes) {
 			t.Errorf("GetAllActive() = %v, want %v", active, newSizes)
 		}
+		if active.Version != newVersion {
+			t.Errorf("GetAllActive() version = %d, want %d", active.Version, newVersion)
+		}
 	})
 }
 
@@ -108,7 +118,7 @@ func TestPostgresRepository_ErrorWrapping(t *testing.T) {
 		if err == nil {
 			// If connection succeeds, test GetAllActive with closed connection
 			repo.Close()
-			_, err = repo.GetAllActive()
+			_, err = repo.GetAllActive(context.Background())
 			if err != nil {
 				// Check if error is wrapped with ErrRepository
 				if !errors.Is(err, pkgerrors.ErrRepository) {
@@ -118,3 +128,86 @@ func TestPostgresRepository_ErrorWrapping(t *testing.T) {
 		}
 	})
 }
+
+func TestPostgresRepository_CancelledContext(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	dsn := "host=localhost port=5432 user=packcalc password=packcalc dbname=packcalc_test sslmode=disable"
+	repo, err := NewPostgresRepository(dsn)
+	if err != nil {
+		t.Skipf("Skipping test: failed to connect to database: %v", err)
+	}
+	defer repo.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := repo.GetAllActive(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetAllActive() error = %v, want context.Canceled", err)
+	}
+	if _, err := repo.Create(ctx, []int{250}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Create() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestPostgresRepository_Readers(t *testing.T) {
+	open := func() *sql.DB {
+		db, err := sql.Open("pgx", "host=localhost")
+		if err != nil {
+			t.Fatalf("sql.Open() error = %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	primary, replicaA, replicaB := open(), open(), open()
+
+	t.Run("no replicas reads from the primary", func(t *testing.T) {
+		repo := &PostgresRepository{db: primary}
+		if got := repo.readers(context.Background()); len(got) != 1 || got[0] != primary {
+			t.Errorf("readers() = %v, want [primary]", got)
+		}
+	})
+
+	t.Run("replicas rotate with the primary as fallback", func(t *testing.T) {
+		repo := &PostgresRepository{db: primary, replicas: []*sql.DB{replicaA, replicaB}}
+		for _, want := range []*sql.DB{replicaA, replicaB, replicaA} {
+			got := repo.readers(context.Background())
+			if len(got) != 2 || got[0] != want || got[1] != primary {
+				t.Fatalf("readers() = %v, want [%p primary]", got, want)
+			}
+		}
+	})
+
+	t.Run("session that wrote reads from the primary", func(t *testing.T) {
+		repo := &PostgresRepository{db: primary, replicas: []*sql.DB{replicaA}}
+		ctx := consistency.WithSession(context.Background())
+
+		if got := repo.readers(ctx); got[0] != replicaA {
+			t.Errorf("readers() before write starts with %p, want replica", got[0])
+		}
+		consistency.MarkWrite(ctx)
+		if got := repo.readers(ctx); len(got) != 1 || got[0] != primary {
+			t.Errorf("readers() after write = %v, want [primary]", got)
+		}
+	})
+}
+
+func TestPostgresRepository_ReplicaFallback(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	dsn := "host=localhost port=5432 user=packcalc password=packcalc dbname=packcalc_test sslmode=disable"
+	unreachable := "host=localhost port=1 user=packcalc password=packcalc dbname=packcalc_test sslmode=disable connect_timeout=1"
+	repo, err := NewPostgresRepository(dsn, unreachable)
+	if err != nil {
+		t.Skipf("Skipping test: failed to connect to database: %v", err)
+	}
+	defer repo.Close()
+
+	if _, err := repo.GetAllActive(context.Background()); err != nil {
+		t.Errorf("GetAllActive() error = %v, want fallback to the primary", err)
+	}
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// PostgresWebhookStore keeps webhook subscriptions and deliveries on the
+// primary.
+type PostgresWebhookStore struct {
+	db *sql.DB
+}
+
+func NewPostgresWebhookStore(repo *PostgresRepository) *PostgresWebhookStore {
+	return &PostgresWebhookStore{db: repo.db}
+}
+
+// Events are read back as JSON because database/sql cannot scan text[].
+const subscriptionColumns = "id, url, array_to_json(events), secret, description, active, created_at, updated_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row rowScanner) (domain.WebhookSubscription, error) {
+	var sub domain.WebhookSubscription
+	var events []byte
+	if err := row.Scan(&sub.ID, &sub.URL, &events, &sub.Secret, &sub.Description, &sub.Active, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	if err := json.Unmarshal(events, &sub.Events); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	return sub, nil
+}
+
+func (s *PostgresWebhookStore) CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_subscriptions (url, events, secret, description, active)
+		VALUES ($1, $2::text[], $3, $4, $5)
+		RETURNING `+subscriptionColumns,
+		sub.URL, sub.Events, sub.Secret, sub.Description, sub.Active)
+
+	created, err := scanSubscription(row)
+	if err != nil {
+		return domain.WebhookSubscription{}, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to create webhook subscription")
+	}
+	return created, nil
+}
+
+func (s *PostgresWebhookStore) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+subscriptionColumns+" FROM webhook_subscriptions ORDER BY id")
+	if err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to list webhook subscriptions")
+	}
+	defer rows.Close()
+
+	subs := []domain.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to scan webhook subscription")
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to list webhook subscriptions")
+	}
+	return subs, nil
+}
+
+func (s *PostgresWebhookStore) GetSubscription(ctx context.Context, id int64) (domain.WebhookSubscription, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE id = $1", id)
+
+	sub, err := scanSubscription(row)
+	if err == sql.ErrNoRows {
+		return domain.WebhookSubscription{}, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		return domain.WebhookSubscription{}, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to get webhook subscription")
+	}
+	return sub, nil
+}
+
+func (s *PostgresWebhookStore) UpdateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE webhook_subscriptions
+		SET url = $2, events = $3::text[], description = $4, active = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+subscriptionColumns,
+		sub.ID, sub.URL, sub.Events, sub.Description, sub.Active)
+
+	updated, err := scanSubscription(row)
+	if err == sql.ErrNoRows {
+		return domain.WebhookSubscription{}, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		return domain.WebhookSubscription{}, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to update webhook subscription")
+	}
+	return updated, nil
+}
+
+func (s *PostgresWebhookStore) DeleteSubscription(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to delete webhook subscription")
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return pkgerrors.ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresWebhookStore) EnqueueDeliveries(ctx context.Context, eventType, eventKey string, payload []byte) (int, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_type, event_key, payload)
+		SELECT id, $1, $2, $3
+		FROM webhook_subscriptions
+		WHERE active AND $1 = ANY(events)
+		ON CONFLICT (subscription_id, event_key) DO NOTHING
+	`, eventType, eventKey, string(payload))
+	if err != nil {
+		return 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to enqueue webhook deliveries")
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to enqueue webhook deliveries")
+	}
+	return int(n), nil
+}
+
+// ClaimDeliveries skips deliveries of deactivated subscriptions; they stay
+// pending and resume if the subscription is reactivated.
+func (s *PostgresWebhookStore) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDispatch, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		WITH due AS (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND s.active
+			ORDER BY d.id
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET attempts = d.attempts + 1,
+			next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM due, webhook_subscriptions s
+		WHERE d.id = due.id AND s.id = d.subscription_id
+		RETURNING d.id, d.subscription_id, d.event_type, d.event_key, d.payload, d.attempts, d.created_at, s.url, s.secret
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to claim webhook deliveries")
+	}
+	defer rows.Close()
+
+	var dispatches []domain.WebhookDispatch
+	for rows.Next() {
+		var d domain.WebhookDispatch
+		var payload []byte
+		err := rows.Scan(&d.Delivery.ID, &d.Delivery.SubscriptionID, &d.Delivery.EventType, &d.Delivery.EventKey,
+			&payload, &d.Delivery.Attempts, &d.Delivery.CreatedAt, &d.URL, &d.Secret)
+		if err != nil {
+			return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to scan webhook delivery")
+		}
+		d.Delivery.Payload = payload
+		d.Delivery.Status = domain.WebhookDeliveryPending
+		dispatches = append(dispatches, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to read webhook deliveries")
+	}
+	return dispatches, nil
+}
+
+func (s *PostgresWebhookStore) MarkDelivered(ctx context.Context, id int64, responseStatus int) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', response_status = $2, last_error = NULL, delivered_at = NOW()
+		WHERE id = $1
+	`, id, responseStatus)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to mark webhook delivery delivered")
+	}
+	return nil
+}
+
+func (s *PostgresWebhookStore) MarkFailed(ctx context.Context, id int64, responseStatus int, cause error, retryAt time.Time, final bool) error {
+	status := domain.WebhookDeliveryPending
+	if final {
+		status = domain.WebhookDeliveryFailed
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, response_status = NULLIF($3, 0), last_error = $4, next_attempt_at = $5
+		WHERE id = $1
+	`, id, status, responseStatus, cause.Error(), retryAt)
+	if err != nil {
+		return pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to record webhook delivery failure")
+	}
+	return nil
+}
+
+func (s *PostgresWebhookStore) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, subscription_id, event_type, event_key, payload, status, attempts,
+			COALESCE(response_status, 0), COALESCE(last_error, ''), next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, subscriptionID, limit)
+	if err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to list webhook deliveries")
+	}
+	defer rows.Close()
+
+	deliveries := []domain.WebhookDelivery{}
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		var payload []byte
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventType, &d.EventKey, &payload, &d.Status, &d.Attempts,
+			&d.ResponseStatus, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt)
+		if err != nil {
+			return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to scan webhook delivery")
+		}
+		d.Payload = payload
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrRepository, "failed to list webhook deliveries")
+	}
+	return deliveries, nil
+}
+
+var _ ports.WebhookStore = (*PostgresWebhookStore)(nil)
@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"pack-calculator/internal/ports"
+)
+
+// Cache retries transient cache failures. Every cache operation is
+// idempotent, so all of them are retried.
+type Cache struct {
+	next    ports.Cache
+	retrier *retrier
+}
+
+func NewCache(next ports.Cache, policy Policy) *Cache {
+	return &Cache{next: next, retrier: newRetrier(policy)}
+}
+
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := c.retrier.do(ctx, "cache.Get", Transient, func() error {
+		var err error
+		value, err = c.next.Get(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (c *Cache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	var values map[string][]byte
+	err := c.retrier.do(ctx, "cache.GetMany", Transient, func() error {
+		var err error
+		values, err = c.next.GetMany(ctx, keys)
+		return err
+	})
+	return values, err
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.retrier.do(ctx, "cache.Set", Transient, func() error {
+		return c.next.Set(ctx, key, value, ttl)
+	})
+}
+
+func (c *Cache) SetMany(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	return c.retrier.do(ctx, "cache.SetMany", Transient, func() error {
+		return c.next.SetMany(ctx, items, ttl)
+	})
+}
+
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	return c.retrier.do(ctx, "cache.Delete", Transient, func() error {
+		return c.next.Delete(ctx, keys...)
+	})
+}
+
+func (c *Cache) GetVersion(ctx context.Context, key string) (int, error) {
+	var version int
+	err := c.retrier.do(ctx, "cache.GetVersion", Transient, func() error {
+		var err error
+		version, err = c.next.GetVersion(ctx, key)
+		return err
+	})
+	return version, err
+}
+
+func (c *Cache) AdvanceVersion(ctx context.Context, key string, version int, ttl time.Duration) error {
+	return c.retrier.do(ctx, "cache.AdvanceVersion", Transient, func() error {
+		return c.next.AdvanceVersion(ctx, key, version, ttl)
+	})
+}
+
+var _ ports.Cache = (*Cache)(nil)
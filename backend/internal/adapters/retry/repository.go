@@ -0,0 +1,42 @@
+package retry
+
+import (
+	"context"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+)
+
+// Repository retries transient repository failures. Reads are retried on any
+// transient error; Create only when the failed attempt certainly did not
+// commit, so a retry never stores the same sizes twice.
+type Repository struct {
+	next    ports.PackSizeRepository
+	retrier *retrier
+}
+
+func NewRepository(next ports.PackSizeRepository, policy Policy) *Repository {
+	return &Repository{next: next, retrier: newRetrier(policy)}
+}
+
+func (r *Repository) GetAllActive(ctx context.Context) (domain.PackSizeSet, error) {
+	var set domain.PackSizeSet
+	err := r.retrier.do(ctx, "repository.GetAllActive", Transient, func() error {
+		var err error
+		set, err = r.next.GetAllActive(ctx)
+		return err
+	})
+	return set, err
+}
+
+func (r *Repository) Create(ctx context.Context, sizes []int) (int, error) {
+	var version int
+	err := r.retrier.do(ctx, "repository.Create", SafeToRepeat, func() error {
+		var err error
+		version, err = r.next.Create(ctx, sizes)
+		return err
+	})
+	return version, err
+}
+
+var _ ports.PackSizeRepository = (*Repository)(nil)
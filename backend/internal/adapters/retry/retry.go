@@ -0,0 +1,100 @@
+package retry
+
+import (
+	"context"
+	"expvar"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes how an operation is retried after a transient failure.
+type Policy struct {
+	// MaxAttempts is the total number of tries, including the first one.
+	// 1 or less disables retries.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Multiplier grows the backoff after each attempt.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction in either
+	// direction, so instances do not retry in lockstep.
+	Jitter float64
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:    3,
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// stats counts retries per operation, published at /debug/vars as "retry":
+// "<op>.retries" is every repeated attempt, "<op>.recovered" an operation
+// that succeeded after retrying and "<op>.exhausted" one that gave up.
+var stats = expvar.NewMap("retry")
+
+// retrier runs operations under a policy.
+type retrier struct {
+	policy Policy
+	sleep  func(ctx context.Context, d time.Duration) error
+	random func() float64
+}
+
+func newRetrier(policy Policy) *retrier {
+	return &retrier{policy: policy, sleep: sleep, random: rand.Float64}
+}
+
+// do calls fn until it succeeds, fails with an error retryable rejects, the
+// attempts run out or ctx is done. It returns fn's last error.
+func (r *retrier) do(ctx context.Context, op string, retryable func(error) bool, fn func() error) error {
+	err := fn()
+	for attempt := 1; err != nil && attempt < r.policy.MaxAttempts && retryable(err); attempt++ {
+		if r.sleep(ctx, r.backoff(attempt)) != nil {
+			return err
+		}
+
+		stats.Add(op+".retries", 1)
+		if err = fn(); err == nil {
+			stats.Add(op+".recovered", 1)
+			return nil
+		}
+	}
+
+	if err != nil && r.policy.MaxAttempts > 1 && retryable(err) && ctx.Err() == nil {
+		stats.Add(op+".exhausted", 1)
+	}
+	return err
+}
+
+// backoff returns the delay before the given retry, counting from 1.
+func (r *retrier) backoff(retry int) time.Duration {
+	multiplier := r.policy.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(r.policy.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if r.policy.MaxBackoff > 0 && delay > float64(r.policy.MaxBackoff) {
+		delay = float64(r.policy.MaxBackoff)
+	}
+	if r.policy.Jitter > 0 {
+		delay *= 1 + r.policy.Jitter*(2*r.random()-1)
+	}
+	return time.Duration(delay)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,236 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func testRetrier(policy Policy) (*retrier, *[]time.Duration) {
+	var delays []time.Duration
+	r := newRetrier(policy)
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	r.random = func() float64 { return 0.5 }
+	return r, &delays
+}
+
+func statValue(key string) int64 {
+	if v, ok := stats.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestRetrier_RetriesTransientErrors(t *testing.T) {
+	r, delays := testRetrier(Policy{MaxAttempts: 4, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 25 * time.Millisecond, Multiplier: 2})
+	retries := statValue("test.transient.retries")
+
+	calls := 0
+	err := r.do(context.Background(), "test.transient", Transient, func() error {
+		calls++
+		if calls < 4 {
+			return io.ErrUnexpectedEOF
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("do() error = %v, want nil", err)
+	}
+	if calls != 4 {
+		t.Errorf("calls = %d, want 4", calls)
+	}
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 25 * time.Millisecond}
+	if fmt.Sprint(*delays) != fmt.Sprint(want) {
+		t.Errorf("delays = %v, want %v", *delays, want)
+	}
+	if got := statValue("test.transient.retries") - retries; got != 3 {
+		t.Errorf("retries metric grew by %d, want 3", got)
+	}
+}
+
+func TestRetrier_StopsOnPermanentError(t *testing.T) {
+	r, _ := testRetrier(DefaultPolicy())
+
+	calls := 0
+	err := r.do(context.Background(), "test.permanent", Transient, func() error {
+		calls++
+		return pkgerrors.ErrNotFound
+	})
+
+	if !errors.Is(err, pkgerrors.ErrNotFound) || calls != 1 {
+		t.Errorf("do() = %v after %d calls, want ErrNotFound after 1", err, calls)
+	}
+}
+
+func TestRetrier_GivesUpAfterMaxAttempts(t *testing.T) {
+	r, _ := testRetrier(Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+	exhausted := statValue("test.exhausted.exhausted")
+
+	calls := 0
+	err := r.do(context.Background(), "test.exhausted", Transient, func() error {
+		calls++
+		return syscall.ECONNRESET
+	})
+
+	if !errors.Is(err, syscall.ECONNRESET) || calls != 3 {
+		t.Errorf("do() = %v after %d calls, want ECONNRESET after 3", err, calls)
+	}
+	if got := statValue("test.exhausted.exhausted") - exhausted; got != 1 {
+		t.Errorf("exhausted metric grew by %d, want 1", got)
+	}
+}
+
+func TestRetrier_StopsWhenContextDone(t *testing.T) {
+	r, _ := testRetrier(DefaultPolicy())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	r.do(ctx, "test.cancelled", Transient, func() error {
+		calls++
+		return io.EOF
+	})
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 once the context is done", calls)
+	}
+}
+
+func TestRetrier_Jitter(t *testing.T) {
+	r := newRetrier(Policy{MaxAttempts: 2, InitialBackoff: 100 * time.Millisecond, Jitter: 0.2})
+
+	for _, tt := range []struct {
+		random float64
+		want   time.Duration
+	}{
+		{0, 80 * time.Millisecond},
+		{0.5, 100 * time.Millisecond},
+		{1, 120 * time.Millisecond},
+	} {
+		r.random = func() float64 { return tt.random }
+		if got := r.backoff(1); got != tt.want {
+			t.Errorf("backoff(1) with random %v = %v, want %v", tt.random, got, tt.want)
+		}
+	}
+}
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"not found", pkgerrors.ErrNotFound, false},
+		{"cancelled", fmt.Errorf("query: %w", context.Canceled), false},
+		{"connection reset", pkgerrors.WrapWithDomain(syscall.ECONNRESET, pkgerrors.ErrRepository, "failed"), true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{"dial timeout", &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, true},
+		{"unknown host", &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "db", IsNotFound: true}}, false},
+		{"network unreachable", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ENETUNREACH}, false},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"redis loading", redisError("LOADING Redis is loading the dataset in memory"), true},
+		{"redis readonly", redisError("READONLY You can't write against a read only replica."), true},
+		{"redis wrong type", redisError("WRONGTYPE Operation against a key holding the wrong kind of value"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Transient(tt.err); got != tt.want {
+				t.Errorf("Transient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeToRepeat(t *testing.T) {
+	if !SafeToRepeat(&pgconn.PgError{Code: "40P01"}) {
+		t.Error("SafeToRepeat(deadlock) = false, want true")
+	}
+	// The connection may have dropped after COMMIT reached the server.
+	if SafeToRepeat(io.ErrUnexpectedEOF) {
+		t.Error("SafeToRepeat(unexpected EOF) = true, want false")
+	}
+}
+
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+func (redisError) RedisError()     {}
+
+type flakyRepository struct {
+	failures int
+	err      error
+	creates  int
+}
+
+func (r *flakyRepository) GetAllActive(ctx context.Context) (domain.PackSizeSet, error) {
+	if r.failures > 0 {
+		r.failures--
+		return domain.PackSizeSet{}, r.err
+	}
+	return domain.PackSizeSet{Version: 1, Sizes: []int{250}}, nil
+}
+
+func (r *flakyRepository) Create(ctx context.Context, sizes []int) (int, error) {
+	r.creates++
+	if r.failures > 0 {
+		r.failures--
+		return 0, r.err
+	}
+	return 2, nil
+}
+
+func TestRepository(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, InitialBackoff: time.Microsecond}
+
+	t.Run("read recovers from a failover", func(t *testing.T) {
+		repo := NewRepository(&flakyRepository{failures: 2, err: &pgconn.PgError{Code: "57P01"}}, policy)
+
+		set, err := repo.GetAllActive(context.Background())
+		if err != nil || set.Version != 1 {
+			t.Errorf("GetAllActive() = %v, %v, want version 1", set, err)
+		}
+	})
+
+	t.Run("ambiguous create is not repeated", func(t *testing.T) {
+		next := &flakyRepository{failures: 1, err: io.ErrUnexpectedEOF}
+		repo := NewRepository(next, policy)
+
+		if _, err := repo.Create(context.Background(), []int{250}); err == nil {
+			t.Error("Create() error = nil, want the original failure")
+		}
+		if next.creates != 1 {
+			t.Errorf("creates = %d, want 1", next.creates)
+		}
+	})
+
+	t.Run("rolled back create is repeated", func(t *testing.T) {
+		next := &flakyRepository{failures: 1, err: &pgconn.PgError{Code: "40001"}}
+		repo := NewRepository(next, policy)
+
+		version, err := repo.Create(context.Background(), []int{250})
+		if err != nil || version != 2 || next.creates != 2 {
+			t.Errorf("Create() = %d, %v after %d attempts, want 2 after 2", version, err, next.creates)
+		}
+	})
+}
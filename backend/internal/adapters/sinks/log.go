@@ -0,0 +1,35 @@
+package sinks
+
+import (
+	"context"
+	"log/slog"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+	"pack-calculator/pkg/logger"
+)
+
+// LogSink writes each event to the application log.
+type LogSink struct {
+	logger *slog.Logger
+}
+
+func NewLogSink() *LogSink {
+	return &LogSink{logger: logger.Default()}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Deliver(ctx context.Context, event domain.OutboxEvent) error {
+	s.logger.InfoContext(ctx, "Outbox event",
+		"event_id", event.ID,
+		"type", event.Type,
+		"payload", string(event.Payload),
+		"created_at", event.CreatedAt,
+	)
+	return nil
+}
+
+var _ ports.EventSink = (*LogSink)(nil)
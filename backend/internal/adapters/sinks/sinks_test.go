@@ -0,0 +1,80 @@
+package sinks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/domain"
+)
+
+func testEvent(id int64) domain.OutboxEvent {
+	return domain.OutboxEvent{
+		ID:        id,
+		Type:      domain.EventPackSizesChanged,
+		Payload:   json.RawMessage(`{"version":2,"sizes":[250,500]}`),
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookSink_Deliver(t *testing.T) {
+	var got domain.OutboxEvent
+	var eventID string
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eventID = r.Header.Get("X-Event-ID")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, time.Second)
+
+	if err := sink.Deliver(context.Background(), testEvent(7)); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if eventID != "7" || got.ID != 7 || string(got.Payload) != `{"version":2,"sizes":[250,500]}` {
+		t.Errorf("received event %+v with X-Event-ID %q", got, eventID)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := sink.Deliver(context.Background(), testEvent(8)); err == nil {
+		t.Error("Deliver() error = nil on 503, want error")
+	}
+}
+
+func TestFileSink_Deliver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink := NewFileSink(path)
+
+	for _, id := range []int64{1, 2} {
+		if err := sink.Deliver(context.Background(), testEvent(id)); err != nil {
+			t.Fatalf("Deliver() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+
+	var ids []int64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event domain.OutboxEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line %q is not an event: %v", scanner.Text(), err)
+		}
+		ids = append(ids, event.ID)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("file events = %v, want [1 2]", ids)
+	}
+}
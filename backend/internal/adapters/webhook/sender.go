@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+)
+
+// ErrDestinationBlocked is returned when a webhook URL resolves to an
+// internal address.
+var ErrDestinationBlocked = errors.New("webhook destination address not allowed")
+
+// SenderOptions configures HTTPSender.
+type SenderOptions struct {
+	Timeout time.Duration
+	// AllowPrivateNetworks lets webhooks reach loopback, private and
+	// link-local addresses, e.g. a receiver on the same host in development.
+	AllowPrivateNetworks bool
+}
+
+// HTTPSender POSTs the event payload with an HMAC signature. Any non-2xx
+// response is a failed attempt, including redirects, which are not followed.
+// Subscribers choose the URL, so unless AllowPrivateNetworks is set the
+// address is checked after DNS resolution, when the connection is dialed,
+// and internal ones are refused.
+type HTTPSender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewHTTPSender(options SenderOptions) *HTTPSender {
+	dialer := &net.Dialer{Timeout: options.Timeout}
+	if !options.AllowPrivateNetworks {
+		dialer.Control = refuseInternal
+	}
+	// No proxy: it would dial on our behalf, past the address check.
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: options.Timeout,
+	}
+	client := &http.Client{
+		Timeout:   options.Timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &HTTPSender{client: client, now: time.Now}
+}
+
+func (s *HTTPSender) Send(ctx context.Context, dispatch domain.WebhookDispatch) (int, error) {
+	delivery := dispatch.Delivery
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dispatch.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pack-calculator-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Event-Key", delivery.EventKey)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(delivery.Attempts))
+	req.Header.Set(SignatureHeader, Sign(dispatch.Secret, s.now(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// blockedPrefixes are internal ranges the netip predicates do not cover.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// refuseInternal is a net.Dialer Control that rejects internal addresses.
+func refuseInternal(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDestinationBlocked, address)
+	}
+	if internalAddr(addrPort.Addr().Unmap()) {
+		return fmt.Errorf("%w: %s", ErrDestinationBlocked, addrPort.Addr())
+	}
+	return nil
+}
+
+func internalAddr(addr netip.Addr) bool {
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+var _ ports.WebhookSender = (*HTTPSender)(nil)
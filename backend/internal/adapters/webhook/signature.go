@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256>" where the
+// MAC covers "<t>.<body>" keyed with the subscription secret. Binding the
+// timestamp lets receivers reject replays.
+const SignatureHeader = "X-Webhook-Signature"
+
+var (
+	ErrSignatureMalformed = errors.New("malformed webhook signature")
+	ErrSignatureMismatch  = errors.New("webhook signature mismatch")
+	ErrSignatureExpired   = errors.New("webhook signature timestamp outside tolerance")
+)
+
+// Sign returns the SignatureHeader value for body sent at ts.
+func Sign(secret string, ts time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, mac(secret, timestamp, body))
+}
+
+// Verify checks a SignatureHeader value against body, rejecting signatures
+// older or newer than tolerance relative to now.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return ErrSignatureMalformed
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	if timestamp == "" || signature == "" {
+		return ErrSignatureMalformed
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureMalformed
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(mac(secret, timestamp, body))) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+func mac(secret, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
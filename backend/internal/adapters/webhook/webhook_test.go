@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/domain"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"version":2}`)
+	header := Sign("whsec_test", now, body)
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", "whsec_test", header, body, now, nil},
+		{"wrong secret", "whsec_other", header, body, now, ErrSignatureMismatch},
+		{"tampered body", "whsec_test", header, []byte(`{"version":3}`), now, ErrSignatureMismatch},
+		{"replayed later", "whsec_test", header, body, now.Add(10 * time.Minute), ErrSignatureExpired},
+		{"malformed", "whsec_test", "v1=abc", body, now, ErrSignatureMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, tt.now, 5*time.Minute)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPSender_Send(t *testing.T) {
+	const secret = "whsec_test"
+	status := http.StatusOK
+	var verifyErr error
+	var event string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = Verify(secret, r.Header.Get(SignatureHeader), body, time.Now(), time.Minute)
+		event = r.Header.Get("X-Webhook-Event")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sender := NewHTTPSender(SenderOptions{Timeout: time.Second, AllowPrivateNetworks: true})
+	dispatch := domain.WebhookDispatch{
+		URL:    server.URL,
+		Secret: secret,
+		Delivery: domain.WebhookDelivery{
+			ID:        1,
+			EventType: domain.EventPackSizesChanged,
+			EventKey:  "outbox:1",
+			Payload:   json.RawMessage(`{"version":2,"sizes":[250]}`),
+			Attempts:  1,
+		},
+	}
+
+	code, err := sender.Send(context.Background(), dispatch)
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("Send() = %d, %v, want 200", code, err)
+	}
+	if verifyErr != nil {
+		t.Errorf("receiver could not verify signature: %v", verifyErr)
+	}
+	if event != domain.EventPackSizesChanged {
+		t.Errorf("X-Webhook-Event = %q, want %q", event, domain.EventPackSizesChanged)
+	}
+
+	status = http.StatusInternalServerError
+	if code, err := sender.Send(context.Background(), dispatch); err == nil || code != http.StatusInternalServerError {
+		t.Errorf("Send() = %d, %v, want 500 and error", code, err)
+	}
+}
+
+func TestHTTPSender_RefusesInternalAddresses(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+
+	sender := NewHTTPSender(SenderOptions{Timeout: time.Second})
+	for _, target := range []string{
+		server.URL,
+		"http://localhost:" + serverURL.Port(),
+		"http://[::1]:9/",
+		"http://169.254.169.254/latest/meta-data/",
+	} {
+		t.Run(target, func(t *testing.T) {
+			_, err := sender.Send(context.Background(), domain.WebhookDispatch{URL: target, Secret: "whsec_test"})
+			if !errors.Is(err, ErrDestinationBlocked) {
+				t.Errorf("Send() error = %v, want ErrDestinationBlocked", err)
+			}
+		})
+	}
+	if calls != 0 {
+		t.Errorf("receiver called %d times, want 0", calls)
+	}
+
+	for _, tt := range []struct {
+		addr string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"93.184.216.34", false},
+		{"2606:2800:220:1:248:1893:25c8:1946", false},
+	} {
+		if got := internalAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("internalAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestHTTPSender_DoesNotFollowRedirects(t *testing.T) {
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	sender := NewHTTPSender(SenderOptions{Timeout: time.Second, AllowPrivateNetworks: true})
+	code, err := sender.Send(context.Background(), domain.WebhookDispatch{URL: server.URL, Secret: "whsec_test"})
+	if err == nil || code != http.StatusTemporaryRedirect || redirected {
+		t.Errorf("Send() = %d, %v, redirected = %v; want 307 as a failure", code, err, redirected)
+	}
+}
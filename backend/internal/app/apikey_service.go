@@ -0,0 +1,187 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+const (
+	apiKeyPrefix = "pk_"
+	// apiKeyDisplayLength is how much of a key is kept in clear to tell keys
+	// apart.
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+	bootstrapSubject    = "api-key:bootstrap"
+)
+
+// APIKeyOptions configures APIKeyService.
+type APIKeyOptions struct {
+	// AnonymousRole is granted to requests without a key; empty rejects them.
+	AnonymousRole string
+	// BootstrapKey is accepted as an admin key without being stored, so the
+	// first keys can be created. Empty disables it.
+	BootstrapKey string
+	// CacheTTL is how long a verified key is trusted without a lookup; a key
+	// revoked on another instance keeps working there for up to this long.
+	// Zero disables caching.
+	CacheTTL time.Duration
+}
+
+func DefaultAPIKeyOptions() APIKeyOptions {
+	return APIKeyOptions{
+		AnonymousRole: domain.RoleReader,
+		CacheTTL:      30 * time.Second,
+	}
+}
+
+type APIKeyServiceInterface interface {
+	// Authenticate returns the principal for key, or ErrUnauthorized. An
+	// empty key authenticates as the anonymous role, if one is configured.
+	Authenticate(ctx context.Context, key string) (domain.Principal, error)
+	// CreateKey stores a new key and returns it together with the plaintext
+	// key, which is not retrievable later.
+	CreateKey(ctx context.Context, name, role string) (domain.APIKey, string, error)
+	ListKeys(ctx context.Context) ([]domain.APIKey, error)
+	RevokeKey(ctx context.Context, id int64) error
+}
+
+// APIKeyService issues API keys and authenticates requests by them.
+type APIKeyService struct {
+	store         ports.APIKeyStore
+	options       APIKeyOptions
+	bootstrapHash []byte
+	now           func() time.Time
+
+	mu       sync.Mutex
+	verified map[string]verifiedKey
+}
+
+type verifiedKey struct {
+	id        int64
+	principal domain.Principal
+	expiresAt time.Time
+}
+
+func NewAPIKeyService(store ports.APIKeyStore, options APIKeyOptions) *APIKeyService {
+	s := &APIKeyService{
+		store:    store,
+		options:  options,
+		now:      time.Now,
+		verified: make(map[string]verifiedKey),
+	}
+	if options.BootstrapKey != "" {
+		sum := sha256.Sum256([]byte(options.BootstrapKey))
+		s.bootstrapHash = sum[:]
+	}
+	return s
+}
+
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (domain.Principal, error) {
+	if key == "" {
+		if s.options.AnonymousRole == "" {
+			return domain.Principal{}, pkgerrors.ErrUnauthorized
+		}
+		return domain.Principal{Role: s.options.AnonymousRole}, nil
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	if s.bootstrapHash != nil && subtle.ConstantTimeCompare(sum[:], s.bootstrapHash) == 1 {
+		return domain.Principal{Subject: bootstrapSubject, Role: domain.RoleAdmin}, nil
+	}
+	hash := hex.EncodeToString(sum[:])
+
+	if principal, ok := s.cached(hash); ok {
+		return principal, nil
+	}
+
+	stored, err := s.store.GetActiveAPIKey(ctx, hash)
+	if errors.Is(err, pkgerrors.ErrNotFound) {
+		return domain.Principal{}, pkgerrors.ErrUnauthorized
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+
+	principal := domain.Principal{Subject: "api-key:" + strconv.FormatInt(stored.ID, 10), Role: stored.Role}
+	if s.options.CacheTTL > 0 {
+		s.mu.Lock()
+		s.verified[hash] = verifiedKey{id: stored.ID, principal: principal, expiresAt: s.now().Add(s.options.CacheTTL)}
+		s.mu.Unlock()
+	}
+	return principal, nil
+}
+
+func (s *APIKeyService) cached(hash string) (domain.Principal, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.verified[hash]
+	if !ok {
+		return domain.Principal{}, false
+	}
+	if !s.now().Before(entry.expiresAt) {
+		delete(s.verified, hash)
+		return domain.Principal{}, false
+	}
+	return entry.principal, true
+}
+
+func (s *APIKeyService) CreateKey(ctx context.Context, name, role string) (domain.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || !domain.ValidRole(role) {
+		return domain.APIKey{}, "", pkgerrors.ErrAPIKeyInvalid
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return domain.APIKey{}, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	plaintext := apiKeyPrefix + hex.EncodeToString(b)
+	sum := sha256.Sum256([]byte(plaintext))
+
+	created, err := s.store.CreateAPIKey(ctx, domain.APIKey{
+		Name:   name,
+		Prefix: plaintext[:apiKeyDisplayLength],
+		Hash:   hex.EncodeToString(sum[:]),
+		Role:   role,
+	})
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	return created, plaintext, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]domain.APIKey, error) {
+	return s.store.ListAPIKeys(ctx)
+}
+
+// RevokeKey revokes the key immediately on this instance; other instances
+// stop accepting it within CacheTTL.
+func (s *APIKeyService) RevokeKey(ctx context.Context, id int64) error {
+	if err := s.store.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for hash, entry := range s.verified {
+		if entry.id == id {
+			delete(s.verified, hash)
+		}
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+var _ APIKeyServiceInterface = (*APIKeyService)(nil)
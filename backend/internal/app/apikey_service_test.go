@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// fakeAPIKeyStore mimics the Postgres API key store in memory.
+type fakeAPIKeyStore struct {
+	mu      sync.Mutex
+	keys    []domain.APIKey
+	lookups int
+}
+
+func (s *fakeAPIKeyStore) CreateAPIKey(ctx context.Context, key domain.APIKey) (domain.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key.ID = int64(len(s.keys) + 1)
+	s.keys = append(s.keys, key)
+	return key, nil
+}
+
+func (s *fakeAPIKeyStore) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]domain.APIKey(nil), s.keys...), nil
+}
+
+func (s *fakeAPIKeyStore) GetActiveAPIKey(ctx context.Context, hash string) (domain.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	for _, key := range s.keys {
+		if key.Hash == hash && key.RevokedAt == nil {
+			return key, nil
+		}
+	}
+	return domain.APIKey{}, pkgerrors.ErrNotFound
+}
+
+func (s *fakeAPIKeyStore) RevokeAPIKey(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if s.keys[i].ID == id && s.keys[i].RevokedAt == nil {
+			now := time.Now()
+			s.keys[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return pkgerrors.ErrNotFound
+}
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	store := &fakeAPIKeyStore{}
+	service := NewAPIKeyService(store, DefaultAPIKeyOptions())
+	ctx := context.Background()
+
+	created, plaintext, err := service.CreateKey(ctx, " ci ", domain.RoleAdmin)
+	if err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) || created.Name != "ci" || !strings.HasPrefix(plaintext, created.Prefix) {
+		t.Errorf("CreateKey() = %+v, %q", created, plaintext)
+	}
+	if strings.Contains(created.Hash, plaintext) || created.Hash == "" {
+		t.Errorf("stored hash %q must not contain the key", created.Hash)
+	}
+
+	principal, err := service.Authenticate(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if principal.Role != domain.RoleAdmin || principal.Subject != "api-key:1" {
+		t.Errorf("Authenticate() = %+v", principal)
+	}
+
+	if _, err := service.Authenticate(ctx, plaintext); err != nil {
+		t.Fatalf("Authenticate() cached error = %v", err)
+	}
+	if store.lookups != 1 {
+		t.Errorf("store lookups = %d, want 1 with caching", store.lookups)
+	}
+
+	if _, err := service.Authenticate(ctx, plaintext+"x"); !errors.Is(err, pkgerrors.ErrUnauthorized) {
+		t.Errorf("Authenticate(wrong key) error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestAPIKeyService_CreateKeyValidates(t *testing.T) {
+	service := NewAPIKeyService(&fakeAPIKeyStore{}, DefaultAPIKeyOptions())
+
+	for _, tt := range []struct{ name, role string }{{"", domain.RoleReader}, {"ci", "owner"}} {
+		if _, _, err := service.CreateKey(context.Background(), tt.name, tt.role); !errors.Is(err, pkgerrors.ErrAPIKeyInvalid) {
+			t.Errorf("CreateKey(%q, %q) error = %v, want ErrAPIKeyInvalid", tt.name, tt.role, err)
+		}
+	}
+}
+
+func TestAPIKeyService_RevokeTakesEffectImmediately(t *testing.T) {
+	service := NewAPIKeyService(&fakeAPIKeyStore{}, DefaultAPIKeyOptions())
+	ctx := context.Background()
+
+	created, plaintext, _ := service.CreateKey(ctx, "ci", domain.RoleReader)
+	if _, err := service.Authenticate(ctx, plaintext); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if err := service.RevokeKey(ctx, created.ID); err != nil {
+		t.Fatalf("RevokeKey() error = %v", err)
+	}
+	if _, err := service.Authenticate(ctx, plaintext); !errors.Is(err, pkgerrors.ErrUnauthorized) {
+		t.Errorf("Authenticate(revoked) error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestAPIKeyService_AnonymousAndBootstrap(t *testing.T) {
+	ctx := context.Background()
+
+	open := NewAPIKeyService(&fakeAPIKeyStore{}, APIKeyOptions{AnonymousRole: domain.RoleReader, BootstrapKey: "let-me-in"})
+	if principal, err := open.Authenticate(ctx, ""); err != nil || principal.Role != domain.RoleReader || principal.Subject != "" {
+		t.Errorf("Authenticate(\"\") = %+v, %v, want anonymous reader", principal, err)
+	}
+	if principal, err := open.Authenticate(ctx, "let-me-in"); err != nil || principal.Role != domain.RoleAdmin {
+		t.Errorf("Authenticate(bootstrap) = %+v, %v, want admin", principal, err)
+	}
+
+	closed := NewAPIKeyService(&fakeAPIKeyStore{}, APIKeyOptions{})
+	if _, err := closed.Authenticate(ctx, ""); !errors.Is(err, pkgerrors.ErrUnauthorized) {
+		t.Errorf("Authenticate(\"\") error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := closed.Authenticate(ctx, "let-me-in"); !errors.Is(err, pkgerrors.ErrUnauthorized) {
+		t.Errorf("Authenticate(bootstrap) error = %v with bootstrap disabled", err)
+	}
+}
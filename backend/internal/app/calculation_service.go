@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"sort"
 
 	"pack-calculator/internal/domain"
@@ -13,16 +14,29 @@ func NewCalculationService() *CalculationService {
 }
 
 func (s *CalculationService) CalculatePacks(packSizes []int, items int) []domain.Pack {
+	packs, _ := s.CalculatePacksContext(context.Background(), packSizes, items)
+	return packs
+}
+
+// cancelCheckInterval is how many search steps run between checks of ctx.
+const cancelCheckInterval = 1024
+
+// CalculatePacksContext is CalculatePacks, but gives up with ctx's error once
+// ctx is done, since the search grows with items plus the largest size.
+func (s *CalculationService) CalculatePacksContext(ctx context.Context, packSizes []int, items int) ([]domain.Pack, error) {
 	if len(packSizes) == 0 || items <= 0 {
-		return []domain.Pack{}
+		return []domain.Pack{}, nil
 	}
 
 	maxSize := maxSizeInSlice(packSizes)
 
 	// We search up to items + maxSize because we can only use whole packs.
 	// If exact match isn't possible, we may need to send more items than requested.
-	result := s.findOptimalCombination(packSizes, items, maxSize)
-	return s.mapToPacks(result)
+	result, err := s.findOptimalCombination(ctx, packSizes, items, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	return s.mapToPacks(result), nil
 }
 
 func (s *CalculationService) mapToPacks(resultMap map[int]int) []domain.Pack {
@@ -50,18 +64,21 @@ type dpState struct {
 
 // 1. Minimizes total items sent (primary objective)
 // 2. Minimizes number of packs (secondary objective, when items are equal)
-func (s *CalculationService) findOptimalCombination(packSizes []int, items int, maxSize int) map[int]int {
+func (s *CalculationService) findOptimalCombination(ctx context.Context, packSizes []int, items int, maxSize int) (map[int]int, error) {
 	maxTarget := items + maxSize
 
 	// For very large inputs, use optimized approach
 	if items > 100000 {
-		return s.findOptimalLargeInput(packSizes, items, maxSize)
+		return s.findOptimalLargeInput(ctx, packSizes, items, maxSize)
 	}
 
 	dp := make(map[int]*dpState)
 	dp[0] = &dpState{totalItems: 0, packCount: 0, combination: make(map[int]int)}
 
 	for target := 1; target <= maxTarget; target++ {
+		if target%cancelCheckInterval == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		best := &dpState{totalItems: maxTarget + 1, packCount: maxTarget + 1, combination: nil}
 
 		for _, size := range packSizes {
@@ -104,17 +121,17 @@ func (s *CalculationService) findOptimalCombination(packSizes []int, items int,
 	}
 
 	if bestState.combination == nil {
-		return make(map[int]int)
+		return make(map[int]int), nil
 	}
 
 	result := make(map[int]int)
 	for k, v := range bestState.combination {
 		result[k] = v
 	}
-	return result
+	return result, nil
 }
 
-func (s *CalculationService) findOptimalLargeInput(packSizes []int, items int, maxSize int) map[int]int {
+func (s *CalculationService) findOptimalLargeInput(ctx context.Context, packSizes []int, items int, maxSize int) (map[int]int, error) {
 	// Use BFS-like approach for large inputs
 	type state struct {
 		total     int
@@ -129,7 +146,10 @@ func (s *CalculationService) findOptimalLargeInput(packSizes []int, items int, m
 	bestState := &state{total: items + maxSize + 1, packCount: items + maxSize + 1, combo: nil}
 	maxTarget := items + maxSize
 
-	for len(queue) > 0 {
+	for steps := 1; len(queue) > 0; steps++ {
+		if steps%cancelCheckInterval == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		current := queue[0]
 		queue = queue[1:]
 
@@ -184,10 +204,10 @@ func (s *CalculationService) findOptimalLargeInput(packSizes []int, items int, m
 	}
 
 	if bestState.combo == nil {
-		return make(map[int]int)
+		return make(map[int]int), nil
 	}
 
-	return bestState.combo
+	return bestState.combo, nil
 }
 
 func maxSizeInSlice(packSizes []int) int {
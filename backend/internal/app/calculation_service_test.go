@@ -1,6 +1,8 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
 
@@ -186,3 +188,18 @@ func TestCalculationService_MinimizeItems(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculationService_CalculatePacksContextStopsWhenCancelled(t *testing.T) {
+	service := NewCalculationService()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, items := range []int{50000, 500000} {
+		if packs, err := service.CalculatePacksContext(ctx, []int{1, 100000}, items); !errors.Is(err, context.Canceled) || packs != nil {
+			t.Errorf("CalculatePacksContext(%d) = %v, %v, want context.Canceled", items, packs, err)
+		}
+	}
+	if packs, err := service.CalculatePacksContext(context.Background(), []int{250, 500}, 251); err != nil || len(packs) != 1 || packs[0].Size != 500 {
+		t.Errorf("CalculatePacksContext() = %v, %v, want one 500 pack", packs, err)
+	}
+}
@@ -0,0 +1,218 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+	"pack-calculator/pkg/logger"
+)
+
+// OutboxOptions configures OutboxDispatcher.
+type OutboxOptions struct {
+	// PollInterval is how often the outbox is checked when nothing wakes
+	// the dispatcher earlier.
+	PollInterval time.Duration
+	BatchSize    int
+	// MaxAttempts is how often a delivery is tried before it is marked failed.
+	MaxAttempts int
+	// Lease hides a claimed delivery from other dispatchers; it must exceed
+	// the time a sink may take to deliver.
+	Lease time.Duration
+	// InitialBackoff doubles after each failed attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Retention is how long events are kept once every sink is done with
+	// them; PruneInterval is how often they are deleted.
+	Retention     time.Duration
+	PruneInterval time.Duration
+}
+
+func DefaultOutboxOptions() OutboxOptions {
+	return OutboxOptions{
+		PollInterval:   time.Second,
+		BatchSize:      100,
+		MaxAttempts:    10,
+		Lease:          30 * time.Second,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Minute,
+		Retention:      7 * 24 * time.Hour,
+		PruneInterval:  time.Hour,
+	}
+}
+
+// OutboxDispatcher delivers outbox events to sinks with at-least-once
+// semantics: a delivery is only marked done after its sink accepted it, and
+// a dispatcher that dies mid-delivery leaves it to be claimed again once its
+// lease expires.
+type OutboxDispatcher struct {
+	store   ports.OutboxStore
+	sinks   map[string]ports.EventSink
+	names   []string
+	options OutboxOptions
+	wake    chan struct{}
+	logger  *slog.Logger
+	now     func() time.Time
+}
+
+func NewOutboxDispatcher(store ports.OutboxStore, sinks []ports.EventSink, options OutboxOptions) *OutboxDispatcher {
+	defaults := DefaultOutboxOptions()
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaults.PollInterval
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaults.BatchSize
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = defaults.MaxAttempts
+	}
+	if options.Lease <= 0 {
+		options.Lease = defaults.Lease
+	}
+	if options.InitialBackoff <= 0 {
+		options.InitialBackoff = defaults.InitialBackoff
+	}
+	if options.MaxBackoff < options.InitialBackoff {
+		options.MaxBackoff = options.InitialBackoff
+	}
+	if options.Retention <= 0 {
+		options.Retention = defaults.Retention
+	}
+	if options.PruneInterval <= 0 {
+		options.PruneInterval = defaults.PruneInterval
+	}
+
+	d := &OutboxDispatcher{
+		store:   store,
+		sinks:   make(map[string]ports.EventSink, len(sinks)),
+		options: options,
+		wake:    make(chan struct{}, 1),
+		logger:  logger.Default(),
+		now:     time.Now,
+	}
+	for _, sink := range sinks {
+		d.sinks[sink.Name()] = sink
+		d.names = append(d.names, sink.Name())
+	}
+	return d
+}
+
+// Wake makes Run check the outbox now instead of at the next poll, e.g.
+// right after a local update.
+func (d *OutboxDispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches until ctx is cancelled.
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	if len(d.sinks) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(d.options.PollInterval)
+	defer ticker.Stop()
+
+	var pruned time.Time
+	for {
+		// Keep going while batches come back full.
+		for {
+			n, err := d.DispatchOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				d.logger.Warn("Outbox dispatch failed", "error", err)
+			}
+			if err != nil || n < d.options.BatchSize {
+				break
+			}
+		}
+
+		if now := d.now(); now.Sub(pruned) >= d.options.PruneInterval {
+			pruned = now
+			if _, err := d.Prune(ctx); err != nil && ctx.Err() == nil {
+				d.logger.Warn("Outbox prune failed", "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// DispatchOnce claims and delivers one batch and returns its size.
+func (d *OutboxDispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	deliveries, err := d.store.ClaimDeliveries(ctx, d.names, d.options.BatchSize, d.options.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		d.deliver(ctx, delivery)
+	}
+	return len(deliveries), nil
+}
+
+// Prune deletes events older than the retention that every sink is done
+// with, in batches, and returns how many it deleted.
+func (d *OutboxDispatcher) Prune(ctx context.Context) (int, error) {
+	before := d.now().Add(-d.options.Retention)
+	total := 0
+	for {
+		n, err := d.store.Prune(ctx, d.names, before, d.options.BatchSize)
+		total += n
+		if err != nil || n < d.options.BatchSize {
+			return total, err
+		}
+	}
+}
+
+func (d *OutboxDispatcher) deliver(ctx context.Context, delivery domain.OutboxDelivery) {
+	log := d.logger.With("event_id", delivery.Event.ID, "sink", delivery.Sink, "attempt", delivery.Attempt)
+
+	sink, ok := d.sinks[delivery.Sink]
+	if !ok {
+		return
+	}
+
+	err := sink.Deliver(ctx, delivery.Event)
+	if err == nil {
+		if err := d.store.MarkDelivered(ctx, delivery.Event.ID, delivery.Sink); err != nil {
+			// The lease expires and the event is delivered again.
+			log.Warn("Failed to record outbox delivery", "error", err)
+		}
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	final := delivery.Attempt >= d.options.MaxAttempts
+	if final {
+		log.Error("Giving up on outbox delivery", "error", err)
+	} else {
+		log.Warn("Outbox delivery failed, will retry", "error", err)
+	}
+
+	retryAt := d.now().Add(d.backoff(delivery.Attempt))
+	if err := d.store.MarkFailed(ctx, delivery.Event.ID, delivery.Sink, err, retryAt, final); err != nil {
+		log.Warn("Failed to record outbox delivery failure", "error", err)
+	}
+}
+
+func (d *OutboxDispatcher) backoff(attempt int) time.Duration {
+	delay := d.options.InitialBackoff
+	for i := 1; i < attempt && delay < d.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.options.MaxBackoff {
+		delay = d.options.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,257 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+)
+
+type deliveryKey struct {
+	eventID int64
+	sink    string
+}
+
+type fakeDeliveryState struct {
+	attempts  int
+	delivered bool
+	failed    bool
+	retryAt   time.Time
+	lastError string
+}
+
+// fakeOutboxStore mimics the Postgres outbox: every event gets a delivery
+// per sink, and a claim returns pending deliveries that are due.
+type fakeOutboxStore struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	events []domain.OutboxEvent
+	state  map[deliveryKey]*fakeDeliveryState
+}
+
+func newFakeOutboxStore(now func() time.Time, events ...domain.OutboxEvent) *fakeOutboxStore {
+	return &fakeOutboxStore{now: now, events: events, state: make(map[deliveryKey]*fakeDeliveryState)}
+}
+
+func (s *fakeOutboxStore) ClaimDeliveries(ctx context.Context, sinks []string, limit int, lease time.Duration) ([]domain.OutboxDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []domain.OutboxDelivery
+	for _, event := range s.events {
+		for _, sink := range sinks {
+			key := deliveryKey{event.ID, sink}
+			st, ok := s.state[key]
+			if !ok {
+				st = &fakeDeliveryState{}
+				s.state[key] = st
+			}
+			if st.delivered || st.failed || s.now().Before(st.retryAt) || len(claimed) == limit {
+				continue
+			}
+			st.attempts++
+			st.retryAt = s.now().Add(lease)
+			claimed = append(claimed, domain.OutboxDelivery{Event: event, Sink: sink, Attempt: st.attempts})
+		}
+	}
+	return claimed, nil
+}
+
+func (s *fakeOutboxStore) MarkDelivered(ctx context.Context, eventID int64, sink string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state[deliveryKey{eventID, sink}].delivered = true
+	return nil
+}
+
+func (s *fakeOutboxStore) MarkFailed(ctx context.Context, eventID int64, sink string, cause error, retryAt time.Time, final bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.state[deliveryKey{eventID, sink}]
+	st.failed = final
+	st.retryAt = retryAt
+	st.lastError = cause.Error()
+	return nil
+}
+
+func (s *fakeOutboxStore) Prune(ctx context.Context, sinks []string, before time.Time, limit int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.events[:0]
+	pruned := 0
+	for _, event := range s.events {
+		settled := pruned < limit && event.CreatedAt.Before(before)
+		for _, sink := range sinks {
+			st, ok := s.state[deliveryKey{event.ID, sink}]
+			settled = settled && ok && (st.delivered || st.failed)
+		}
+		if settled {
+			pruned++
+			continue
+		}
+		kept = append(kept, event)
+	}
+	s.events = kept
+	return pruned, nil
+}
+
+func (s *fakeOutboxStore) get(eventID int64, sink string) fakeDeliveryState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.state[deliveryKey{eventID, sink}]
+}
+
+type fakeSink struct {
+	name      string
+	failUntil int
+	calls     int
+	received  []int64
+}
+
+func (s *fakeSink) Name() string {
+	return s.name
+}
+
+func (s *fakeSink) Deliver(ctx context.Context, event domain.OutboxEvent) error {
+	s.calls++
+	if s.calls <= s.failUntil {
+		return errors.New("sink unavailable")
+	}
+	s.received = append(s.received, event.ID)
+	return nil
+}
+
+func TestOutboxDispatcher_DeliversToEverySink(t *testing.T) {
+	now := time.Now()
+	store := newFakeOutboxStore(func() time.Time { return now }, domain.OutboxEvent{ID: 1}, domain.OutboxEvent{ID: 2})
+	logSink, fileSink := &fakeSink{name: "log"}, &fakeSink{name: "file"}
+	dispatcher := NewOutboxDispatcher(store, []ports.EventSink{logSink, fileSink}, DefaultOutboxOptions())
+
+	n, err := dispatcher.DispatchOnce(context.Background())
+	if err != nil || n != 4 {
+		t.Fatalf("DispatchOnce() = %d, %v, want 4 deliveries", n, err)
+	}
+	for _, sink := range []*fakeSink{logSink, fileSink} {
+		if len(sink.received) != 2 {
+			t.Errorf("sink %s received %v, want both events", sink.name, sink.received)
+		}
+	}
+
+	if n, _ := dispatcher.DispatchOnce(context.Background()); n != 0 {
+		t.Errorf("second DispatchOnce() = %d, want 0 once delivered", n)
+	}
+}
+
+func TestOutboxDispatcher_RetriesWithBackoff(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	store := newFakeOutboxStore(clock, domain.OutboxEvent{ID: 1})
+	sink := &fakeSink{name: "webhook", failUntil: 2}
+	dispatcher := NewOutboxDispatcher(store, []ports.EventSink{sink}, OutboxOptions{
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		MaxAttempts:    5,
+	})
+	dispatcher.now = clock
+
+	dispatcher.DispatchOnce(context.Background())
+	if st := store.get(1, "webhook"); st.delivered || !st.retryAt.Equal(now.Add(time.Second)) {
+		t.Fatalf("after first failure state = %+v, want retry in 1s", st)
+	}
+
+	// Not due yet.
+	if n, _ := dispatcher.DispatchOnce(context.Background()); n != 0 {
+		t.Fatalf("DispatchOnce() before backoff = %d, want 0", n)
+	}
+
+	now = now.Add(time.Second)
+	dispatcher.DispatchOnce(context.Background())
+	if st := store.get(1, "webhook"); !st.retryAt.Equal(now.Add(2 * time.Second)) {
+		t.Fatalf("after second failure state = %+v, want retry in 2s", st)
+	}
+
+	now = now.Add(2 * time.Second)
+	dispatcher.DispatchOnce(context.Background())
+	if st := store.get(1, "webhook"); !st.delivered || st.attempts != 3 {
+		t.Errorf("state = %+v, want delivered on attempt 3", st)
+	}
+}
+
+func TestOutboxDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	store := newFakeOutboxStore(clock, domain.OutboxEvent{ID: 1})
+	sink := &fakeSink{name: "webhook", failUntil: 100}
+	dispatcher := NewOutboxDispatcher(store, []ports.EventSink{sink}, OutboxOptions{MaxAttempts: 2, InitialBackoff: time.Second})
+	dispatcher.now = clock
+
+	for i := 0; i < 5; i++ {
+		dispatcher.DispatchOnce(context.Background())
+		now = now.Add(time.Hour)
+	}
+
+	if st := store.get(1, "webhook"); !st.failed || st.attempts != 2 || st.lastError != "sink unavailable" {
+		t.Errorf("state = %+v, want failed after 2 attempts", st)
+	}
+}
+
+func TestOutboxDispatcher_RedeliversAfterLeaseExpires(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	store := newFakeOutboxStore(clock, domain.OutboxEvent{ID: 1})
+
+	// A dispatcher claims the delivery and dies before recording anything.
+	claimed, _ := store.ClaimDeliveries(context.Background(), []string{"log"}, 10, time.Minute)
+	if len(claimed) != 1 {
+		t.Fatalf("claimed %d deliveries, want 1", len(claimed))
+	}
+
+	sink := &fakeSink{name: "log"}
+	dispatcher := NewOutboxDispatcher(store, []ports.EventSink{sink}, OutboxOptions{Lease: time.Minute})
+	if n, _ := dispatcher.DispatchOnce(context.Background()); n != 0 {
+		t.Fatalf("DispatchOnce() during lease = %d, want 0", n)
+	}
+
+	now = now.Add(time.Minute)
+	dispatcher.DispatchOnce(context.Background())
+	if len(sink.received) != 1 || !store.get(1, "log").delivered {
+		t.Errorf("event not redelivered after lease expiry")
+	}
+}
+
+func TestOutboxDispatcher_PrunesSettledEvents(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	old := now.Add(-8 * 24 * time.Hour)
+	store := newFakeOutboxStore(clock,
+		domain.OutboxEvent{ID: 1, CreatedAt: old},
+		domain.OutboxEvent{ID: 2, CreatedAt: old},
+		domain.OutboxEvent{ID: 3, CreatedAt: old},
+		domain.OutboxEvent{ID: 4, CreatedAt: now},
+	)
+	// The first delivery fails and stays pending.
+	sink := &fakeSink{name: "log", failUntil: 1}
+	dispatcher := NewOutboxDispatcher(store, []ports.EventSink{sink}, OutboxOptions{BatchSize: 1})
+	dispatcher.now = clock
+	for range 4 {
+		dispatcher.DispatchOnce(context.Background())
+	}
+
+	n, err := dispatcher.Prune(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("Prune() = %d, %v, want 2", n, err)
+	}
+	var kept []int64
+	for _, event := range store.events {
+		kept = append(kept, event.ID)
+	}
+	if !reflect.DeepEqual(kept, []int64{1, 4}) {
+		t.Errorf("kept events %v, want the pending and the recent one", kept)
+	}
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+const (
+	RuleMaxSizes      = "max_sizes"
+	RuleMinRatio      = "min_ratio"
+	RuleNoMultiples   = "no_multiples"
+	RuleRequiredSizes = "required_sizes"
+	packSizesField    = "/sizes"
+)
+
+// PackSizeRule checks a candidate set and returns every violation it finds.
+type PackSizeRule func(sizes []int) []pkgerrors.FieldViolation
+
+// PolicyRules builds the rule set enabled by policy.
+func PolicyRules(policy domain.PackSizePolicy) []PackSizeRule {
+	var rules []PackSizeRule
+	if policy.MaxSizes > 0 {
+		rules = append(rules, maxSizesRule(policy.MaxSizes))
+	}
+	if policy.MinRatio > 1 {
+		rules = append(rules, minRatioRule(policy.MinRatio))
+	}
+	if policy.ForbidMultiples {
+		rules = append(rules, noMultiplesRule)
+	}
+	if len(policy.RequiredSizes) > 0 {
+		rules = append(rules, requiredSizesRule(policy.RequiredSizes))
+	}
+	return rules
+}
+
+// EvaluatePolicy runs every rule and returns a *pkgerrors.ViolationsError
+// listing all violations, or nil.
+func EvaluatePolicy(rules []PackSizeRule, sizes []int) error {
+	var violations []pkgerrors.FieldViolation
+	for _, rule := range rules {
+		violations = append(violations, rule(sizes)...)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &pkgerrors.ViolationsError{Violations: violations}
+}
+
+func maxSizesRule(max int) PackSizeRule {
+	return func(sizes []int) []pkgerrors.FieldViolation {
+		if len(sizes) <= max {
+			return nil
+		}
+		return []pkgerrors.FieldViolation{{
+			Field:   packSizesField,
+			Rule:    RuleMaxSizes,
+			Message: fmt.Sprintf("at most %d pack sizes are allowed, got %d", max, len(sizes)),
+		}}
+	}
+}
+
+func minRatioRule(ratio float64) PackSizeRule {
+	return func(sizes []int) []pkgerrors.FieldViolation {
+		order := sortedIndexes(sizes)
+
+		var violations []pkgerrors.FieldViolation
+		for i := 1; i < len(order); i++ {
+			smaller, larger := sizes[order[i-1]], sizes[order[i]]
+			if float64(larger) < float64(smaller)*ratio {
+				violations = append(violations, pkgerrors.FieldViolation{
+					Field:   sizeField(order[i]),
+					Rule:    RuleMinRatio,
+					Message: fmt.Sprintf("%d must be at least %g times the next smaller size %d", larger, ratio, smaller),
+				})
+			}
+		}
+		return violations
+	}
+}
+
+func noMultiplesRule(sizes []int) []pkgerrors.FieldViolation {
+	order := sortedIndexes(sizes)
+
+	var violations []pkgerrors.FieldViolation
+	for i := 1; i < len(order); i++ {
+		larger := sizes[order[i]]
+		for j := 0; j < i; j++ {
+			smaller := sizes[order[j]]
+			if smaller > 0 && larger != smaller && larger%smaller == 0 {
+				violations = append(violations, pkgerrors.FieldViolation{
+					Field:   sizeField(order[i]),
+					Rule:    RuleNoMultiples,
+					Message: fmt.Sprintf("%d is a multiple of %d", larger, smaller),
+				})
+				break
+			}
+		}
+	}
+	return violations
+}
+
+func requiredSizesRule(required []int) PackSizeRule {
+	return func(sizes []int) []pkgerrors.FieldViolation {
+		present := make(map[int]bool, len(sizes))
+		for _, size := range sizes {
+			present[size] = true
+		}
+
+		var violations []pkgerrors.FieldViolation
+		for _, size := range required {
+			if !present[size] {
+				violations = append(violations, pkgerrors.FieldViolation{
+					Field:   packSizesField,
+					Rule:    RuleRequiredSizes,
+					Message: fmt.Sprintf("pack size %d is required", size),
+				})
+			}
+		}
+		return violations
+	}
+}
+
+// sortedIndexes returns the indexes of sizes ordered by size, so violations
+// can point at the entry in the original request.
+func sortedIndexes(sizes []int) []int {
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return sizes[order[a]] < sizes[order[b]] })
+	return order
+}
+
+func sizeField(index int) string {
+	return packSizesField + pkgerrors.Pointer(index)
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+func TestEvaluatePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy domain.PackSizePolicy
+		sizes  []int
+		want   []pkgerrors.FieldViolation
+	}{
+		{
+			name:   "no rules",
+			policy: domain.PackSizePolicy{},
+			sizes:  []int{250, 251, 500},
+		},
+		{
+			name:   "too many sizes",
+			policy: domain.PackSizePolicy{MaxSizes: 2},
+			sizes:  []int{250, 500, 1000},
+			want:   []pkgerrors.FieldViolation{{Field: "/sizes", Rule: RuleMaxSizes, Message: "at most 2 pack sizes are allowed, got 3"}},
+		},
+		{
+			name:   "ratio points at the larger size in request order",
+			policy: domain.PackSizePolicy{MinRatio: 1.5},
+			sizes:  []int{1000, 250, 300},
+			want:   []pkgerrors.FieldViolation{{Field: "/sizes/2", Rule: RuleMinRatio, Message: "300 must be at least 1.5 times the next smaller size 250"}},
+		},
+		{
+			name:   "multiples",
+			policy: domain.PackSizePolicy{ForbidMultiples: true},
+			sizes:  []int{250, 500, 1000, 333},
+			want: []pkgerrors.FieldViolation{
+				{Field: "/sizes/1", Rule: RuleNoMultiples, Message: "500 is a multiple of 250"},
+				{Field: "/sizes/2", Rule: RuleNoMultiples, Message: "1000 is a multiple of 250"},
+			},
+		},
+		{
+			name:   "missing unit pack",
+			policy: domain.PackSizePolicy{RequiredSizes: []int{1}},
+			sizes:  []int{250, 500},
+			want:   []pkgerrors.FieldViolation{{Field: "/sizes", Rule: RuleRequiredSizes, Message: "pack size 1 is required"}},
+		},
+		{
+			name:   "all violations are reported",
+			policy: domain.PackSizePolicy{MaxSizes: 1, RequiredSizes: []int{1}},
+			sizes:  []int{250, 500},
+			want: []pkgerrors.FieldViolation{
+				{Field: "/sizes", Rule: RuleMaxSizes, Message: "at most 1 pack sizes are allowed, got 2"},
+				{Field: "/sizes", Rule: RuleRequiredSizes, Message: "pack size 1 is required"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := EvaluatePolicy(PolicyRules(tt.policy), tt.sizes)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("EvaluatePolicy() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var violations *pkgerrors.ViolationsError
+			if !errors.As(err, &violations) {
+				t.Fatalf("EvaluatePolicy() error = %v, want ViolationsError", err)
+			}
+			if !errors.Is(err, pkgerrors.ErrPolicyViolation) {
+				t.Errorf("EvaluatePolicy() error should match ErrPolicyViolation")
+			}
+			if !reflect.DeepEqual(violations.Violations, tt.want) {
+				t.Errorf("violations = %+v, want %+v", violations.Violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackService_UpdatePackSizes_Policy(t *testing.T) {
+	ctx := context.Background()
+	created := false
+	repo := &mockRepository{
+		createFunc: func(sizes []int) (int, error) {
+			created = true
+			return 1, nil
+		},
+	}
+	options := DefaultPackServiceOptions()
+	options.Policy = domain.PackSizePolicy{ForbidMultiples: true}
+	service := NewPackService(repo, &mockCache{}, NewCalculationService(), options)
+
+	if err := service.UpdatePackSizes(ctx, []int{250, 500}); !errors.Is(err, pkgerrors.ErrPolicyViolation) {
+		t.Fatalf("UpdatePackSizes() error = %v, want ErrPolicyViolation", err)
+	}
+	if created {
+		t.Error("UpdatePackSizes() persisted a set that violates the policy")
+	}
+
+	if err := service.UpdatePackSizes(ctx, []int{250, 333}); err != nil {
+		t.Fatalf("UpdatePackSizes() error = %v", err)
+	}
+	if !created {
+		t.Error("UpdatePackSizes() did not persist a valid set")
+	}
+}
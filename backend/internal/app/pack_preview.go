@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// PackSizeImpact summarises how a pack-size set handles an order sample.
+type PackSizeImpact struct {
+	Sizes          []int
+	TotalItems     int
+	TotalShipped   int
+	TotalOvershoot int
+	TotalPacks     int
+	// AverageOvershoot is the mean number of items shipped beyond the order.
+	AverageOvershoot float64
+	AveragePacks     float64
+}
+
+// OrderChange is an order whose packs differ between the current and the
+// candidate set.
+type OrderChange struct {
+	Items     int
+	Current   []domain.Pack
+	Candidate []domain.Pack
+}
+
+// PackSizePreview compares the current and a candidate pack-size set on the
+// same orders.
+type PackSizePreview struct {
+	Current   PackSizeImpact
+	Candidate PackSizeImpact
+	Changed   []OrderChange
+	// Violations lists the policy rules the candidate breaks; publishing it
+	// would be rejected.
+	Violations []pkgerrors.FieldViolation
+}
+
+// PreviewPackSizes runs orders through the current and the candidate sets
+// without persisting anything. The candidate must pass the hard checks;
+// policy violations are reported rather than returned as an error.
+func (s *PackService) PreviewPackSizes(ctx context.Context, candidate []int, orders []int) (PackSizePreview, error) {
+	if err := validatePackSizes(candidate); err != nil {
+		return PackSizePreview{}, err
+	}
+	if len(orders) == 0 || len(orders) > pkgerrors.MaxPreviewOrders {
+		return PackSizePreview{}, pkgerrors.ErrPreviewOrdersInvalid
+	}
+	for _, items := range orders {
+		if items < pkgerrors.MinItems || items > pkgerrors.MaxPreviewItems {
+			return PackSizePreview{}, pkgerrors.ErrItemsOutOfRange
+		}
+	}
+
+	current, err := s.GetPackSizes(ctx)
+	if err != nil {
+		return PackSizePreview{}, pkgerrors.Wrap(err, "failed to get pack sizes")
+	}
+
+	preview := PackSizePreview{
+		Current:   PackSizeImpact{Sizes: current},
+		Candidate: PackSizeImpact{Sizes: append([]int(nil), candidate...)},
+	}
+	var violations *pkgerrors.ViolationsError
+	if errors.As(EvaluatePolicy(s.rules, candidate), &violations) {
+		preview.Violations = violations.Violations
+	}
+
+	// Samples usually repeat quantities; each one is calculated once per set.
+	type outcome struct{ current, candidate []domain.Pack }
+	outcomes := make(map[int]outcome)
+	for _, items := range orders {
+		o, ok := outcomes[items]
+		if !ok {
+			if o.current, err = s.calculationSvc.CalculatePacksContext(ctx, current, items); err != nil {
+				return PackSizePreview{}, err
+			}
+			if o.candidate, err = s.calculationSvc.CalculatePacksContext(ctx, candidate, items); err != nil {
+				return PackSizePreview{}, err
+			}
+			outcomes[items] = o
+			if !samePacks(o.current, o.candidate) {
+				preview.Changed = append(preview.Changed, OrderChange{Items: items, Current: o.current, Candidate: o.candidate})
+			}
+		}
+
+		preview.Current.add(items, 1, o.current)
+		preview.Candidate.add(items, 1, o.candidate)
+	}
+
+	preview.Current.average(len(orders))
+	preview.Candidate.average(len(orders))
+	return preview, nil
+}
+
+// add records weight orders of items each, all packed as packs.
+func (i *PackSizeImpact) add(items, weight int, packs []domain.Pack) {
+	shipped, count := 0, 0
+	for _, p := range packs {
+		shipped += p.Size * p.Quantity
+		count += p.Quantity
+	}
+	i.TotalItems += items * weight
+	i.TotalShipped += shipped * weight
+	i.TotalOvershoot += (shipped - items) * weight
+	i.TotalPacks += count * weight
+}
+
+func (i *PackSizeImpact) average(orders int) {
+	i.AverageOvershoot = float64(i.TotalOvershoot) / float64(orders)
+	i.AveragePacks = float64(i.TotalPacks) / float64(orders)
+}
+
+func samePacks(a, b []domain.Pack) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
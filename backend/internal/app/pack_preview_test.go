@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+func TestPackService_PreviewPackSizes(t *testing.T) {
+	ctx := context.Background()
+	created := false
+	repo := &mockRepository{
+		createFunc: func(sizes []int) (int, error) {
+			created = true
+			return 2, nil
+		},
+	}
+	cache := &mockCache{
+		getFunc: func(key string) ([]int, error) {
+			return []int{250, 500, 1000}, nil
+		},
+	}
+	options := DefaultPackServiceOptions()
+	options.Policy = domain.PackSizePolicy{MaxSizes: 3}
+	service := NewPackService(repo, cache, NewCalculationService(), options)
+
+	preview, err := service.PreviewPackSizes(ctx, []int{100, 250, 500, 1000}, []int{1, 251, 251, 1000})
+	if err != nil {
+		t.Fatalf("PreviewPackSizes() error = %v", err)
+	}
+	if created {
+		t.Error("PreviewPackSizes() persisted the candidate")
+	}
+
+	wantCurrent := PackSizeImpact{
+		Sizes:            []int{250, 500, 1000},
+		TotalItems:       1503,
+		TotalShipped:     2250,
+		TotalOvershoot:   747,
+		TotalPacks:       4,
+		AverageOvershoot: 186.75,
+		AveragePacks:     1,
+	}
+	if !reflect.DeepEqual(preview.Current, wantCurrent) {
+		t.Errorf("Current = %+v, want %+v", preview.Current, wantCurrent)
+	}
+	if preview.Candidate.TotalShipped != 1700 || preview.Candidate.TotalPacks != 8 {
+		t.Errorf("Candidate = %+v, want 1700 shipped in 8 packs", preview.Candidate)
+	}
+
+	// 251 appears twice but is one changed order; 1000 is unchanged.
+	if len(preview.Changed) != 2 || preview.Changed[0].Items != 1 || preview.Changed[1].Items != 251 {
+		t.Fatalf("Changed = %+v, want orders 1 and 251", preview.Changed)
+	}
+	wantCandidate := []domain.Pack{{Size: 100, Quantity: 3}}
+	if !reflect.DeepEqual(preview.Changed[1].Candidate, wantCandidate) {
+		t.Errorf("Changed[1].Candidate = %+v, want %+v", preview.Changed[1].Candidate, wantCandidate)
+	}
+
+	if len(preview.Violations) != 1 || preview.Violations[0].Rule != RuleMaxSizes {
+		t.Errorf("Violations = %+v, want max_sizes", preview.Violations)
+	}
+}
+
+func TestPackService_PreviewPackSizes_Validation(t *testing.T) {
+	ctx := context.Background()
+	service := NewPackService(&mockRepository{}, &mockCache{}, NewCalculationService(), DefaultPackServiceOptions())
+
+	tests := []struct {
+		name      string
+		candidate []int
+		orders    []int
+		wantErr   error
+	}{
+		{"empty candidate", nil, []int{1}, pkgerrors.ErrPackSizesEmpty},
+		{"duplicate candidate", []int{5, 5}, []int{1}, pkgerrors.ErrDuplicatePackSizes},
+		{"no orders", []int{5}, nil, pkgerrors.ErrPreviewOrdersInvalid},
+		{"too many orders", []int{5}, make([]int, pkgerrors.MaxPreviewOrders+1), pkgerrors.ErrPreviewOrdersInvalid},
+		{"invalid order", []int{5}, []int{0}, pkgerrors.ErrItemsOutOfRange},
+		{"order too large", []int{5}, []int{pkgerrors.MaxPreviewItems + 1}, pkgerrors.ErrItemsOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.PreviewPackSizes(ctx, tt.candidate, tt.orders); !errors.Is(err, tt.wantErr) {
+				t.Errorf("PreviewPackSizes() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPackService_PreviewPackSizes_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cache := &mockCache{
+		getFunc: func(key string) ([]int, error) {
+			// The request is cancelled once the current sizes are read.
+			cancel()
+			return []int{23, 31, 53}, nil
+		},
+	}
+	service := NewPackService(&mockRepository{}, cache, NewCalculationService(), DefaultPackServiceOptions())
+
+	if _, err := service.PreviewPackSizes(ctx, []int{23, 31}, []int{pkgerrors.MaxPreviewItems}); !errors.Is(err, context.Canceled) {
+		t.Errorf("PreviewPackSizes() error = %v, want context.Canceled", err)
+	}
+}
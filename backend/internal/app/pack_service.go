@@ -1,62 +1,354 @@
 package app
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
 
 	"pack-calculator/internal/domain"
 	"pack-calculator/internal/ports"
 	pkgerrors "pack-calculator/pkg/errors"
 	"pack-calculator/pkg/logger"
+
+	"golang.org/x/sync/singleflight"
 )
 
+const packSizesLoadKey = "pack-sizes"
+
+// PackServiceOptions configures how PackService uses the cache.
+type PackServiceOptions struct {
+	// CacheEnabled turns caching of pack sizes on or off.
+	CacheEnabled bool
+	// CacheTTL is how long cached pack sizes live.
+	CacheTTL time.Duration
+	// KeyPrefix namespaces cache keys so several deployments can share one
+	// Redis. Empty means no prefix.
+	KeyPrefix string
+	// EarlyRefreshBeta enables probabilistic early refresh of the cached pack
+	// sizes (XFetch): a cache hit may trigger a background reload shortly
+	// before the entry expires, so it rarely expires under load. Larger values
+	// refresh earlier; 0 disables early refresh.
+	EarlyRefreshBeta float64
+	// Policy holds the soft rules every update is checked against. The
+	// service holds a single catalog, so this is that catalog's policy.
+	Policy domain.PackSizePolicy
+}
+
+func DefaultPackServiceOptions() PackServiceOptions {
+	return PackServiceOptions{
+		CacheEnabled: true,
+		CacheTTL:     time.Hour,
+	}
+}
+
 type PackServiceInterface interface {
-	GetPackSizes() ([]int, error)
-	UpdatePackSizes(sizes []int) error
-	CalculatePacks(items int) ([]domain.Pack, error)
+	GetPackSizes(ctx context.Context) ([]int, error)
+	UpdatePackSizes(ctx context.Context, sizes []int) error
+	CalculatePacks(ctx context.Context, items int) ([]domain.Pack, error)
+	PreviewPackSizes(ctx context.Context, candidate []int, orders []int) (PackSizePreview, error)
 }
 
 type PackService struct {
 	repo           ports.PackSizeRepository
-	cache          ports.Cache
+	cache          ports.TypedCache[[]int]
 	calculationSvc *CalculationService
+	notifier       ports.ChangeNotifier
+	rules          []PackSizeRule
 	logger         *slog.Logger
+
+	options  PackServiceOptions
+	cacheTTL time.Duration
+	// loads coalesces concurrent repository reads after a cache miss.
+	loads  singleflight.Group
+	now    func() time.Time
+	random func() float64
+
+	mu        sync.Mutex
+	version   int
+	listeners []func(domain.PackSizesChanged)
+	// calculated is notified after every successful calculation.
+	calculated []func(domain.PacksCalculated)
+	// expiresAt and loadTime describe the cache entry last written by this
+	// instance; they drive early refresh.
+	expiresAt time.Time
+	loadTime  time.Duration
 }
 
-func NewPackService(repo ports.PackSizeRepository, cache ports.Cache, calculationSvc *CalculationService) *PackService {
+func NewPackService(repo ports.PackSizeRepository, cache ports.TypedCache[[]int], calculationSvc *CalculationService, options PackServiceOptions) *PackService {
+	cacheTTL := options.CacheTTL
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultPackServiceOptions().CacheTTL
+	}
+
 	return &PackService{
 		repo:           repo,
 		cache:          cache,
 		calculationSvc: calculationSvc,
+		rules:          PolicyRules(options.Policy),
 		logger:         logger.Default(),
+		options:        options,
+		cacheTTL:       cacheTTL,
+		now:            time.Now,
+		random:         rand.Float64,
 	}
 }
 
-func (s *PackService) GetPackSizes() ([]int, error) {
-	cacheKey := "pack-sizes:active"
+// WithChangeNotifier publishes a change event on every update and lets Watch
+// receive events published by other instances.
+func (s *PackService) WithChangeNotifier(notifier ports.ChangeNotifier) *PackService {
+	s.notifier = notifier
+	return s
+}
+
+// OnPackSizesChanged registers fn to refresh state derived from the active
+// pack sizes. Each version is delivered at most once per instance, whether the
+// update happened locally or on another instance.
+func (s *PackService) OnPackSizesChanged(fn func(domain.PackSizesChanged)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, fn)
+}
+
+// OnPacksCalculated registers fn to be called synchronously after every
+// successful calculation.
+func (s *PackService) OnPacksCalculated(fn func(domain.PacksCalculated)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calculated = append(s.calculated, fn)
+}
+
+// Watch receives change events from other instances until ctx is cancelled,
+// resubscribing after failures.
+func (s *PackService) Watch(ctx context.Context) {
+	if s.notifier == nil {
+		return
+	}
+
+	for {
+		err := s.notifier.Subscribe(ctx, s.handleChange)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.logger.Warn("Change subscription failed, retrying", "error", err)
+		}
 
-	sizes, err := s.cache.Get(cacheKey)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (s *PackService) handleChange(event domain.PackSizesChanged) {
+	s.mu.Lock()
+	if event.Version <= s.version {
+		s.mu.Unlock()
+		return
+	}
+	s.version = event.Version
+	listeners := append([]func(domain.PackSizesChanged){}, s.listeners...)
+	s.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+func (s *PackService) GetPackSizes(ctx context.Context) ([]int, error) {
+	sizes, err := s.getCachedPackSizes(ctx)
 	if err == nil {
+		if s.shouldRefreshEarly() {
+			// The refresh outlives the request that triggered it.
+			refreshCtx := context.WithoutCancel(ctx)
+			s.loads.DoChan(packSizesLoadKey, func() (interface{}, error) {
+				return s.loadPackSizes(refreshCtx)
+			})
+		}
 		return sizes, nil
 	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 
 	if !errors.Is(err, pkgerrors.ErrNotFound) {
-		s.logger.Warn("Cache get failed, falling back to repository", "error", err, "key", cacheKey)
+		s.logger.Warn("Cache get failed, falling back to repository", "error", err)
 	}
 
-	sizes, err = s.repo.GetAllActive()
+	// Only one caller per instance reads the repository after a miss; the
+	// others wait for and share its result. The load runs with the first
+	// caller's context, so if that caller is cancelled the waiters whose own
+	// contexts are still live start another shared load. Singleflight drops a
+	// finished call before delivering its result, so the next DoChan starts a
+	// fresh one.
+	for {
+		results := s.loads.DoChan(packSizesLoadKey, func() (interface{}, error) {
+			sizes, err := s.loadPackSizes(ctx)
+			if err != nil && ctx.Err() != nil {
+				return nil, errLoadCancelled
+			}
+			return sizes, err
+		})
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case result := <-results:
+			if errors.Is(result.Err, errLoadCancelled) {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				continue
+			}
+			if result.Err != nil {
+				return nil, result.Err
+			}
+			return append([]int(nil), result.Val.([]int)...), nil
+		}
+	}
+}
+
+// errLoadCancelled marks a shared load abandoned because the caller running
+// it was cancelled, as opposed to the repository failing.
+var errLoadCancelled = errors.New("pack sizes load cancelled")
+
+// getCachedPackSizes follows the version pointer to the entry for that
+// version. Entries are immutable, so only the pointer can ever be stale.
+func (s *PackService) getCachedPackSizes(ctx context.Context) ([]int, error) {
+	if !s.options.CacheEnabled {
+		return nil, pkgerrors.ErrNotFound
+	}
+
+	version, err := s.cache.GetVersion(ctx, s.pointerKey())
+	if err != nil {
+		return nil, err
+	}
+	return s.cache.Get(ctx, s.versionKey(version))
+}
+
+func (s *PackService) loadPackSizes(ctx context.Context) (interface{}, error) {
+	start := s.now()
+	set, err := s.repo.GetAllActive(ctx)
 	if err != nil {
 		return nil, pkgerrors.Wrap(err, "failed to get pack sizes from repository")
 	}
 
-	if err := s.cache.Set(cacheKey, sizes, 3600); err != nil {
-		s.logger.Warn("Failed to set cache", "error", err, "key", cacheKey)
+	s.mu.Lock()
+	s.loadTime = s.now().Sub(start)
+	s.mu.Unlock()
+
+	s.storePackSizes(ctx, set)
+	return set.Sizes, nil
+}
+
+// storePackSizes writes the versioned entry before advancing the pointer, so
+// the pointer never references a missing entry. A reader that loaded an older
+// version concurrently with an update only writes its own versioned entry;
+// the pointer refuses to move backwards.
+func (s *PackService) storePackSizes(ctx context.Context, set domain.PackSizeSet) {
+	if !s.options.CacheEnabled {
+		return
+	}
+
+	key := s.versionKey(set.Version)
+	if err := s.cache.Set(ctx, key, set.Sizes, s.cacheTTL); err != nil {
+		s.logger.Warn("Failed to set cache", "error", err, "key", key)
+		return
+	}
+	pointerKey := s.pointerKey()
+	if err := s.cache.AdvanceVersion(ctx, pointerKey, set.Version, s.cacheTTL); err != nil {
+		s.logger.Warn("Failed to advance cached version", "error", err, "key", pointerKey, "version", set.Version)
+		return
+	}
+
+	s.mu.Lock()
+	s.expiresAt = s.now().Add(s.cacheTTL)
+	s.mu.Unlock()
+}
+
+func (s *PackService) pointerKey() string {
+	return s.key("pack-sizes:current")
+}
+
+func (s *PackService) versionKey(version int) string {
+	return s.key(fmt.Sprintf("pack-sizes:v%d", version))
+}
+
+func (s *PackService) key(name string) string {
+	if s.options.KeyPrefix == "" {
+		return name
+	}
+	return s.options.KeyPrefix + ":" + name
+}
+
+// shouldRefreshEarly implements the XFetch check
+// now - loadTime*beta*ln(rand) >= expiry.
+func (s *PackService) shouldRefreshEarly() bool {
+	if s.options.EarlyRefreshBeta <= 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	expiresAt, loadTime := s.expiresAt, s.loadTime
+	s.mu.Unlock()
+
+	if expiresAt.IsZero() {
+		return false
+	}
+
+	gap := time.Duration(float64(loadTime) * s.options.EarlyRefreshBeta * -math.Log(s.random()))
+	return !s.now().Add(gap).Before(expiresAt)
+}
+
+func (s *PackService) UpdatePackSizes(ctx context.Context, sizes []int) error {
+	if err := validatePackSizes(sizes); err != nil {
+		return err
 	}
+	if err := EvaluatePolicy(s.rules, sizes); err != nil {
+		return err
+	}
+
+	version, err := s.repo.Create(ctx, sizes)
+	if err != nil {
+		return pkgerrors.Wrap(err, "failed to create pack sizes")
+	}
+
+	principal, _ := domain.PrincipalFrom(ctx)
+	s.logger.Info("Pack sizes updated", "version", version, "sizes", sizes,
+		"subject", principal.Subject, "role", principal.Role, "tenant", principal.Tenant)
 
-	return sizes, nil
+	// The update is committed; finish caching and notifying even if the
+	// client goes away now.
+	ctx = context.WithoutCancel(ctx)
+	s.storePackSizes(ctx, domain.PackSizeSet{Version: version, Sizes: sizes})
+
+	event := domain.PackSizesChanged{
+		Version:   version,
+		Sizes:     append([]int(nil), sizes...),
+		ChangedAt: s.now().UTC(),
+		ChangedBy: principal.Subject,
+		Tenant:    principal.Tenant,
+	}
+	s.handleChange(event)
+	if s.notifier != nil {
+		if err := s.notifier.Publish(ctx, event); err != nil {
+			s.logger.Warn("Failed to publish pack sizes change", "error", err, "version", version)
+		}
+	}
+
+	return nil
 }
 
-func (s *PackService) UpdatePackSizes(sizes []int) error {
+// validatePackSizes applies the hard checks every pack-size set must pass.
+func validatePackSizes(sizes []int) error {
 	if len(sizes) == 0 {
 		return pkgerrors.ErrPackSizesEmpty
 	}
@@ -71,30 +363,34 @@ func (s *PackService) UpdatePackSizes(sizes []int) error {
 		}
 		seen[size] = true
 	}
-
-	if err := s.repo.Create(sizes); err != nil {
-		return pkgerrors.Wrap(err, "failed to create pack sizes")
-	}
-
-	// Invalidate cache by deleting the active key
-	// New requests will fetch from DB and cache with new version
-	cacheKey := "pack-sizes:active"
-	if err := s.cache.Delete(cacheKey); err != nil {
-		s.logger.Warn("Failed to delete cache", "error", err, "key", cacheKey)
-	}
-
 	return nil
 }
 
-func (s *PackService) CalculatePacks(items int) ([]domain.Pack, error) {
+func (s *PackService) CalculatePacks(ctx context.Context, items int) ([]domain.Pack, error) {
 	if items < pkgerrors.MinItems || items > pkgerrors.MaxItems {
 		return nil, pkgerrors.ErrItemsOutOfRange
 	}
 
-	packSizes, err := s.GetPackSizes()
+	packSizes, err := s.GetPackSizes(ctx)
 	if err != nil {
 		return nil, pkgerrors.Wrap(err, "failed to get pack sizes")
 	}
 
-	return s.calculationSvc.CalculatePacks(packSizes, items), nil
+	packs := s.calculationSvc.CalculatePacks(packSizes, items)
+
+	s.mu.Lock()
+	listeners := s.calculated
+	s.mu.Unlock()
+	if len(listeners) > 0 {
+		event := domain.PacksCalculated{
+			Items:        items,
+			Packs:        append([]domain.Pack(nil), packs...),
+			CalculatedAt: s.now().UTC(),
+		}
+		for _, listener := range listeners {
+			listener(event)
+		}
+	}
+
+	return packs, nil
 }
@@ -1,56 +1,101 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"pack-calculator/internal/domain"
 	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
 )
 
 type mockRepository struct {
 	getAllActiveFunc func() ([]int, error)
-	createFunc       func(sizes []int) error
+	createFunc       func(sizes []int) (int, error)
 }
 
-func (m *mockRepository) GetAllActive() ([]int, error) {
+func (m *mockRepository) GetAllActive(ctx context.Context) (domain.PackSizeSet, error) {
 	if m.getAllActiveFunc != nil {
-		return m.getAllActiveFunc()
+		sizes, err := m.getAllActiveFunc()
+		return domain.PackSizeSet{Version: 1, Sizes: sizes}, err
 	}
-	return nil, nil
+	return domain.PackSizeSet{}, nil
 }
 
-func (m *mockRepository) Create(sizes []int) error {
+func (m *mockRepository) Create(ctx context.Context, sizes []int) (int, error) {
 	if m.createFunc != nil {
 		return m.createFunc(sizes)
 	}
-	return nil
+	return 1, nil
 }
 
 type mockCache struct {
-	getFunc    func(key string) ([]int, error)
-	setFunc    func(key string, value []int, ttl int) error
-	deleteFunc func(key string) error
+	getFunc            func(key string) ([]int, error)
+	setFunc            func(key string, value []int, ttl time.Duration) error
+	deleteFunc         func(key string) error
+	getVersionFunc     func(key string) (int, error)
+	advanceVersionFunc func(key string, version int, ttl time.Duration) error
 }
 
-func (m *mockCache) Get(key string) ([]int, error) {
+func (m *mockCache) Get(ctx context.Context, key string) ([]int, error) {
 	if m.getFunc != nil {
 		return m.getFunc(key)
 	}
 	return nil, errors.New("key not found")
 }
 
-func (m *mockCache) Set(key string, value []int, ttl int) error {
+func (m *mockCache) GetMany(ctx context.Context, keys []string) (map[string][]int, error) {
+	values := make(map[string][]int)
+	for _, key := range keys {
+		if value, err := m.Get(ctx, key); err == nil {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func (m *mockCache) Set(ctx context.Context, key string, value []int, ttl time.Duration) error {
 	if m.setFunc != nil {
 		return m.setFunc(key, value, ttl)
 	}
 	return nil
 }
 
-func (m *mockCache) Delete(key string) error {
-	if m.deleteFunc != nil {
-		return m.deleteFunc(key)
+func (m *mockCache) SetMany(ctx context.Context, items map[string][]int, ttl time.Duration) error {
+	for key, value := range items {
+		if err := m.Set(ctx, key, value, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockCache) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if m.deleteFunc != nil {
+			if err := m.deleteFunc(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mockCache) GetVersion(ctx context.Context, key string) (int, error) {
+	if m.getVersionFunc != nil {
+		return m.getVersionFunc(key)
+	}
+	return 1, nil
+}
+
+func (m *mockCache) AdvanceVersion(ctx context.Context, key string, version int, ttl time.Duration) error {
+	if m.advanceVersionFunc != nil {
+		return m.advanceVersionFunc(key, version, ttl)
 	}
 	return nil
 }
@@ -59,7 +104,7 @@ func TestPackService_GetPackSizes(t *testing.T) {
 	tests := []struct {
 		name           string
 		repo           ports.PackSizeRepository
-		cache          ports.Cache
+		cache          ports.TypedCache[[]int]
 		want           []int
 		wantErr        bool
 		cacheCalled    bool
@@ -84,7 +129,7 @@ func TestPackService_GetPackSizes(t *testing.T) {
 				getFunc: func(key string) ([]int, error) {
 					return nil, errors.New("key not found")
 				},
-				setFunc: func(key string, value []int, ttl int) error {
+				setFunc: func(key string, value []int, ttl time.Duration) error {
 					return nil
 				},
 			},
@@ -122,7 +167,7 @@ func TestPackService_GetPackSizes(t *testing.T) {
 				getFunc: func(key string) ([]int, error) {
 					return nil, errors.New("key not found")
 				},
-				setFunc: func(key string, value []int, ttl int) error {
+				setFunc: func(key string, value []int, ttl time.Duration) error {
 					return errors.New("cache set failed")
 				},
 			},
@@ -142,8 +187,8 @@ func TestPackService_GetPackSizes(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			calcService := NewCalculationService()
-			service := NewPackService(tt.repo, tt.cache, calcService)
-			got, err := service.GetPackSizes()
+			service := NewPackService(tt.repo, tt.cache, calcService, DefaultPackServiceOptions())
+			got, err := service.GetPackSizes(context.Background())
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetPackSizes() error = %v, wantErr %v", err, tt.wantErr)
@@ -159,38 +204,36 @@ func TestPackService_GetPackSizes(t *testing.T) {
 
 func TestPackService_UpdatePackSizes(t *testing.T) {
 	tests := []struct {
-		name         string
-		repo         ports.PackSizeRepository
-		cache        ports.Cache
-		sizes        []int
-		wantErr      bool
-		repoCalled   bool
-		cacheCalled  bool
-		deleteCalled bool
+		name        string
+		repo        ports.PackSizeRepository
+		cache       ports.TypedCache[[]int]
+		sizes       []int
+		wantErr     bool
+		repoCalled  bool
+		cacheCalled bool
 	}{
 		{
 			name: "successful update",
 			repo: &mockRepository{
-				createFunc: func(sizes []int) error {
-					return nil
+				createFunc: func(sizes []int) (int, error) {
+					return 1, nil
 				},
 			},
 			cache: &mockCache{
-				deleteFunc: func(key string) error {
+				setFunc: func(key string, value []int, ttl time.Duration) error {
 					return nil
 				},
 			},
-			sizes:        []int{250, 500, 1000},
-			wantErr:      false,
-			repoCalled:   true,
-			cacheCalled:  true,
-			deleteCalled: true,
+			sizes:       []int{250, 500, 1000},
+			wantErr:     false,
+			repoCalled:  true,
+			cacheCalled: true,
 		},
 		{
 			name: "repository error",
 			repo: &mockRepository{
-				createFunc: func(sizes []int) error {
-					return errors.New("database error")
+				createFunc: func(sizes []int) (int, error) {
+					return 0, errors.New("database error")
 				},
 			},
 			cache:      &mockCache{},
@@ -199,30 +242,46 @@ func TestPackService_UpdatePackSizes(t *testing.T) {
 			repoCalled: true,
 		},
 		{
-			name: "cache delete error doesn't fail request",
+			name: "cache set error doesn't fail request",
 			repo: &mockRepository{
-				createFunc: func(sizes []int) error {
-					return nil
+				createFunc: func(sizes []int) (int, error) {
+					return 1, nil
 				},
 			},
 			cache: &mockCache{
-				deleteFunc: func(key string) error {
-					return errors.New("cache delete failed")
+				setFunc: func(key string, value []int, ttl time.Duration) error {
+					return errors.New("cache set failed")
 				},
 			},
-			sizes:        []int{250, 500},
-			wantErr:      false,
-			repoCalled:   true,
-			cacheCalled:  true,
-			deleteCalled: true,
+			sizes:       []int{250, 500},
+			wantErr:     false,
+			repoCalled:  true,
+			cacheCalled: true,
+		},
+		{
+			name: "cache version error doesn't fail request",
+			repo: &mockRepository{
+				createFunc: func(sizes []int) (int, error) {
+					return 1, nil
+				},
+			},
+			cache: &mockCache{
+				advanceVersionFunc: func(key string, version int, ttl time.Duration) error {
+					return errors.New("cache version failed")
+				},
+			},
+			sizes:       []int{250, 500},
+			wantErr:     false,
+			repoCalled:  true,
+			cacheCalled: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			calcService := NewCalculationService()
-			service := NewPackService(tt.repo, tt.cache, calcService)
-			err := service.UpdatePackSizes(tt.sizes)
+			service := NewPackService(tt.repo, tt.cache, calcService, DefaultPackServiceOptions())
+			err := service.UpdatePackSizes(context.Background(), tt.sizes)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("UpdatePackSizes() error = %v, wantErr %v", err, tt.wantErr)
@@ -235,7 +294,7 @@ func TestPackService_CalculatePacks(t *testing.T) {
 	tests := []struct {
 		name    string
 		repo    ports.PackSizeRepository
-		cache   ports.Cache
+		cache   ports.TypedCache[[]int]
 		items   int
 		want    []domain.Pack
 		wantErr bool
@@ -305,8 +364,8 @@ func TestPackService_CalculatePacks(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			calcService := NewCalculationService()
-			service := NewPackService(tt.repo, tt.cache, calcService)
-			got, err := service.CalculatePacks(tt.items)
+			service := NewPackService(tt.repo, tt.cache, calcService, DefaultPackServiceOptions())
+			got, err := service.CalculatePacks(context.Background(), tt.items)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CalculatePacks() error = %v, wantErr %v", err, tt.wantErr)
@@ -320,6 +379,35 @@ func TestPackService_CalculatePacks(t *testing.T) {
 	}
 }
 
+func TestPackService_OnPacksCalculated(t *testing.T) {
+	ctx := context.Background()
+	cache := &mockCache{
+		getFunc: func(key string) ([]int, error) {
+			return []int{250, 500, 1000}, nil
+		},
+	}
+	service := NewPackService(&mockRepository{}, cache, NewCalculationService(), DefaultPackServiceOptions())
+
+	var events []domain.PacksCalculated
+	service.OnPacksCalculated(func(event domain.PacksCalculated) {
+		events = append(events, event)
+	})
+
+	if _, err := service.CalculatePacks(ctx, 251); err != nil {
+		t.Fatalf("CalculatePacks() error = %v", err)
+	}
+	if _, err := service.CalculatePacks(ctx, 0); err == nil {
+		t.Fatal("CalculatePacks(0) error = nil, want error")
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1 for the successful calculation", len(events))
+	}
+	if events[0].Items != 251 || !reflect.DeepEqual(events[0].Packs, []domain.Pack{{Size: 500, Quantity: 1}}) {
+		t.Errorf("event = %+v", events[0])
+	}
+}
+
 func TestPackService_UpdatePackSizes_Validation(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -366,18 +454,14 @@ func TestPackService_UpdatePackSizes_Validation(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := &mockRepository{
-				createFunc: func(sizes []int) error {
-					return nil
-				},
-			}
-			cache := &mockCache{
-				deleteFunc: func(key string) error {
-					return nil
+				createFunc: func(sizes []int) (int, error) {
+					return 1, nil
 				},
 			}
+			cache := &mockCache{}
 			calcService := NewCalculationService()
-			service := NewPackService(repo, cache, calcService)
-			err := service.UpdatePackSizes(tt.sizes)
+			service := NewPackService(repo, cache, calcService, DefaultPackServiceOptions())
+			err := service.UpdatePackSizes(context.Background(), tt.sizes)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("UpdatePackSizes() error = %v, wantErr %v", err, tt.wantErr)
@@ -385,3 +469,642 @@ func TestPackService_UpdatePackSizes_Validation(t *testing.T) {
 		})
 	}
 }
+
+type mockNotifier struct {
+	published []domain.PackSizesChanged
+	events    chan domain.PackSizesChanged
+}
+
+func (m *mockNotifier) Publish(ctx context.Context, event domain.PackSizesChanged) error {
+	m.published = append(m.published, event)
+	return nil
+}
+
+func (m *mockNotifier) Subscribe(ctx context.Context, handle func(domain.PackSizesChanged)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-m.events:
+			handle(event)
+		}
+	}
+}
+
+func TestPackService_ChangeNotifications(t *testing.T) {
+	notifier := &mockNotifier{events: make(chan domain.PackSizesChanged)}
+	repo := &mockRepository{
+		createFunc: func(sizes []int) (int, error) {
+			return 7, nil
+		},
+	}
+	service := NewPackService(repo, &mockCache{}, NewCalculationService(), DefaultPackServiceOptions()).WithChangeNotifier(notifier)
+
+	var received []int
+	service.OnPackSizesChanged(func(event domain.PackSizesChanged) {
+		received = append(received, event.Version)
+	})
+
+	if err := service.UpdatePackSizes(context.Background(), []int{250, 500}); err != nil {
+		t.Fatalf("UpdatePackSizes() error = %v", err)
+	}
+	if len(notifier.published) != 1 || notifier.published[0].Version != 7 {
+		t.Fatalf("published = %+v, want one event with version 7", notifier.published)
+	}
+	if !reflect.DeepEqual(notifier.published[0].Sizes, []int{250, 500}) {
+		t.Errorf("published sizes = %v, want [250 500]", notifier.published[0].Sizes)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Watch(ctx)
+		close(done)
+	}()
+
+	// Echo of the local update and an older version are ignored; a newer
+	// version from another instance is delivered.
+	notifier.events <- domain.PackSizesChanged{Version: 7}
+	notifier.events <- domain.PackSizesChanged{Version: 5}
+	notifier.events <- domain.PackSizesChanged{Version: 8}
+	cancel()
+	<-done
+
+	if !reflect.DeepEqual(received, []int{7, 8}) {
+		t.Errorf("listener versions = %v, want [7 8]", received)
+	}
+}
+
+func TestPackService_UpdatePackSizes_RecordsPrincipal(t *testing.T) {
+	notifier := &mockNotifier{events: make(chan domain.PackSizesChanged)}
+	repo := &mockRepository{
+		createFunc: func(sizes []int) (int, error) {
+			return 3, nil
+		},
+	}
+	service := NewPackService(repo, &mockCache{}, NewCalculationService(), DefaultPackServiceOptions()).WithChangeNotifier(notifier)
+	changedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return changedAt }
+
+	ctx := domain.WithPrincipal(context.Background(), domain.Principal{Subject: "jwt:alice", Role: domain.RoleAdmin, Tenant: "acme"})
+	if err := service.UpdatePackSizes(ctx, []int{250}); err != nil {
+		t.Fatalf("UpdatePackSizes() error = %v", err)
+	}
+	if event := notifier.published[0]; event.ChangedBy != "jwt:alice" || event.Tenant != "acme" {
+		t.Errorf("published = %+v, want changed by jwt:alice of acme", event)
+	}
+	if event := notifier.published[0]; !event.ChangedAt.Equal(changedAt) {
+		t.Errorf("ChangedAt = %v, want the service clock %v", event.ChangedAt, changedAt)
+	}
+}
+
+func TestPackService_GetPackSizes_CoalescesConcurrentMisses(t *testing.T) {
+	const callers = 50
+
+	var cacheGets, repoCalls atomic.Int32
+	release := make(chan struct{})
+	repo := &mockRepository{
+		getAllActiveFunc: func() ([]int, error) {
+			repoCalls.Add(1)
+			<-release
+			return []int{250, 500, 1000}, nil
+		},
+	}
+	cache := &mockCache{
+		getFunc: func(key string) ([]int, error) {
+			cacheGets.Add(1)
+			return nil, pkgerrors.ErrNotFound
+		},
+	}
+	service := NewPackService(repo, cache, NewCalculationService(), DefaultPackServiceOptions())
+
+	var wg sync.WaitGroup
+	results := make([][]int, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = service.GetPackSizes(context.Background())
+		}(i)
+	}
+
+	for cacheGets.Load() < callers {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := repoCalls.Load(); got != 1 {
+		t.Errorf("repository calls = %d, want 1", got)
+	}
+	for i := 0; i < callers; i++ {
+		if errs[i] != nil {
+			t.Fatalf("GetPackSizes() error = %v", errs[i])
+		}
+		if !reflect.DeepEqual(results[i], []int{250, 500, 1000}) {
+			t.Fatalf("GetPackSizes() = %v, want [250 500 1000]", results[i])
+		}
+	}
+
+	// Callers get independent slices.
+	results[0][0] = 1
+	if results[1][0] != 250 {
+		t.Error("GetPackSizes() results share a backing array")
+	}
+}
+
+func TestPackService_GetPackSizes_EarlyRefresh(t *testing.T) {
+	now := time.Now()
+	refreshed := make(chan struct{}, 1)
+	repo := &mockRepository{
+		getAllActiveFunc: func() ([]int, error) {
+			refreshed <- struct{}{}
+			return []int{250, 500}, nil
+		},
+	}
+	cache := &mockCache{
+		getFunc: func(key string) ([]int, error) {
+			return []int{250, 500}, nil
+		},
+	}
+	options := DefaultPackServiceOptions()
+	options.EarlyRefreshBeta = 1
+	service := NewPackService(repo, cache, NewCalculationService(), options)
+	service.now = func() time.Time { return now }
+	service.random = func() float64 { return 0.5 }
+
+	// Far from expiry: no refresh.
+	service.expiresAt = now.Add(time.Hour)
+	service.loadTime = 100 * time.Millisecond
+	if _, err := service.GetPackSizes(context.Background()); err != nil {
+		t.Fatalf("GetPackSizes() error = %v", err)
+	}
+	select {
+	case <-refreshed:
+		t.Fatal("repository called far from expiry")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Within loadTime*beta*-ln(0.5) of expiry: refresh in the background.
+	service.expiresAt = now.Add(50 * time.Millisecond)
+	if _, err := service.GetPackSizes(context.Background()); err != nil {
+		t.Fatalf("GetPackSizes() error = %v", err)
+	}
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("repository not called close to expiry")
+	}
+}
+
+func TestPackService_GetPackSizes_EarlyRefreshDisabled(t *testing.T) {
+	service := NewPackService(&mockRepository{}, &mockCache{}, NewCalculationService(), DefaultPackServiceOptions())
+	service.expiresAt = time.Now()
+	if service.shouldRefreshEarly() {
+		t.Error("shouldRefreshEarly() = true with beta 0, want false")
+	}
+}
+
+// versionedMemoryCache is a minimal thread-safe ports.TypedCache for race tests.
+type versionedMemoryCache struct {
+	mu       sync.Mutex
+	values   map[string][]int
+	versions map[string]int
+}
+
+func newVersionedMemoryCache() *versionedMemoryCache {
+	return &versionedMemoryCache{values: make(map[string][]int), versions: make(map[string]int)}
+}
+
+func (c *versionedMemoryCache) Get(ctx context.Context, key string) ([]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		return nil, pkgerrors.ErrNotFound
+	}
+	return value, nil
+}
+
+func (c *versionedMemoryCache) GetMany(ctx context.Context, keys []string) (map[string][]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := make(map[string][]int)
+	for _, key := range keys {
+		if value, ok := c.values[key]; ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func (c *versionedMemoryCache) Set(ctx context.Context, key string, value []int, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	return nil
+}
+
+func (c *versionedMemoryCache) SetMany(ctx context.Context, items map[string][]int, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, value := range items {
+		c.values[key] = value
+	}
+	return nil
+}
+
+func (c *versionedMemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.values, key)
+	}
+	return nil
+}
+
+func (c *versionedMemoryCache) GetVersion(ctx context.Context, key string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	version, ok := c.versions[key]
+	if !ok {
+		return 0, pkgerrors.ErrNotFound
+	}
+	return version, nil
+}
+
+func (c *versionedMemoryCache) AdvanceVersion(ctx context.Context, key string, version int, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if current, ok := c.versions[key]; !ok || current <= version {
+		c.versions[key] = version
+	}
+	return nil
+}
+
+// slowReaderRepository hands out a snapshot of the active set and then blocks
+// the first reader until resume is closed, reproducing a reader that loaded
+// the old sizes just before an update committed.
+type slowReaderRepository struct {
+	mu      sync.Mutex
+	active  domain.PackSizeSet
+	reading chan struct{}
+	resume  chan struct{}
+	blocked bool
+}
+
+func (r *slowReaderRepository) GetAllActive(ctx context.Context) (domain.PackSizeSet, error) {
+	r.mu.Lock()
+	snapshot := r.active
+	block := !r.blocked
+	r.blocked = true
+	r.mu.Unlock()
+
+	if block {
+		close(r.reading)
+		<-r.resume
+	}
+	return snapshot, nil
+}
+
+func (r *slowReaderRepository) Create(ctx context.Context, sizes []int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active = domain.PackSizeSet{Version: r.active.Version + 1, Sizes: sizes}
+	return r.active.Version, nil
+}
+
+func TestPackService_StaleReaderRace(t *testing.T) {
+	oldSizes := []int{250, 500}
+	newSizes := []int{100, 200}
+
+	t.Run("a fresh read during a stale load keeps the newer sizes", func(t *testing.T) {
+		repo := &slowReaderRepository{
+			active:  domain.PackSizeSet{Version: 1, Sizes: oldSizes},
+			reading: make(chan struct{}),
+			resume:  make(chan struct{}),
+		}
+		cache := newVersionedMemoryCache()
+		service := NewPackService(repo, cache, NewCalculationService(), DefaultPackServiceOptions())
+
+		readerDone := make(chan []int)
+		go func() {
+			sizes, _ := service.GetPackSizes(context.Background())
+			readerDone <- sizes
+		}()
+
+		// The first reader holds the old snapshot while the update commits
+		// and a second reader loads and caches the new sizes.
+		<-repo.reading
+		if err := service.UpdatePackSizes(context.Background(), newSizes); err != nil {
+			t.Fatalf("UpdatePackSizes() error = %v", err)
+		}
+		if got, err := service.GetPackSizes(context.Background()); err != nil || !reflect.DeepEqual(got, newSizes) {
+			t.Fatalf("GetPackSizes() during the stale load = %v, %v; want %v", got, err, newSizes)
+		}
+
+		// The stale reader then finishes and stores its snapshot.
+		close(repo.resume)
+		if stale := <-readerDone; !reflect.DeepEqual(stale, oldSizes) {
+			t.Fatalf("reader got %v, want the snapshot %v", stale, oldSizes)
+		}
+
+		got, err := service.GetPackSizes(context.Background())
+		if err != nil {
+			t.Fatalf("GetPackSizes() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, newSizes) {
+			t.Errorf("GetPackSizes() after the stale load = %v, want %v", got, newSizes)
+		}
+	})
+
+	t.Run("versioned keys keep the newer sizes", func(t *testing.T) {
+		repo := &slowReaderRepository{
+			active:  domain.PackSizeSet{Version: 1, Sizes: oldSizes},
+			reading: make(chan struct{}),
+			resume:  make(chan struct{}),
+		}
+		cache := newVersionedMemoryCache()
+		service := NewPackService(repo, cache, NewCalculationService(), DefaultPackServiceOptions())
+
+		readerDone := make(chan []int)
+		go func() {
+			sizes, _ := service.GetPackSizes(context.Background())
+			readerDone <- sizes
+		}()
+
+		<-repo.reading
+		if err := service.UpdatePackSizes(context.Background(), newSizes); err != nil {
+			t.Fatalf("UpdatePackSizes() error = %v", err)
+		}
+		close(repo.resume)
+
+		if stale := <-readerDone; !reflect.DeepEqual(stale, oldSizes) {
+			t.Fatalf("reader got %v, want the snapshot %v", stale, oldSizes)
+		}
+
+		got, err := service.GetPackSizes(context.Background())
+		if err != nil {
+			t.Fatalf("GetPackSizes() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, newSizes) {
+			t.Errorf("GetPackSizes() after race = %v, want %v", got, newSizes)
+		}
+		if version, _ := cache.GetVersion(context.Background(), service.pointerKey()); version != 2 {
+			t.Errorf("cached version = %d, want 2", version)
+		}
+	})
+}
+
+func TestPackService_CacheOptions(t *testing.T) {
+	t.Run("key prefix and ttl", func(t *testing.T) {
+		var keys []string
+		var ttls []time.Duration
+		cache := &mockCache{
+			getVersionFunc: func(key string) (int, error) {
+				keys = append(keys, key)
+				return 0, pkgerrors.ErrNotFound
+			},
+			setFunc: func(key string, value []int, ttl time.Duration) error {
+				keys = append(keys, key)
+				ttls = append(ttls, ttl)
+				return nil
+			},
+			advanceVersionFunc: func(key string, version int, ttl time.Duration) error {
+				keys = append(keys, key)
+				ttls = append(ttls, ttl)
+				return nil
+			},
+		}
+		repo := &mockRepository{
+			getAllActiveFunc: func() ([]int, error) {
+				return []int{250}, nil
+			},
+		}
+		service := NewPackService(repo, cache, NewCalculationService(), PackServiceOptions{
+			CacheEnabled: true,
+			CacheTTL:     5 * time.Minute,
+			KeyPrefix:    "tenant-a",
+		})
+
+		if _, err := service.GetPackSizes(context.Background()); err != nil {
+			t.Fatalf("GetPackSizes() error = %v", err)
+		}
+
+		wantKeys := []string{"tenant-a:pack-sizes:current", "tenant-a:pack-sizes:v1", "tenant-a:pack-sizes:current"}
+		if !reflect.DeepEqual(keys, wantKeys) {
+			t.Errorf("cache keys = %v, want %v", keys, wantKeys)
+		}
+		if !reflect.DeepEqual(ttls, []time.Duration{300 * time.Second, 300 * time.Second}) {
+			t.Errorf("cache ttls = %v, want [300 300]", ttls)
+		}
+	})
+
+	t.Run("cache disabled", func(t *testing.T) {
+		cache := &mockCache{
+			getVersionFunc: func(key string) (int, error) {
+				t.Error("cache read while disabled")
+				return 0, nil
+			},
+			setFunc: func(key string, value []int, ttl time.Duration) error {
+				t.Error("cache write while disabled")
+				return nil
+			},
+		}
+		repoCalls := 0
+		repo := &mockRepository{
+			getAllActiveFunc: func() ([]int, error) {
+				repoCalls++
+				return []int{250}, nil
+			},
+		}
+		service := NewPackService(repo, cache, NewCalculationService(), PackServiceOptions{CacheEnabled: false})
+
+		for i := 0; i < 2; i++ {
+			if _, err := service.GetPackSizes(context.Background()); err != nil {
+				t.Fatalf("GetPackSizes() error = %v", err)
+			}
+		}
+		if err := service.UpdatePackSizes(context.Background(), []int{100}); err != nil {
+			t.Fatalf("UpdatePackSizes() error = %v", err)
+		}
+		if repoCalls != 2 {
+			t.Errorf("repository calls = %d, want 2", repoCalls)
+		}
+	})
+}
+
+// blockingRepository blocks the first read until its context is cancelled and
+// serves sizes to every later read.
+type blockingRepository struct {
+	mu      sync.Mutex
+	calls   int
+	reading chan struct{}
+	sizes   []int
+}
+
+func (r *blockingRepository) GetAllActive(ctx context.Context) (domain.PackSizeSet, error) {
+	r.mu.Lock()
+	r.calls++
+	first := r.calls == 1
+	r.mu.Unlock()
+
+	if !first {
+		return domain.PackSizeSet{Version: 1, Sizes: r.sizes}, nil
+	}
+	close(r.reading)
+	<-ctx.Done()
+	return domain.PackSizeSet{}, ctx.Err()
+}
+
+func (r *blockingRepository) Create(ctx context.Context, sizes []int) (int, error) {
+	return 0, ctx.Err()
+}
+
+func TestPackService_ContextCancellation(t *testing.T) {
+	t.Run("cancelled request aborts the repository query", func(t *testing.T) {
+		repo := &blockingRepository{reading: make(chan struct{}), sizes: []int{250}}
+		service := NewPackService(repo, &mockCache{}, NewCalculationService(), DefaultPackServiceOptions())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			_, err := service.GetPackSizes(ctx)
+			done <- err
+		}()
+
+		<-repo.reading
+		cancel()
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("GetPackSizes() error = %v, want context.Canceled", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("GetPackSizes() did not return after cancellation")
+		}
+	})
+
+	t.Run("waiter with a live context survives the first caller's cancellation", func(t *testing.T) {
+		repo := &blockingRepository{reading: make(chan struct{}), sizes: []int{250}}
+		service := NewPackService(repo, &mockCache{}, NewCalculationService(), DefaultPackServiceOptions())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go service.GetPackSizes(ctx)
+		<-repo.reading
+
+		done := make(chan []int, 1)
+		go func() {
+			sizes, _ := service.GetPackSizes(context.Background())
+			done <- sizes
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+
+		select {
+		case sizes := <-done:
+			if !reflect.DeepEqual(sizes, []int{250}) {
+				t.Errorf("GetPackSizes() = %v, want [250]", sizes)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("GetPackSizes() did not return")
+		}
+	})
+
+	t.Run("cancelled waiter stops waiting for the shared load", func(t *testing.T) {
+		reading, release := make(chan struct{}), make(chan struct{})
+		defer close(release)
+		repo := &mockRepository{
+			getAllActiveFunc: func() ([]int, error) {
+				close(reading)
+				<-release
+				return []int{250}, nil
+			},
+		}
+		service := NewPackService(repo, &mockCache{}, NewCalculationService(), DefaultPackServiceOptions())
+
+		go service.GetPackSizes(context.Background())
+		<-reading
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			_, err := service.GetPackSizes(ctx)
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("GetPackSizes() error = %v, want context.Canceled", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("GetPackSizes() waited for the shared load after cancellation")
+		}
+	})
+
+	t.Run("waiters share one retry after the first caller's cancellation", func(t *testing.T) {
+		const waiters = 10
+
+		var calls atomic.Int32
+		reading, abandon, release := make(chan struct{}), make(chan struct{}), make(chan struct{})
+		repo := &mockRepository{
+			getAllActiveFunc: func() ([]int, error) {
+				if calls.Add(1) == 1 {
+					close(reading)
+					<-abandon
+					return nil, context.Canceled
+				}
+				<-release
+				return []int{250}, nil
+			},
+		}
+		service := NewPackService(repo, &mockCache{}, NewCalculationService(), DefaultPackServiceOptions())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go service.GetPackSizes(ctx)
+		<-reading
+
+		var wg sync.WaitGroup
+		results := make([][]int, waiters)
+		for i := 0; i < waiters; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], _ = service.GetPackSizes(context.Background())
+			}(i)
+		}
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		close(abandon)
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if got := calls.Load(); got != 2 {
+			t.Errorf("repository calls = %d, want 2", got)
+		}
+		for i := range results {
+			if !reflect.DeepEqual(results[i], []int{250}) {
+				t.Fatalf("GetPackSizes() = %v, want [250]", results[i])
+			}
+		}
+	})
+
+	t.Run("cancelled update is not written", func(t *testing.T) {
+		repo := &blockingRepository{reading: make(chan struct{})}
+		service := NewPackService(repo, &mockCache{}, NewCalculationService(), DefaultPackServiceOptions())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := service.UpdatePackSizes(ctx, []int{250}); !errors.Is(err, context.Canceled) {
+			t.Errorf("UpdatePackSizes() error = %v, want context.Canceled", err)
+		}
+	})
+}
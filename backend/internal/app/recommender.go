@@ -0,0 +1,293 @@
+package app
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// Demand is how often orders of Items were placed.
+type Demand struct {
+	Items     int
+	Frequency int
+}
+
+// RecommendConstraints bound the recommended set.
+type RecommendConstraints struct {
+	// Sizes is how many pack sizes to recommend.
+	Sizes   int
+	MinSize int
+	MaxSize int
+}
+
+// Recommendation is the best set found, with its expected impact on the
+// demand.
+type Recommendation struct {
+	Sizes  []int
+	Impact PackSizeImpact
+	// Evaluations counts the candidate sets that were scored.
+	Evaluations int
+	// Converged is false when the search stopped at its evaluation or time
+	// budget rather than at a local optimum.
+	Converged bool
+}
+
+// RecommenderOptions bound the search.
+type RecommenderOptions struct {
+	// PoolSize caps how many distinct sizes the search picks from.
+	PoolSize       int
+	MaxEvaluations int
+	Timeout        time.Duration
+}
+
+func DefaultRecommenderOptions() RecommenderOptions {
+	return RecommenderOptions{
+		PoolSize:       32,
+		MaxEvaluations: 500,
+		Timeout:        10 * time.Second,
+	}
+}
+
+type RecommenderInterface interface {
+	Recommend(ctx context.Context, demand []Demand, constraints RecommendConstraints) (Recommendation, error)
+}
+
+// Recommender searches for the pack-size set that minimises expected
+// overshoot, then pack count, over a demand distribution, scoring every
+// candidate with CalculationService.
+type Recommender struct {
+	calculationSvc *CalculationService
+	options        RecommenderOptions
+}
+
+func NewRecommender(calculationSvc *CalculationService, options RecommenderOptions) *Recommender {
+	defaults := DefaultRecommenderOptions()
+	if options.PoolSize <= 0 {
+		options.PoolSize = defaults.PoolSize
+	}
+	if options.MaxEvaluations <= 0 {
+		options.MaxEvaluations = defaults.MaxEvaluations
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = defaults.Timeout
+	}
+	return &Recommender{calculationSvc: calculationSvc, options: options}
+}
+
+// Recommend picks sizes greedily, adding the size that helps most until the
+// set is full, then swaps single sizes while that improves the score. The
+// best set found so far is returned when the budget runs out.
+func (r *Recommender) Recommend(ctx context.Context, demand []Demand, constraints RecommendConstraints) (Recommendation, error) {
+	if err := validateDemand(demand); err != nil {
+		return Recommendation{}, err
+	}
+	c := constraints
+	if c.Sizes < 1 || c.Sizes > pkgerrors.MaxRecommendSizes || c.MinSize < pkgerrors.MinPackSize || c.MaxSize < c.MinSize || c.MaxSize > pkgerrors.MaxRecommendSize {
+		return Recommendation{}, pkgerrors.ErrConstraintsInvalid
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.options.Timeout)
+	defer cancel()
+
+	s := &search{
+		calc:   r.calculationSvc,
+		demand: demand,
+		budget: r.options.MaxEvaluations,
+		scores: make(map[string]PackSizeImpact),
+	}
+	pool := candidatePool(demand, c, r.options.PoolSize)
+
+	var chosen []int
+	best, stopped := PackSizeImpact{}, false
+	for len(chosen) < c.Sizes && len(chosen) < len(pool) && !stopped {
+		var pick int
+		var pickScore PackSizeImpact
+		for _, size := range pool {
+			if contains(chosen, size) {
+				continue
+			}
+			score, ok := s.score(ctx, append(append([]int(nil), chosen...), size))
+			if !ok {
+				stopped = true
+				break
+			}
+			if pick == 0 || better(score, pickScore) {
+				pick, pickScore = size, score
+			}
+		}
+		if pick == 0 {
+			break
+		}
+		chosen = append(chosen, pick)
+		best = pickScore
+	}
+
+	for improved := !stopped; improved && !stopped; {
+		improved = false
+		for i := range chosen {
+			for _, size := range pool {
+				if contains(chosen, size) {
+					continue
+				}
+				candidate := append([]int(nil), chosen...)
+				candidate[i] = size
+				score, ok := s.score(ctx, candidate)
+				if !ok {
+					stopped = true
+					break
+				}
+				if better(score, best) {
+					chosen, best, improved = candidate, score, true
+				}
+			}
+			if stopped {
+				break
+			}
+		}
+	}
+
+	if len(chosen) == 0 {
+		// The budget ran out before a single set was scored.
+		if err := ctx.Err(); err != nil {
+			return Recommendation{}, err
+		}
+	}
+	if err := context.Cause(ctx); err != nil && err != context.DeadlineExceeded {
+		// The caller went away; nobody reads the result.
+		return Recommendation{}, err
+	}
+
+	sort.Ints(chosen)
+	best.Sizes = chosen
+	return Recommendation{Sizes: chosen, Impact: best, Evaluations: s.evaluations, Converged: !stopped}, nil
+}
+
+func validateDemand(demand []Demand) error {
+	if len(demand) == 0 || len(demand) > pkgerrors.MaxDemandEntries {
+		return pkgerrors.ErrDemandInvalid
+	}
+	for _, d := range demand {
+		if d.Frequency < 1 || d.Frequency > pkgerrors.MaxDemandFrequency {
+			return pkgerrors.ErrDemandInvalid
+		}
+		if d.Items < pkgerrors.MinItems || d.Items > pkgerrors.MaxDemandItems {
+			return pkgerrors.ErrItemsOutOfRange
+		}
+	}
+	return nil
+}
+
+// search scores candidate sets, remembering sets it has already seen.
+type search struct {
+	calc        *CalculationService
+	demand      []Demand
+	budget      int
+	evaluations int
+	scores      map[string]PackSizeImpact
+}
+
+// score returns false once the evaluation or time budget is spent.
+func (s *search) score(ctx context.Context, sizes []int) (PackSizeImpact, bool) {
+	sorted := append([]int(nil), sizes...)
+	sort.Ints(sorted)
+	key := setKey(sorted)
+	if score, ok := s.scores[key]; ok {
+		return score, true
+	}
+	if s.evaluations >= s.budget {
+		return PackSizeImpact{}, false
+	}
+
+	var impact PackSizeImpact
+	orders := 0
+	for _, d := range s.demand {
+		packs, err := s.calc.CalculatePacksContext(ctx, sorted, d.Items)
+		if err != nil {
+			return PackSizeImpact{}, false
+		}
+		impact.add(d.Items, d.Frequency, packs)
+		orders += d.Frequency
+	}
+	impact.average(orders)
+
+	s.evaluations++
+	s.scores[key] = impact
+	return impact, true
+}
+
+// better orders sets like the calculator orders packings: fewer items over
+// the order first, then fewer packs.
+func better(a, b PackSizeImpact) bool {
+	if a.TotalOvershoot != b.TotalOvershoot {
+		return a.TotalOvershoot < b.TotalOvershoot
+	}
+	return a.TotalPacks < b.TotalPacks
+}
+
+// candidatePool returns the sizes the search picks from: the most frequent
+// order quantities within bounds, which ship without overshoot, plus a
+// geometric grid of round sizes between the bounds.
+func candidatePool(demand []Demand, c RecommendConstraints, limit int) []int {
+	byFrequency := append([]Demand(nil), demand...)
+	sort.SliceStable(byFrequency, func(i, j int) bool { return byFrequency[i].Frequency > byFrequency[j].Frequency })
+
+	seen := make(map[int]bool)
+	var pool []int
+	addSize := func(size int) {
+		if size >= c.MinSize && size <= c.MaxSize && !seen[size] && len(pool) < limit {
+			seen[size] = true
+			pool = append(pool, size)
+		}
+	}
+
+	addSize(c.MinSize)
+	for _, d := range byFrequency {
+		if len(pool) >= limit*3/4 {
+			break
+		}
+		addSize(d.Items)
+	}
+
+	const gridPoints = 12
+	ratio := math.Pow(float64(c.MaxSize)/float64(c.MinSize), 1.0/(gridPoints-1))
+	for i := 0; i < gridPoints; i++ {
+		addSize(roundSize(float64(c.MinSize) * math.Pow(ratio, float64(i))))
+	}
+	addSize(c.MaxSize)
+
+	sort.Ints(pool)
+	return pool
+}
+
+// roundSize rounds to two significant digits, e.g. 2371 to 2400.
+func roundSize(v float64) int {
+	if v < 100 {
+		return int(math.Round(v))
+	}
+	scale := math.Pow(10, math.Floor(math.Log10(v))-1)
+	return int(math.Round(v/scale) * scale)
+}
+
+func setKey(sizes []int) string {
+	parts := make([]string, len(sizes))
+	for i, size := range sizes {
+		parts[i] = strconv.Itoa(size)
+	}
+	return strings.Join(parts, ",")
+}
+
+func contains(sizes []int, size int) bool {
+	for _, s := range sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+var _ RecommenderInterface = (*Recommender)(nil)
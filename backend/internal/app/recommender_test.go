@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+func TestRecommender_Recommend(t *testing.T) {
+	recommender := NewRecommender(NewCalculationService(), DefaultRecommenderOptions())
+	demand := []Demand{{Items: 250, Frequency: 10}, {Items: 500, Frequency: 5}, {Items: 1000, Frequency: 2}}
+
+	got, err := recommender.Recommend(context.Background(), demand, RecommendConstraints{Sizes: 3, MinSize: 100, MaxSize: 1000})
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got.Sizes, []int{250, 500, 1000}) {
+		t.Errorf("Sizes = %v, want [250 500 1000]", got.Sizes)
+	}
+	if got.Impact.TotalOvershoot != 0 || got.Impact.TotalPacks != 17 {
+		t.Errorf("Impact = %+v, want no overshoot in 17 packs", got.Impact)
+	}
+	if !got.Converged {
+		t.Error("Converged = false, want true")
+	}
+}
+
+func TestRecommender_RespectsBounds(t *testing.T) {
+	recommender := NewRecommender(NewCalculationService(), DefaultRecommenderOptions())
+	demand := []Demand{{Items: 3, Frequency: 4}, {Items: 70, Frequency: 1}, {Items: 1200, Frequency: 1}}
+
+	got, err := recommender.Recommend(context.Background(), demand, RecommendConstraints{Sizes: 2, MinSize: 10, MaxSize: 500})
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+
+	if len(got.Sizes) != 2 {
+		t.Fatalf("Sizes = %v, want 2 sizes", got.Sizes)
+	}
+	for _, size := range got.Sizes {
+		if size < 10 || size > 500 {
+			t.Errorf("size %d outside [10, 500]", size)
+		}
+	}
+}
+
+func TestRecommender_StopsAtBudget(t *testing.T) {
+	recommender := NewRecommender(NewCalculationService(), RecommenderOptions{MaxEvaluations: 3})
+	demand := []Demand{{Items: 251, Frequency: 1}, {Items: 12001, Frequency: 1}}
+
+	got, err := recommender.Recommend(context.Background(), demand, RecommendConstraints{Sizes: 3, MinSize: 100, MaxSize: 5000})
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+
+	if got.Converged || got.Evaluations != 3 || len(got.Sizes) == 0 {
+		t.Errorf("Recommend() = %+v, want a partial result after 3 evaluations", got)
+	}
+}
+
+func TestRecommender_TimeoutInterruptsCalculation(t *testing.T) {
+	recommender := NewRecommender(NewCalculationService(), RecommenderOptions{Timeout: 20 * time.Millisecond})
+	demand := []Demand{{Items: pkgerrors.MaxDemandItems, Frequency: 1}}
+
+	// Scoring a single set at these bounds takes seconds.
+	start := time.Now()
+	_, err := recommender.Recommend(context.Background(), demand, RecommendConstraints{Sizes: 1, MinSize: 1, MaxSize: pkgerrors.MaxRecommendSize})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Recommend() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Recommend() took %v, want it stopped near the 20ms timeout", elapsed)
+	}
+}
+
+func TestRecommender_Validation(t *testing.T) {
+	recommender := NewRecommender(NewCalculationService(), DefaultRecommenderOptions())
+	valid := RecommendConstraints{Sizes: 3, MinSize: 1, MaxSize: 1000}
+
+	tests := []struct {
+		name        string
+		demand      []Demand
+		constraints RecommendConstraints
+		wantErr     error
+	}{
+		{"no demand", nil, valid, pkgerrors.ErrDemandInvalid},
+		{"zero frequency", []Demand{{Items: 10}}, valid, pkgerrors.ErrDemandInvalid},
+		{"invalid items", []Demand{{Items: 0, Frequency: 1}}, valid, pkgerrors.ErrItemsOutOfRange},
+		{"items too large", []Demand{{Items: pkgerrors.MaxDemandItems + 1, Frequency: 1}}, valid, pkgerrors.ErrItemsOutOfRange},
+		{"frequency too large", []Demand{{Items: 1, Frequency: pkgerrors.MaxDemandFrequency + 1}}, valid, pkgerrors.ErrDemandInvalid},
+		{"no sizes", []Demand{{Items: 10, Frequency: 1}}, RecommendConstraints{MinSize: 1, MaxSize: 10}, pkgerrors.ErrConstraintsInvalid},
+		{"min above max", []Demand{{Items: 10, Frequency: 1}}, RecommendConstraints{Sizes: 1, MinSize: 10, MaxSize: 5}, pkgerrors.ErrConstraintsInvalid},
+		{"max too large", []Demand{{Items: 10, Frequency: 1}}, RecommendConstraints{Sizes: 1, MinSize: 1, MaxSize: pkgerrors.MaxRecommendSize + 1}, pkgerrors.ErrConstraintsInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := recommender.Recommend(context.Background(), tt.demand, tt.constraints); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Recommend() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoundSize(t *testing.T) {
+	tests := map[float64]int{7.4: 7, 99.6: 100, 2371: 2400, 14999: 15000}
+	for in, want := range tests {
+		if got := roundSize(in); got != want {
+			t.Errorf("roundSize(%v) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/jwt"
+	"pack-calculator/pkg/logger"
+)
+
+const tokenSubjectPrefix = "jwt:"
+
+// Authenticator resolves the principal a credential identifies, or returns
+// ErrUnauthorized.
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (domain.Principal, error)
+}
+
+// TokenOptions configures TokenService.
+type TokenOptions struct {
+	// Issuer must equal the iss claim.
+	Issuer string
+	// Audience must be one of the aud claim's values.
+	Audience string
+	// RoleClaim and TenantClaim name the claims read for the caller's role
+	// and tenant; dots reach into nested objects.
+	RoleClaim   string
+	TenantClaim string
+	// RoleMap maps role claim values to roles. Empty uses the values as
+	// roles. The most privileged mapped role wins; unmapped values are
+	// ignored.
+	RoleMap map[string]string
+	// Leeway absorbs clock skew with the issuer when checking exp and nbf.
+	Leeway time.Duration
+}
+
+func DefaultTokenOptions() TokenOptions {
+	return TokenOptions{
+		RoleClaim:   "roles",
+		TenantClaim: "tenant",
+		Leeway:      30 * time.Second,
+	}
+}
+
+// TokenService authenticates signed JWT bearer tokens, e.g. issued by an
+// OpenID Connect provider.
+type TokenService struct {
+	keys    ports.KeySet
+	options TokenOptions
+	now     func() time.Time
+}
+
+func NewTokenService(keys ports.KeySet, options TokenOptions) *TokenService {
+	return &TokenService{keys: keys, options: options, now: time.Now}
+}
+
+// Authenticate verifies token and maps its claims to a principal. Every
+// rejected token is ErrUnauthorized; the reason is only logged, at debug
+// level. A valid token without a known role yields a principal without one.
+func (s *TokenService) Authenticate(ctx context.Context, token string) (domain.Principal, error) {
+	claims, err := s.verify(ctx, token)
+	if err != nil {
+		if errors.Is(err, pkgerrors.ErrUnauthorized) {
+			logger.Default().Debug("Rejected bearer token", "error", err)
+		}
+		return domain.Principal{}, err
+	}
+
+	return domain.Principal{
+		Subject: tokenSubjectPrefix + claims.String("sub"),
+		Role:    s.role(claims),
+		Tenant:  claims.String(s.options.TenantClaim),
+	}, nil
+}
+
+func (s *TokenService) verify(ctx context.Context, token string) (jwt.Claims, error) {
+	parsed, err := jwt.Parse(token)
+	if err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrUnauthorized, "malformed token")
+	}
+
+	key, err := s.keys.Key(ctx, parsed.Header.Kid)
+	if errors.Is(err, pkgerrors.ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown signing key %q", pkgerrors.ErrUnauthorized, parsed.Header.Kid)
+	}
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "failed to get signing key")
+	}
+	if err := parsed.Verify(key); err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrUnauthorized, "token not verified")
+	}
+
+	claims := parsed.Claims
+	if err := s.validateClaims(claims); err != nil {
+		return nil, pkgerrors.WrapWithDomain(err, pkgerrors.ErrUnauthorized, "token rejected")
+	}
+	return claims, nil
+}
+
+func (s *TokenService) validateClaims(claims jwt.Claims) error {
+	now := s.now()
+
+	exp, ok, err := claims.Time("exp")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if !now.Before(exp.Add(s.options.Leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok, err := claims.Time("nbf"); err != nil {
+		return err
+	} else if ok && now.Add(s.options.Leeway).Before(nbf) {
+		return errors.New("token not yet valid")
+	}
+
+	if claims.String("iss") != s.options.Issuer {
+		return errors.New("unexpected issuer")
+	}
+	if !slices.Contains(claims.Strings("aud"), s.options.Audience) {
+		return errors.New("unexpected audience")
+	}
+	if claims.String("sub") == "" {
+		return errors.New("token has no subject")
+	}
+	return nil
+}
+
+func (s *TokenService) role(claims jwt.Claims) string {
+	values := claims.Strings(s.options.RoleClaim)
+	if len(s.options.RoleMap) == 0 {
+		return domain.HighestRole(values)
+	}
+
+	roles := make([]string, 0, len(values))
+	for _, value := range values {
+		if role, ok := s.options.RoleMap[value]; ok {
+			roles = append(roles, role)
+		}
+	}
+	return domain.HighestRole(roles)
+}
+
+var _ Authenticator = (*TokenService)(nil)
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/jwt"
+)
+
+type staticKeySet struct {
+	keys []jwt.Key
+	err  error
+}
+
+func (s staticKeySet) Key(ctx context.Context, kid string) (jwt.Key, error) {
+	if s.err != nil {
+		return jwt.Key{}, s.err
+	}
+	for _, key := range s.keys {
+		if key.ID == kid {
+			return key, nil
+		}
+	}
+	return jwt.Key{}, pkgerrors.ErrNotFound
+}
+
+func newTestIssuer(t *testing.T) (*ecdsa.PrivateKey, staticKeySet) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, staticKeySet{keys: []jwt.Key{{ID: "k1", Alg: "ES256", Public: &key.PublicKey}}}
+}
+
+func testTokenOptions() TokenOptions {
+	options := DefaultTokenOptions()
+	options.Issuer = "https://sso.example.com"
+	options.Audience = "pack-calculator"
+	return options
+}
+
+func TestTokenService_Authenticate(t *testing.T) {
+	signer, keys := newTestIssuer(t)
+	now := time.Now()
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss":    "https://sso.example.com",
+			"aud":    []string{"other", "pack-calculator"},
+			"sub":    "alice",
+			"exp":    now.Add(time.Minute).Unix(),
+			"roles":  []string{"reader", "admin", "auditor"},
+			"tenant": "acme",
+		}
+	}
+	with := func(name string, value any) map[string]any {
+		claims := valid()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		kid     string
+		claims  map[string]any
+		want    domain.Principal
+		wantErr bool
+	}{
+		{"valid", "k1", valid(), domain.Principal{Subject: "jwt:alice", Role: domain.RoleAdmin, Tenant: "acme"}, false},
+		{"within leeway", "k1", with("exp", now.Add(-10*time.Second).Unix()), domain.Principal{Subject: "jwt:alice", Role: domain.RoleAdmin, Tenant: "acme"}, false},
+		{"no known role", "k1", with("roles", "auditor"), domain.Principal{Subject: "jwt:alice", Tenant: "acme"}, false},
+		{"expired", "k1", with("exp", now.Add(-time.Minute).Unix()), domain.Principal{}, true},
+		{"no expiry", "k1", with("exp", nil), domain.Principal{}, true},
+		{"not yet valid", "k1", with("nbf", now.Add(time.Minute).Unix()), domain.Principal{}, true},
+		{"other issuer", "k1", with("iss", "https://evil.example.com"), domain.Principal{}, true},
+		{"other audience", "k1", with("aud", "other"), domain.Principal{}, true},
+		{"no subject", "k1", with("sub", nil), domain.Principal{}, true},
+		{"unknown key", "k2", valid(), domain.Principal{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewTokenService(keys, testTokenOptions())
+			service.now = func() time.Time { return now }
+
+			token, err := jwt.Sign(jwt.Header{Alg: "ES256", Kid: tt.kid}, tt.claims, signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			principal, err := service.Authenticate(context.Background(), token)
+			if tt.wantErr {
+				if !errors.Is(err, pkgerrors.ErrUnauthorized) {
+					t.Errorf("Authenticate() error = %v, want ErrUnauthorized", err)
+				}
+				return
+			}
+			if err != nil || principal != tt.want {
+				t.Errorf("Authenticate() = %+v, %v, want %+v", principal, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenService_RoleMapAndNestedClaims(t *testing.T) {
+	signer, keys := newTestIssuer(t)
+	options := testTokenOptions()
+	options.RoleClaim = "realm_access.roles"
+	options.TenantClaim = "org.id"
+	options.RoleMap = map[string]string{"pack-readers": domain.RoleReader, "pack-admins": domain.RoleAdmin}
+	service := NewTokenService(keys, options)
+
+	token, _ := jwt.Sign(jwt.Header{Alg: "ES256", Kid: "k1"}, map[string]any{
+		"iss":          options.Issuer,
+		"aud":          options.Audience,
+		"sub":          "bob",
+		"exp":          time.Now().Add(time.Minute).Unix(),
+		"realm_access": map[string]any{"roles": []string{"admin", "pack-readers"}},
+		"org":          map[string]any{"id": "globex"},
+	}, signer)
+
+	principal, err := service.Authenticate(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	// "admin" is not mapped, so only pack-readers counts.
+	if principal.Role != domain.RoleReader || principal.Tenant != "globex" {
+		t.Errorf("Authenticate() = %+v, want reader of globex", principal)
+	}
+}
+
+func TestTokenService_RejectsForgedAndMalformedTokens(t *testing.T) {
+	_, keys := newTestIssuer(t)
+	forger, _ := newTestIssuer(t)
+	service := NewTokenService(keys, testTokenOptions())
+
+	forged, _ := jwt.Sign(jwt.Header{Alg: "ES256", Kid: "k1"}, map[string]any{
+		"iss": "https://sso.example.com", "aud": "pack-calculator", "sub": "mallory",
+		"exp": time.Now().Add(time.Minute).Unix(), "roles": "admin",
+	}, forger)
+
+	for _, token := range []string{forged, "not-a-token", ""} {
+		if _, err := service.Authenticate(context.Background(), token); !errors.Is(err, pkgerrors.ErrUnauthorized) {
+			t.Errorf("Authenticate(%q) error = %v, want ErrUnauthorized", token, err)
+		}
+	}
+}
+
+func TestTokenService_KeySetFailureIsNotUnauthorized(t *testing.T) {
+	signer, _ := newTestIssuer(t)
+	service := NewTokenService(staticKeySet{err: errors.New("issuer unreachable")}, testTokenOptions())
+
+	token, _ := jwt.Sign(jwt.Header{Alg: "ES256", Kid: "k1"}, map[string]any{"sub": "alice"}, signer)
+	if _, err := service.Authenticate(context.Background(), token); err == nil || errors.Is(err, pkgerrors.ErrUnauthorized) {
+		t.Errorf("Authenticate() error = %v, want an internal error", err)
+	}
+}
@@ -0,0 +1,338 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/logger"
+)
+
+// WebhookSinkName is the outbox sink that fans events out to webhook
+// subscriptions.
+const WebhookSinkName = "webhooks"
+
+const webhookSecretPrefix = "whsec_"
+
+// WebhookOptions configures WebhookService delivery.
+type WebhookOptions struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// MaxAttempts is how often a delivery is tried before it is marked failed.
+	MaxAttempts int
+	// Lease hides a claimed delivery from other instances; it must exceed the
+	// sender timeout.
+	Lease time.Duration
+	// InitialBackoff doubles after each failed attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// DeliveryLogLimit caps how many deliveries ListDeliveries returns.
+	DeliveryLogLimit int
+}
+
+func DefaultWebhookOptions() WebhookOptions {
+	return WebhookOptions{
+		PollInterval:     time.Second,
+		BatchSize:        100,
+		MaxAttempts:      8,
+		Lease:            30 * time.Second,
+		InitialBackoff:   5 * time.Second,
+		MaxBackoff:       time.Hour,
+		DeliveryLogLimit: 100,
+	}
+}
+
+// WebhookEvent is the JSON body posted to subscribers.
+type WebhookEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type WebhookServiceInterface interface {
+	CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id int64) (domain.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, subscriptionID int64) ([]domain.WebhookDelivery, error)
+}
+
+// WebhookService manages subscriptions and delivers events to them with
+// retries. Pack-size changes arrive through the outbox (it is an EventSink),
+// so a committed update is never lost; other events are published directly.
+type WebhookService struct {
+	store   ports.WebhookStore
+	sender  ports.WebhookSender
+	options WebhookOptions
+	wake    chan struct{}
+	logger  *slog.Logger
+	now     func() time.Time
+}
+
+func NewWebhookService(store ports.WebhookStore, sender ports.WebhookSender, options WebhookOptions) *WebhookService {
+	defaults := DefaultWebhookOptions()
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaults.PollInterval
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaults.BatchSize
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = defaults.MaxAttempts
+	}
+	if options.Lease <= 0 {
+		options.Lease = defaults.Lease
+	}
+	if options.InitialBackoff <= 0 {
+		options.InitialBackoff = defaults.InitialBackoff
+	}
+	if options.MaxBackoff < options.InitialBackoff {
+		options.MaxBackoff = options.InitialBackoff
+	}
+	if options.DeliveryLogLimit <= 0 {
+		options.DeliveryLogLimit = defaults.DeliveryLogLimit
+	}
+
+	return &WebhookService{
+		store:   store,
+		sender:  sender,
+		options: options,
+		wake:    make(chan struct{}, 1),
+		logger:  logger.Default(),
+		now:     time.Now,
+	}
+}
+
+// CreateSubscription validates sub and stores it with a fresh secret. The
+// returned subscription is the only place the secret is exposed.
+func (s *WebhookService) CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	if err := normalizeSubscription(&sub); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	sub.Secret = secret
+
+	return s.store.CreateSubscription(ctx, sub)
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	return s.store.ListSubscriptions(ctx)
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id int64) (domain.WebhookSubscription, error) {
+	return s.store.GetSubscription(ctx, id)
+}
+
+// UpdateSubscription replaces the URL, events, description and active flag;
+// the secret is kept.
+func (s *WebhookService) UpdateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	if err := normalizeSubscription(&sub); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	return s.store.UpdateSubscription(ctx, sub)
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id int64) error {
+	return s.store.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries returns the delivery log of a subscription, newest first.
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID int64) ([]domain.WebhookDelivery, error) {
+	if _, err := s.store.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.store.ListDeliveries(ctx, subscriptionID, s.options.DeliveryLogLimit)
+}
+
+func normalizeSubscription(sub *domain.WebhookSubscription) error {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return pkgerrors.ErrWebhookURLInvalid
+	}
+
+	if len(sub.Events) == 0 {
+		return pkgerrors.ErrWebhookEventsInvalid
+	}
+	known := make(map[string]bool, len(domain.WebhookEventTypes))
+	for _, event := range domain.WebhookEventTypes {
+		known[event] = true
+	}
+	seen := make(map[string]bool, len(sub.Events))
+	events := make([]string, 0, len(sub.Events))
+	for _, event := range sub.Events {
+		if !known[event] {
+			return pkgerrors.ErrWebhookEventsInvalid
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	sub.Events = events
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return webhookSecretPrefix + hex.EncodeToString(b), nil
+}
+
+// Publish queues data as an event of eventType for every active subscriber.
+// key identifies the event; publishing the same key twice queues it once.
+func (s *WebhookService) Publish(ctx context.Context, eventType, key string, createdAt time.Time, data json.RawMessage) error {
+	body, err := json.Marshal(WebhookEvent{ID: key, Type: eventType, CreatedAt: createdAt.UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	n, err := s.store.EnqueueDeliveries(ctx, eventType, key, body)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		s.Wake()
+	}
+	return nil
+}
+
+// PublishPacksCalculated queues a packs.calculated event.
+func (s *WebhookService) PublishPacksCalculated(ctx context.Context, event domain.PacksCalculated) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+	key, err := newEventKey("calc")
+	if err != nil {
+		return err
+	}
+	return s.Publish(ctx, domain.EventPacksCalculated, key, event.CalculatedAt, data)
+}
+
+func newEventKey(prefix string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate event key: %w", err)
+	}
+	return prefix + ":" + hex.EncodeToString(b), nil
+}
+
+func (s *WebhookService) Name() string {
+	return WebhookSinkName
+}
+
+// Deliver implements ports.EventSink: an outbox event is accepted once it is
+// queued for every subscriber. The outbox id keys the event, so an outbox
+// retry does not queue it twice.
+func (s *WebhookService) Deliver(ctx context.Context, event domain.OutboxEvent) error {
+	key := "outbox:" + strconv.FormatInt(event.ID, 10)
+	return s.Publish(ctx, event.Type, key, event.CreatedAt, event.Payload)
+}
+
+// Wake makes Run send queued deliveries now instead of at the next poll.
+func (s *WebhookService) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run sends queued deliveries until ctx is cancelled.
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := s.DispatchOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				s.logger.Warn("Webhook dispatch failed", "error", err)
+			}
+			if err != nil || n < s.options.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// DispatchOnce claims and sends one batch and returns its size.
+func (s *WebhookService) DispatchOnce(ctx context.Context) (int, error) {
+	dispatches, err := s.store.ClaimDeliveries(ctx, s.options.BatchSize, s.options.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, dispatch := range dispatches {
+		s.send(ctx, dispatch)
+	}
+	return len(dispatches), nil
+}
+
+func (s *WebhookService) send(ctx context.Context, dispatch domain.WebhookDispatch) {
+	delivery := dispatch.Delivery
+	log := s.logger.With("delivery_id", delivery.ID, "subscription_id", delivery.SubscriptionID, "event", delivery.EventType, "attempt", delivery.Attempts)
+
+	status, err := s.sender.Send(ctx, dispatch)
+	if err == nil {
+		if err := s.store.MarkDelivered(ctx, delivery.ID, status); err != nil {
+			// The lease expires and the delivery is sent again.
+			log.Warn("Failed to record webhook delivery", "error", err)
+		}
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	final := delivery.Attempts >= s.options.MaxAttempts
+	if final {
+		log.Error("Giving up on webhook delivery", "error", err, "status", status)
+	} else {
+		log.Warn("Webhook delivery failed, will retry", "error", err, "status", status)
+	}
+
+	retryAt := s.now().Add(s.backoff(delivery.Attempts))
+	if err := s.store.MarkFailed(ctx, delivery.ID, status, err, retryAt, final); err != nil {
+		log.Warn("Failed to record webhook delivery failure", "error", err)
+	}
+}
+
+func (s *WebhookService) backoff(attempt int) time.Duration {
+	delay := s.options.InitialBackoff
+	for i := 1; i < attempt && delay < s.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.options.MaxBackoff {
+		delay = s.options.MaxBackoff
+	}
+	return delay
+}
+
+var (
+	_ WebhookServiceInterface = (*WebhookService)(nil)
+	_ ports.EventSink         = (*WebhookService)(nil)
+)
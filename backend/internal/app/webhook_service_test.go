@@ -0,0 +1,349 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/adapters/webhook"
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// fakeWebhookStore mimics the Postgres webhook store in memory.
+type fakeWebhookStore struct {
+	mu         sync.Mutex
+	now        func() time.Time
+	subs       []domain.WebhookSubscription
+	deliveries []*domain.WebhookDelivery
+}
+
+func (s *fakeWebhookStore) CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub.ID = int64(len(s.subs) + 1)
+	s.subs = append(s.subs, sub)
+	return sub, nil
+}
+
+func (s *fakeWebhookStore) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]domain.WebhookSubscription(nil), s.subs...), nil
+}
+
+func (s *fakeWebhookStore) GetSubscription(ctx context.Context, id int64) (domain.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subs {
+		if sub.ID == id {
+			return sub, nil
+		}
+	}
+	return domain.WebhookSubscription{}, pkgerrors.ErrNotFound
+}
+
+func (s *fakeWebhookStore) UpdateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.subs {
+		if s.subs[i].ID == sub.ID {
+			sub.Secret = s.subs[i].Secret
+			s.subs[i] = sub
+			return sub, nil
+		}
+	}
+	return domain.WebhookSubscription{}, pkgerrors.ErrNotFound
+}
+
+func (s *fakeWebhookStore) DeleteSubscription(ctx context.Context, id int64) error {
+	return errors.New("not implemented")
+}
+
+func (s *fakeWebhookStore) EnqueueDeliveries(ctx context.Context, eventType, eventKey string, payload []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, sub := range s.subs {
+		if !sub.Active || !sub.Subscribes(eventType) || s.queued(sub.ID, eventKey) {
+			continue
+		}
+		s.deliveries = append(s.deliveries, &domain.WebhookDelivery{
+			ID:             int64(len(s.deliveries) + 1),
+			SubscriptionID: sub.ID,
+			EventType:      eventType,
+			EventKey:       eventKey,
+			Payload:        payload,
+			Status:         domain.WebhookDeliveryPending,
+			NextAttemptAt:  s.now(),
+		})
+		n++
+	}
+	return n, nil
+}
+
+func (s *fakeWebhookStore) queued(subscriptionID int64, eventKey string) bool {
+	for _, d := range s.deliveries {
+		if d.SubscriptionID == subscriptionID && d.EventKey == eventKey {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeWebhookStore) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDispatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []domain.WebhookDispatch
+	for _, d := range s.deliveries {
+		if d.Status != domain.WebhookDeliveryPending || s.now().Before(d.NextAttemptAt) || len(claimed) == limit {
+			continue
+		}
+		d.Attempts++
+		d.NextAttemptAt = s.now().Add(lease)
+		sub := s.subs[d.SubscriptionID-1]
+		claimed = append(claimed, domain.WebhookDispatch{Delivery: *d, URL: sub.URL, Secret: sub.Secret})
+	}
+	return claimed, nil
+}
+
+func (s *fakeWebhookStore) MarkDelivered(ctx context.Context, id int64, responseStatus int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.deliveries[id-1]
+	d.Status = domain.WebhookDeliveryDelivered
+	d.ResponseStatus = responseStatus
+	return nil
+}
+
+func (s *fakeWebhookStore) MarkFailed(ctx context.Context, id int64, responseStatus int, cause error, retryAt time.Time, final bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.deliveries[id-1]
+	if final {
+		d.Status = domain.WebhookDeliveryFailed
+	}
+	d.ResponseStatus = responseStatus
+	d.LastError = cause.Error()
+	d.NextAttemptAt = retryAt
+	return nil
+}
+
+func (s *fakeWebhookStore) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]domain.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deliveries []domain.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, *d)
+		}
+	}
+	return deliveries, nil
+}
+
+// webhookReceiver is a local stand-in for a subscriber endpoint that
+// verifies signatures and fails the first failures requests.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	failures int
+	bodies   []string
+	errs     []error
+}
+
+func (r *webhookReceiver) handler(secret func() string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err := webhook.Verify(secret(), req.Header.Get(webhook.SignatureHeader), body, time.Now(), time.Minute); err != nil {
+			r.errs = append(r.errs, err)
+		}
+		if r.failures > 0 {
+			r.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		r.bodies = append(r.bodies, string(body))
+	}
+}
+
+func TestWebhookService_CreateSubscription(t *testing.T) {
+	ctx := context.Background()
+	svc := NewWebhookService(&fakeWebhookStore{now: time.Now}, nil, DefaultWebhookOptions())
+
+	tests := []struct {
+		name    string
+		sub     domain.WebhookSubscription
+		wantErr error
+	}{
+		{"valid", domain.WebhookSubscription{URL: "https://wms.local/hook", Events: []string{domain.EventPackSizesChanged}}, nil},
+		{"relative url", domain.WebhookSubscription{URL: "/hook", Events: []string{domain.EventPackSizesChanged}}, pkgerrors.ErrWebhookURLInvalid},
+		{"ftp url", domain.WebhookSubscription{URL: "ftp://wms.local", Events: []string{domain.EventPackSizesChanged}}, pkgerrors.ErrWebhookURLInvalid},
+		{"no events", domain.WebhookSubscription{URL: "https://wms.local/hook"}, pkgerrors.ErrWebhookEventsInvalid},
+		{"unknown event", domain.WebhookSubscription{URL: "https://wms.local/hook", Events: []string{"orders.created"}}, pkgerrors.ErrWebhookEventsInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := svc.CreateSubscription(ctx, tt.sub)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !strings.HasPrefix(created.Secret, webhookSecretPrefix) {
+				t.Errorf("CreateSubscription() secret = %q, want %s prefix", created.Secret, webhookSecretPrefix)
+			}
+		})
+	}
+
+	t.Run("duplicate events are collapsed", func(t *testing.T) {
+		created, err := svc.CreateSubscription(ctx, domain.WebhookSubscription{
+			URL:    "https://wms.local/hook",
+			Events: []string{domain.EventPacksCalculated, domain.EventPacksCalculated},
+		})
+		if err != nil {
+			t.Fatalf("CreateSubscription() error = %v", err)
+		}
+		if len(created.Events) != 1 {
+			t.Errorf("Events = %v, want one", created.Events)
+		}
+	})
+}
+
+func TestWebhookService_DeliversSignedEventsWithRetries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	store := &fakeWebhookStore{now: clock}
+	options := DefaultWebhookOptions()
+	options.InitialBackoff = time.Second
+	options.MaxAttempts = 3
+	svc := NewWebhookService(store, webhook.NewHTTPSender(webhook.SenderOptions{Timeout: time.Second, AllowPrivateNetworks: true}), options)
+	svc.now = clock
+
+	var secret string
+	receiver := &webhookReceiver{failures: 1}
+	server := httptest.NewServer(receiver.handler(func() string { return secret }))
+	defer server.Close()
+
+	sub, err := svc.CreateSubscription(ctx, domain.WebhookSubscription{URL: server.URL, Events: []string{domain.EventPackSizesChanged}, Active: true})
+	if err != nil {
+		t.Fatalf("CreateSubscription() error = %v", err)
+	}
+	secret = sub.Secret
+
+	event := domain.OutboxEvent{ID: 9, Type: domain.EventPackSizesChanged, Payload: json.RawMessage(`{"version":2,"sizes":[250,500]}`), CreatedAt: now}
+	if err := svc.Deliver(ctx, event); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	// An outbox retry of the same event must not queue it twice.
+	if err := svc.Deliver(ctx, event); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	if n, err := svc.DispatchOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("DispatchOnce() = %d, %v, want 1 delivery", n, err)
+	}
+	deliveries, _ := svc.ListDeliveries(ctx, sub.ID)
+	if len(deliveries) != 1 || deliveries[0].Status != domain.WebhookDeliveryPending || deliveries[0].ResponseStatus != http.StatusServiceUnavailable {
+		t.Fatalf("after failed attempt deliveries = %+v", deliveries)
+	}
+
+	// Not due before the backoff elapses.
+	if n, _ := svc.DispatchOnce(ctx); n != 0 {
+		t.Fatalf("DispatchOnce() before backoff = %d, want 0", n)
+	}
+
+	now = now.Add(time.Second)
+	if n, err := svc.DispatchOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("DispatchOnce() after backoff = %d, %v, want 1", n, err)
+	}
+
+	deliveries, _ = svc.ListDeliveries(ctx, sub.ID)
+	if deliveries[0].Status != domain.WebhookDeliveryDelivered || deliveries[0].Attempts != 2 {
+		t.Errorf("delivery = %+v, want delivered after 2 attempts", deliveries[0])
+	}
+	if len(receiver.errs) != 0 {
+		t.Errorf("receiver rejected signatures: %v", receiver.errs)
+	}
+	if len(receiver.bodies) != 1 {
+		t.Fatalf("receiver got %d events, want 1", len(receiver.bodies))
+	}
+
+	var got WebhookEvent
+	if err := json.Unmarshal([]byte(receiver.bodies[0]), &got); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if got.ID != "outbox:9" || got.Type != domain.EventPackSizesChanged || string(got.Data) != string(event.Payload) {
+		t.Errorf("body = %+v", got)
+	}
+}
+
+func TestWebhookService_GivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	store := &fakeWebhookStore{now: clock}
+	options := DefaultWebhookOptions()
+	options.MaxAttempts = 2
+	svc := NewWebhookService(store, webhook.NewHTTPSender(webhook.SenderOptions{Timeout: time.Second, AllowPrivateNetworks: true}), options)
+	svc.now = clock
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sub, _ := svc.CreateSubscription(ctx, domain.WebhookSubscription{URL: server.URL, Events: []string{domain.EventPacksCalculated}, Active: true})
+	if err := svc.PublishPacksCalculated(ctx, domain.PacksCalculated{Items: 251, CalculatedAt: now}); err != nil {
+		t.Fatalf("PublishPacksCalculated() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		svc.DispatchOnce(ctx)
+		now = now.Add(time.Hour)
+	}
+
+	deliveries, _ := svc.ListDeliveries(ctx, sub.ID)
+	if len(deliveries) != 1 || deliveries[0].Status != domain.WebhookDeliveryFailed || deliveries[0].Attempts != 2 {
+		t.Errorf("deliveries = %+v, want one failed after 2 attempts", deliveries)
+	}
+}
+
+func TestWebhookService_SkipsInactiveAndUnsubscribed(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeWebhookStore{now: time.Now}
+	svc := NewWebhookService(store, nil, DefaultWebhookOptions())
+
+	svc.CreateSubscription(ctx, domain.WebhookSubscription{URL: "http://a.local", Events: []string{domain.EventPackSizesChanged}, Active: false})
+	svc.CreateSubscription(ctx, domain.WebhookSubscription{URL: "http://b.local", Events: []string{domain.EventPacksCalculated}, Active: true})
+
+	if err := svc.Publish(ctx, domain.EventPackSizesChanged, "k", time.Now(), json.RawMessage(`{}`)); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if len(store.deliveries) != 0 {
+		t.Errorf("queued %d deliveries, want 0", len(store.deliveries))
+	}
+}
+
+func TestWebhookService_Backoff(t *testing.T) {
+	svc := NewWebhookService(nil, nil, WebhookOptions{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second})
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := svc.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
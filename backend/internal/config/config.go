@@ -2,14 +2,28 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	DB     DBConfig
-	Redis  RedisConfig
-	Server ServerConfig
+	DB          DBConfig
+	Redis       RedisConfig
+	Cache       CacheConfig
+	Retry       RetryConfig
+	Outbox      OutboxConfig
+	Webhooks    WebhooksConfig
+	Policy      PolicyConfig
+	Auth        AuthConfig
+	RateLimit   RateLimitConfig
+	CORS        CORSConfig
+	Idempotency IdempotencyConfig
+	Server      ServerConfig
 }
 
 type DBConfig struct {
@@ -18,45 +32,510 @@ type DBConfig struct {
 	User     string
 	Password string
 	Name     string
+	// ChangeChannel is the LISTEN/NOTIFY channel for pack-size change events.
+	ChangeChannel string
+	// ReplicaHosts lists read replicas as host or host:port; the port
+	// defaults to Port. Credentials and database name match the primary.
+	ReplicaHosts []string
 }
 
 func (c DBConfig) DSN() string {
+	return c.dsn(c.Host, c.Port)
+}
+
+// ReplicaDSNs returns one DSN per configured read replica.
+func (c DBConfig) ReplicaDSNs() []string {
+	dsns := make([]string, 0, len(c.ReplicaHosts))
+	for _, replica := range c.ReplicaHosts {
+		host, port, _ := c.splitReplica(replica)
+		dsns = append(dsns, c.dsn(host, port))
+	}
+	return dsns
+}
+
+func (c DBConfig) dsn(host string, port int) string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		c.Host, c.Port, c.User, c.Password, c.Name)
+		host, port, c.User, c.Password, c.Name)
+}
+
+func (c DBConfig) splitReplica(replica string) (string, int, error) {
+	if !strings.Contains(replica, ":") {
+		return replica, c.Port, nil
+	}
+
+	host, portStr, err := net.SplitHostPort(replica)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 {
+		return "", 0, fmt.Errorf("invalid port %q", portStr)
+	}
+	return host, port, nil
 }
 
+const (
+	RedisModeSingle   = "single"
+	RedisModeSentinel = "sentinel"
+	RedisModeCluster  = "cluster"
+)
+
 type RedisConfig struct {
-	Host     string
-	Port     int
-	Password string
-	DB       int
+	// URL, when set, takes precedence over Host/Port/Addrs and credentials.
+	// Supports redis:// and rediss:// (TLS) schemes.
+	URL              string
+	Mode             string
+	Host             string
+	Port             int
+	Addrs            []string
+	MasterName       string
+	SentinelUsername string
+	SentinelPassword string
+	Username         string
+	Password         string
+	DB               int
+	TLS              TLSConfig
+}
+
+type TLSConfig struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
 }
 
 func (c RedisConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// Addresses returns the seed addresses for the configured mode: sentinel
+// addresses in sentinel mode, cluster nodes in cluster mode.
+func (c RedisConfig) Addresses() []string {
+	if len(c.Addrs) > 0 {
+		return c.Addrs
+	}
+	return []string{c.Addr()}
+}
+
+const (
+	CacheCodecJSON    = "json"
+	CacheCodecMsgpack = "msgpack"
+)
+
+type CacheConfig struct {
+	Enabled bool
+	TTL     time.Duration
+	// KeyPrefix namespaces every cache key, so several deployments can
+	// share one Redis.
+	KeyPrefix string
+	// Codec selects how cached values are serialized: json or msgpack.
+	Codec string
+	// Required makes startup fail when Redis is unreachable instead of
+	// running in degraded mode.
+	Required         bool
+	FailureThreshold int
+	RetryInterval    time.Duration
+	// Local enables the in-process tier in front of Redis.
+	Local               bool
+	LocalSize           int
+	LocalTTL            time.Duration
+	InvalidationChannel string
+	// EarlyRefreshBeta tunes probabilistic refresh before expiry; 0 disables it.
+	EarlyRefreshBeta float64
+}
+
+// RetryConfig controls retries of transient database and cache failures.
+type RetryConfig struct {
+	// MaxAttempts counts the first try; 1 disables retries.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction by which each delay is randomized.
+	Jitter float64
+}
+
+const (
+	OutboxSinkLog     = "log"
+	OutboxSinkWebhook = "webhook"
+	OutboxSinkFile    = "file"
+)
+
+// OutboxConfig controls delivery of change events to external systems.
+type OutboxConfig struct {
+	// Sinks lists where events are delivered; empty disables the dispatcher.
+	Sinks          []string
+	WebhookURL     string
+	WebhookTimeout time.Duration
+	FilePath       string
+	PollInterval   time.Duration
+	BatchSize      int
+	MaxAttempts    int
+	Lease          time.Duration
+	// Retention is how long events are kept once every sink is done with
+	// them.
+	Retention time.Duration
+}
+
+// WebhooksConfig controls webhook subscriptions and their deliveries.
+type WebhooksConfig struct {
+	Enabled bool
+	// CalculationEvents also publishes packs.calculated after every
+	// calculation, at the cost of a database write per request.
+	CalculationEvents bool
+	Timeout           time.Duration
+	PollInterval      time.Duration
+	BatchSize         int
+	MaxAttempts       int
+	Lease             time.Duration
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+
+	// AllowPrivate lets subscriptions reach loopback, private and link-local
+	// addresses, which are refused by default.
+	AllowPrivate bool
+}
+
+// PolicyConfig holds the soft rules pack-size updates must satisfy. Zero
+// values disable a rule.
+type PolicyConfig struct {
+	MaxSizes        int
+	MinRatio        float64
+	ForbidMultiples bool
+	RequiredSizes   []int
+}
+
+// Roles accepted by AUTH_ANONYMOUS_ROLE.
+const (
+	AuthRoleNone   = "none"
+	AuthRoleReader = "reader"
+	// authRoleAdmin is only accepted in AUTH_JWT_ROLE_MAP.
+	authRoleAdmin = "admin"
+)
+
+// minBootstrapKeyLength keeps the bootstrap key as hard to guess as an issued
+// key.
+const minBootstrapKeyLength = 32
+
+// AuthConfig controls authentication by API key and bearer token.
+type AuthConfig struct {
+	Enabled bool
+	// AnonymousRole is granted to requests without a key: reader, or none to
+	// require a key everywhere.
+	AnonymousRole string
+	// BootstrapKey is accepted as an admin key without being stored, so the
+	// first keys can be created; empty disables it.
+	BootstrapKey string
+	// KeyCacheTTL is how long a verified key is trusted without a database
+	// lookup, and so how long a revocation takes to reach other instances.
+	KeyCacheTTL time.Duration
+	JWT         JWTConfig
+}
+
+// JWTConfig controls bearer token authentication. It is enabled by setting
+// JWKSFile or JWKSURL.
+type JWTConfig struct {
+	// JWKSFile and JWKSURL locate the issuer's signing keys; set at most
+	// one.
+	JWKSFile    string
+	JWKSURL     string
+	JWKSRefresh time.Duration
+	Issuer      string
+	Audience    string
+	// RoleClaim and TenantClaim name the claims holding the caller's roles
+	// and tenant; dots reach into nested objects.
+	RoleClaim   string
+	TenantClaim string
+	// RoleMap lists value=role pairs mapping role claim values to roles;
+	// empty uses the claim values as roles.
+	RoleMap []string
+	Leeway  time.Duration
+}
+
+// Enabled reports whether bearer tokens are accepted.
+func (c JWTConfig) Enabled() bool {
+	return c.JWKSFile != "" || c.JWKSURL != ""
+}
+
+// Roles returns RoleMap as a map from claim value to role.
+func (c JWTConfig) Roles() map[string]string {
+	roles := make(map[string]string, len(c.RoleMap))
+	for _, pair := range c.RoleMap {
+		value, role, _ := strings.Cut(pair, "=")
+		roles[strings.TrimSpace(value)] = strings.TrimSpace(role)
+	}
+	return roles
+}
+
+const (
+	RateLimitBackendMemory = "memory"
+	RateLimitBackendRedis  = "redis"
+)
+
+// RateLimitConfig controls per-client API rate limits. Rates are written
+// limit/window, e.g. 100/1m.
+type RateLimitConfig struct {
+	Enabled bool
+	// Backend keeps counters in memory, per instance, or in Redis, shared by
+	// all instances. Redis falls back to memory while it is unreachable.
+	Backend string
+	// Anonymous applies per client IP, Authenticated per principal.
+	Anonymous     string
+	Authenticated string
+	// Clients lists subject=rate overrides, e.g. api-key:12=1000/1m.
+	Clients []string
+	// Routes lists [METHOD ]path=rate limits on top of the caller's overall
+	// rate, with paths relative to /api/v1; a trailing * matches a prefix.
+	Routes []string
+	// TrustedProxies lists the CIDRs whose X-Forwarded-For is believed.
+	TrustedProxies []string
+}
+
+// Rate is a parsed limit/window rate.
+type Rate struct {
+	Limit  int
+	Window time.Duration
+}
+
+// ParseRate parses limit/window, e.g. 100/1m.
+func ParseRate(s string) (Rate, error) {
+	limitStr, windowStr, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("rate %q must be limit/window", s)
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		return Rate{}, fmt.Errorf("rate %q must have a positive limit", s)
+	}
+	window, err := time.ParseDuration(windowStr)
+	if err != nil || window < time.Second {
+		return Rate{}, fmt.Errorf("rate %q must have a window of at least 1s", s)
+	}
+	return Rate{Limit: limit, Window: window}, nil
+}
+
+// ClientRates returns Clients by subject.
+func (c RateLimitConfig) ClientRates() map[string]Rate {
+	rates := make(map[string]Rate, len(c.Clients))
+	for _, entry := range c.Clients {
+		subject, rate, _ := cutRate(entry)
+		rates[subject] = rate
+	}
+	return rates
+}
+
+// RouteRate is a parsed Routes entry.
+type RouteRate struct {
+	// Method is empty for any method.
+	Method string
+	Path   string
+	Rate   Rate
+}
+
+// RouteRates returns Routes in order.
+func (c RateLimitConfig) RouteRates() []RouteRate {
+	routes := make([]RouteRate, 0, len(c.Routes))
+	for _, entry := range c.Routes {
+		route, rate, _ := cutRate(entry)
+		method, path, ok := strings.Cut(route, " ")
+		if !ok {
+			method, path = "", route
+		}
+		routes = append(routes, RouteRate{Method: strings.ToUpper(method), Path: strings.TrimSpace(path), Rate: rate})
+	}
+	return routes
+}
+
+// Proxies returns TrustedProxies; a bare address trusts just that address.
+func (c RateLimitConfig) Proxies() []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+	for _, proxy := range c.TrustedProxies {
+		prefix, _ := parsePrefix(proxy)
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+// cutRate splits name=rate at the last "=".
+func cutRate(entry string) (string, Rate, error) {
+	i := strings.LastIndex(entry, "=")
+	if i < 0 {
+		return "", Rate{}, fmt.Errorf("entry %q must be name=limit/window", entry)
+	}
+	rate, err := ParseRate(entry[i+1:])
+	return strings.TrimSpace(entry[:i]), rate, err
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// CORSConfig is the cross-origin policy of the API.
+type CORSConfig struct {
+	// AllowedOrigins are scheme://host[:port] origins, "https://*.example.com"
+	// for any subdomain, or "*" for any origin. Empty disables CORS.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// IdempotencyConfig controls replay of POST requests that carry an
+// Idempotency-Key. Responses are kept in Redis when the cache is enabled, and
+// in process otherwise.
+type IdempotencyConfig struct {
+	Enabled bool
+	// TTL is how long a response is replayed for retries.
+	TTL time.Duration
+	// LockTimeout is how long a request in progress holds its key.
+	LockTimeout time.Duration
+}
+
 type ServerConfig struct {
 	Port int
+	// LegacyDeprecatedAt and LegacySunset are announced on the unversioned
+	// /api routes; zero omits the header.
+	LegacyDeprecatedAt time.Time
+	LegacySunset       time.Time
 }
 
 func Load() (*Config, error) {
 	cfg := &Config{
 		DB: DBConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvAsInt("DB_PORT", 5432),
-			User:     getEnv("DB_USER", "packcalc"),
-			Password: getEnv("DB_PASSWORD", "packcalc"),
-			Name:     getEnv("DB_NAME", "packcalc"),
+			Host:          getEnv("DB_HOST", "localhost"),
+			Port:          getEnvAsInt("DB_PORT", 5432),
+			User:          getEnv("DB_USER", "packcalc"),
+			Password:      getEnv("DB_PASSWORD", "packcalc"),
+			Name:          getEnv("DB_NAME", "packcalc"),
+			ChangeChannel: getEnv("DB_CHANGE_CHANNEL", "pack_sizes_changed"),
+			ReplicaHosts:  getEnvAsSlice("DB_REPLICA_HOSTS", nil),
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
-			Port:     getEnvAsInt("REDIS_PORT", 6379),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       getEnvAsInt("REDIS_DB", 0),
+			URL:              getEnv("REDIS_URL", ""),
+			Mode:             getEnv("REDIS_MODE", RedisModeSingle),
+			Host:             getEnv("REDIS_HOST", "localhost"),
+			Port:             getEnvAsInt("REDIS_PORT", 6379),
+			Addrs:            getEnvAsSlice("REDIS_ADDRS", nil),
+			MasterName:       getEnv("REDIS_MASTER_NAME", ""),
+			SentinelUsername: getEnv("REDIS_SENTINEL_USERNAME", ""),
+			SentinelPassword: getEnv("REDIS_SENTINEL_PASSWORD", ""),
+			Username:         getEnv("REDIS_USERNAME", ""),
+			Password:         getEnv("REDIS_PASSWORD", ""),
+			DB:               getEnvAsInt("REDIS_DB", 0),
+			TLS: TLSConfig{
+				Enabled:            getEnvAsBool("REDIS_TLS_ENABLED", false),
+				CAFile:             getEnv("REDIS_TLS_CA_FILE", ""),
+				CertFile:           getEnv("REDIS_TLS_CERT_FILE", ""),
+				KeyFile:            getEnv("REDIS_TLS_KEY_FILE", ""),
+				ServerName:         getEnv("REDIS_TLS_SERVER_NAME", ""),
+				InsecureSkipVerify: getEnvAsBool("REDIS_TLS_INSECURE_SKIP_VERIFY", false),
+			},
+		},
+		Cache: CacheConfig{
+			Enabled:             getEnvAsBool("CACHE_ENABLED", true),
+			TTL:                 getEnvAsDuration("CACHE_TTL", time.Hour),
+			KeyPrefix:           getEnv("CACHE_KEY_PREFIX", "pack-calculator"),
+			Codec:               getEnv("CACHE_CODEC", CacheCodecJSON),
+			Required:            getEnvAsBool("CACHE_REQUIRED", false),
+			FailureThreshold:    getEnvAsInt("CACHE_FAILURE_THRESHOLD", 3),
+			RetryInterval:       getEnvAsDuration("CACHE_RETRY_INTERVAL", 10*time.Second),
+			Local:               getEnvAsBool("CACHE_LOCAL_ENABLED", true),
+			LocalSize:           getEnvAsInt("CACHE_LOCAL_SIZE", 1000),
+			LocalTTL:            getEnvAsDuration("CACHE_LOCAL_TTL", 30*time.Second),
+			InvalidationChannel: getEnv("CACHE_INVALIDATION_CHANNEL", "pack-calculator:cache-invalidate"),
+			EarlyRefreshBeta:    getEnvAsFloat("CACHE_EARLY_REFRESH_BETA", 1.0),
+		},
+		Retry: RetryConfig{
+			MaxAttempts:    getEnvAsInt("RETRY_MAX_ATTEMPTS", 3),
+			InitialBackoff: getEnvAsDuration("RETRY_INITIAL_BACKOFF", 50*time.Millisecond),
+			MaxBackoff:     getEnvAsDuration("RETRY_MAX_BACKOFF", time.Second),
+			Multiplier:     getEnvAsFloat("RETRY_MULTIPLIER", 2.0),
+			Jitter:         getEnvAsFloat("RETRY_JITTER", 0.2),
+		},
+		Outbox: OutboxConfig{
+			Sinks:          getEnvAsSlice("OUTBOX_SINKS", nil),
+			WebhookURL:     getEnv("OUTBOX_WEBHOOK_URL", ""),
+			WebhookTimeout: getEnvAsDuration("OUTBOX_WEBHOOK_TIMEOUT", 10*time.Second),
+			FilePath:       getEnv("OUTBOX_FILE_PATH", ""),
+			PollInterval:   getEnvAsDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			BatchSize:      getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+			MaxAttempts:    getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 10),
+			Lease:          getEnvAsDuration("OUTBOX_LEASE", 30*time.Second),
+			Retention:      getEnvAsDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+		},
+		Webhooks: WebhooksConfig{
+			Enabled:           getEnvAsBool("WEBHOOKS_ENABLED", false),
+			CalculationEvents: getEnvAsBool("WEBHOOKS_CALCULATION_EVENTS", false),
+			AllowPrivate:      getEnvAsBool("WEBHOOKS_ALLOW_PRIVATE_NETWORKS", false),
+			Timeout:           getEnvAsDuration("WEBHOOKS_TIMEOUT", 10*time.Second),
+			PollInterval:      getEnvAsDuration("WEBHOOKS_POLL_INTERVAL", time.Second),
+			BatchSize:         getEnvAsInt("WEBHOOKS_BATCH_SIZE", 100),
+			MaxAttempts:       getEnvAsInt("WEBHOOKS_MAX_ATTEMPTS", 8),
+			Lease:             getEnvAsDuration("WEBHOOKS_LEASE", 30*time.Second),
+			InitialBackoff:    getEnvAsDuration("WEBHOOKS_INITIAL_BACKOFF", 5*time.Second),
+			MaxBackoff:        getEnvAsDuration("WEBHOOKS_MAX_BACKOFF", time.Hour),
+		},
+		Policy: PolicyConfig{
+			MaxSizes:        getEnvAsInt("PACK_POLICY_MAX_SIZES", 0),
+			MinRatio:        getEnvAsFloat("PACK_POLICY_MIN_RATIO", 0),
+			ForbidMultiples: getEnvAsBool("PACK_POLICY_FORBID_MULTIPLES", false),
+			RequiredSizes:   getEnvAsIntSlice("PACK_POLICY_REQUIRED_SIZES"),
+		},
+		Auth: AuthConfig{
+			Enabled:       getEnvAsBool("AUTH_ENABLED", true),
+			AnonymousRole: getEnv("AUTH_ANONYMOUS_ROLE", AuthRoleReader),
+			BootstrapKey:  getEnv("AUTH_BOOTSTRAP_KEY", ""),
+			KeyCacheTTL:   getEnvAsDuration("AUTH_KEY_CACHE_TTL", 30*time.Second),
+			JWT: JWTConfig{
+				JWKSFile:    getEnv("AUTH_JWKS_FILE", ""),
+				JWKSURL:     getEnv("AUTH_JWKS_URL", ""),
+				JWKSRefresh: getEnvAsDuration("AUTH_JWKS_REFRESH", 5*time.Minute),
+				Issuer:      getEnv("AUTH_JWT_ISSUER", ""),
+				Audience:    getEnv("AUTH_JWT_AUDIENCE", ""),
+				RoleClaim:   getEnv("AUTH_JWT_ROLE_CLAIM", "roles"),
+				TenantClaim: getEnv("AUTH_JWT_TENANT_CLAIM", "tenant"),
+				RoleMap:     getEnvAsSlice("AUTH_JWT_ROLE_MAP", nil),
+				Leeway:      getEnvAsDuration("AUTH_JWT_LEEWAY", 30*time.Second),
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:        getEnvAsBool("RATE_LIMIT_ENABLED", true),
+			Backend:        getEnv("RATE_LIMIT_BACKEND", RateLimitBackendRedis),
+			Anonymous:      getEnv("RATE_LIMIT_ANONYMOUS", "100/1m"),
+			Authenticated:  getEnv("RATE_LIMIT_AUTHENTICATED", "600/1m"),
+			Clients:        getEnvAsSlice("RATE_LIMIT_CLIENTS", nil),
+			Routes:         getEnvAsSlice("RATE_LIMIT_ROUTES", nil),
+			TrustedProxies: getEnvAsSlice("RATE_LIMIT_TRUSTED_PROXIES", nil),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000"}),
+			AllowedMethods:   getEnvAsSlice("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE"}),
+			AllowedHeaders:   getEnvAsSlice("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "X-API-Key", "Idempotency-Key"}),
+			ExposedHeaders:   getEnvAsSlice("CORS_EXPOSED_HEADERS", []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", "Deprecation", "Sunset", "Link", "Idempotent-Replayed"}),
+			AllowCredentials: getEnvAsBool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           getEnvAsDuration("CORS_MAX_AGE", 10*time.Minute),
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled:     getEnvAsBool("IDEMPOTENCY_ENABLED", true),
+			TTL:         getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+			LockTimeout: getEnvAsDuration("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute),
 		},
 		Server: ServerConfig{
-			Port: getEnvAsInt("API_PORT", 8080),
+			Port:               getEnvAsInt("API_PORT", 8080),
+			LegacyDeprecatedAt: getEnvAsDate("API_LEGACY_DEPRECATED_AT", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)),
+			LegacySunset:       getEnvAsDate("API_LEGACY_SUNSET", time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)),
 		},
 	}
 
@@ -77,12 +556,361 @@ func (c *Config) validate() error {
 	if c.DB.Name == "" {
 		return fmt.Errorf("DB_NAME is required")
 	}
-	if c.Redis.Host == "" {
-		return fmt.Errorf("REDIS_HOST is required")
+	for _, replica := range c.DB.ReplicaHosts {
+		if host, _, err := c.DB.splitReplica(replica); err != nil || host == "" {
+			return fmt.Errorf("DB_REPLICA_HOSTS entry %q must be host or host:port", replica)
+		}
+	}
+	if err := c.Cache.validate(); err != nil {
+		return err
+	}
+	if c.Cache.Enabled || (c.RateLimit.Enabled && c.RateLimit.Backend == RateLimitBackendRedis) {
+		if err := c.Redis.validate(); err != nil {
+			return err
+		}
+	}
+	if err := c.Retry.validate(); err != nil {
+		return err
+	}
+	if err := c.Outbox.validate(); err != nil {
+		return err
+	}
+	if err := c.Webhooks.validate(); err != nil {
+		return err
+	}
+	if err := c.Policy.validate(); err != nil {
+		return err
+	}
+	if err := c.Auth.validate(); err != nil {
+		return err
+	}
+	if err := c.RateLimit.validate(); err != nil {
+		return err
+	}
+	if err := c.CORS.validate(); err != nil {
+		return err
+	}
+	if c.Idempotency.Enabled {
+		if c.Idempotency.TTL < time.Minute {
+			return fmt.Errorf("IDEMPOTENCY_TTL must be at least 1m")
+		}
+		if c.Idempotency.LockTimeout < time.Second || c.Idempotency.LockTimeout > c.Idempotency.TTL {
+			return fmt.Errorf("IDEMPOTENCY_LOCK_TIMEOUT must be between 1s and IDEMPOTENCY_TTL")
+		}
 	}
 	if c.Server.Port <= 0 {
 		return fmt.Errorf("API_PORT must be greater than 0")
 	}
+	if !c.Server.LegacySunset.IsZero() && c.Server.LegacySunset.Before(c.Server.LegacyDeprecatedAt) {
+		return fmt.Errorf("API_LEGACY_SUNSET must not be before API_LEGACY_DEPRECATED_AT")
+	}
+	return nil
+}
+
+func (c CacheConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.TTL < time.Second {
+		return fmt.Errorf("CACHE_TTL must be at least 1s")
+	}
+	if strings.ContainsAny(c.KeyPrefix, " \t\r\n") {
+		return fmt.Errorf("CACHE_KEY_PREFIX must not contain whitespace")
+	}
+	switch c.Codec {
+	case CacheCodecJSON, CacheCodecMsgpack:
+	default:
+		return fmt.Errorf("CACHE_CODEC must be one of %s, %s", CacheCodecJSON, CacheCodecMsgpack)
+	}
+	if c.FailureThreshold <= 0 {
+		return fmt.Errorf("CACHE_FAILURE_THRESHOLD must be greater than 0")
+	}
+	if c.RetryInterval <= 0 {
+		return fmt.Errorf("CACHE_RETRY_INTERVAL must be greater than 0")
+	}
+	if c.Local {
+		if c.LocalSize <= 0 {
+			return fmt.Errorf("CACHE_LOCAL_SIZE must be greater than 0")
+		}
+		if c.LocalTTL < time.Second {
+			return fmt.Errorf("CACHE_LOCAL_TTL must be at least 1s")
+		}
+		if c.InvalidationChannel == "" {
+			return fmt.Errorf("CACHE_INVALIDATION_CHANNEL is required when the local cache is enabled")
+		}
+	}
+	if c.EarlyRefreshBeta < 0 {
+		return fmt.Errorf("CACHE_EARLY_REFRESH_BETA must not be negative")
+	}
+	return nil
+}
+
+func (c RedisConfig) validate() error {
+	switch c.Mode {
+	case RedisModeSingle, RedisModeCluster:
+	case RedisModeSentinel:
+		if c.MasterName == "" {
+			return fmt.Errorf("REDIS_MASTER_NAME is required in sentinel mode")
+		}
+	default:
+		return fmt.Errorf("REDIS_MODE must be one of %s, %s, %s", RedisModeSingle, RedisModeSentinel, RedisModeCluster)
+	}
+	if c.URL == "" && c.Host == "" && len(c.Addrs) == 0 {
+		return fmt.Errorf("REDIS_HOST, REDIS_ADDRS or REDIS_URL is required")
+	}
+	if c.Mode == RedisModeSingle && len(c.Addrs) > 1 {
+		return fmt.Errorf("REDIS_ADDRS accepts a single address in single mode")
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("REDIS_TLS_CERT_FILE and REDIS_TLS_KEY_FILE must be set together")
+	}
+	return nil
+}
+
+func (c RetryConfig) validate() error {
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("RETRY_MAX_ATTEMPTS must be at least 1")
+	}
+	if c.InitialBackoff < 0 {
+		return fmt.Errorf("RETRY_INITIAL_BACKOFF must not be negative")
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("RETRY_MAX_BACKOFF must not be less than RETRY_INITIAL_BACKOFF")
+	}
+	if c.Multiplier < 1 {
+		return fmt.Errorf("RETRY_MULTIPLIER must be at least 1")
+	}
+	if c.Jitter < 0 || c.Jitter > 1 {
+		return fmt.Errorf("RETRY_JITTER must be between 0 and 1")
+	}
+	return nil
+}
+
+func (c OutboxConfig) validate() error {
+	for _, sink := range c.Sinks {
+		switch sink {
+		case OutboxSinkLog:
+		case OutboxSinkWebhook:
+			if c.WebhookURL == "" {
+				return fmt.Errorf("OUTBOX_WEBHOOK_URL is required for the webhook sink")
+			}
+		case OutboxSinkFile:
+			if c.FilePath == "" {
+				return fmt.Errorf("OUTBOX_FILE_PATH is required for the file sink")
+			}
+		default:
+			return fmt.Errorf("OUTBOX_SINKS entry %q must be one of %s, %s, %s", sink, OutboxSinkLog, OutboxSinkWebhook, OutboxSinkFile)
+		}
+	}
+	if len(c.Sinks) == 0 {
+		return nil
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("OUTBOX_POLL_INTERVAL must be greater than 0")
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("OUTBOX_BATCH_SIZE must be greater than 0")
+	}
+	if c.MaxAttempts <= 0 {
+		return fmt.Errorf("OUTBOX_MAX_ATTEMPTS must be greater than 0")
+	}
+	if c.Lease <= c.WebhookTimeout {
+		return fmt.Errorf("OUTBOX_LEASE must exceed OUTBOX_WEBHOOK_TIMEOUT")
+	}
+	if c.Retention <= 0 {
+		return fmt.Errorf("OUTBOX_RETENTION must be greater than 0")
+	}
+	return nil
+}
+
+func (c AuthConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.AnonymousRole != AuthRoleNone && c.AnonymousRole != AuthRoleReader {
+		return fmt.Errorf("AUTH_ANONYMOUS_ROLE must be one of %s, %s", AuthRoleReader, AuthRoleNone)
+	}
+	if c.BootstrapKey != "" && len(c.BootstrapKey) < minBootstrapKeyLength {
+		return fmt.Errorf("AUTH_BOOTSTRAP_KEY must be at least %d characters", minBootstrapKeyLength)
+	}
+	if c.KeyCacheTTL < 0 {
+		return fmt.Errorf("AUTH_KEY_CACHE_TTL must not be negative")
+	}
+	return c.JWT.validate()
+}
+
+func (c JWTConfig) validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.JWKSFile != "" && c.JWKSURL != "" {
+		return fmt.Errorf("AUTH_JWKS_FILE and AUTH_JWKS_URL are mutually exclusive")
+	}
+	if c.JWKSURL != "" {
+		if u, err := url.Parse(c.JWKSURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("AUTH_JWKS_URL must be an http or https URL")
+		}
+		if c.JWKSRefresh <= 0 {
+			return fmt.Errorf("AUTH_JWKS_REFRESH must be greater than 0")
+		}
+	}
+	// Without both checks a token minted for another service would be
+	// accepted here.
+	if c.Issuer == "" || c.Audience == "" {
+		return fmt.Errorf("AUTH_JWT_ISSUER and AUTH_JWT_AUDIENCE are required with a JWKS")
+	}
+	if c.RoleClaim == "" {
+		return fmt.Errorf("AUTH_JWT_ROLE_CLAIM is required with a JWKS")
+	}
+	for _, pair := range c.RoleMap {
+		value, role, ok := strings.Cut(pair, "=")
+		role = strings.TrimSpace(role)
+		if !ok || strings.TrimSpace(value) == "" || (role != AuthRoleReader && role != authRoleAdmin) {
+			return fmt.Errorf("AUTH_JWT_ROLE_MAP entry %q must be value=%s or value=%s", pair, AuthRoleReader, authRoleAdmin)
+		}
+	}
+	if c.Leeway < 0 {
+		return fmt.Errorf("AUTH_JWT_LEEWAY must not be negative")
+	}
+	return nil
+}
+
+func (c RateLimitConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Backend {
+	case RateLimitBackendMemory, RateLimitBackendRedis:
+	default:
+		return fmt.Errorf("RATE_LIMIT_BACKEND must be one of %s, %s", RateLimitBackendMemory, RateLimitBackendRedis)
+	}
+	if _, err := ParseRate(c.Anonymous); err != nil {
+		return fmt.Errorf("RATE_LIMIT_ANONYMOUS: %w", err)
+	}
+	if _, err := ParseRate(c.Authenticated); err != nil {
+		return fmt.Errorf("RATE_LIMIT_AUTHENTICATED: %w", err)
+	}
+	for _, entry := range c.Clients {
+		if subject, _, err := cutRate(entry); err != nil || subject == "" {
+			return fmt.Errorf("RATE_LIMIT_CLIENTS entry %q must be subject=limit/window", entry)
+		}
+	}
+	for _, entry := range c.Routes {
+		route, _, err := cutRate(entry)
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = strings.TrimSpace(path)
+		}
+		if err != nil || !strings.HasPrefix(route, "/") {
+			return fmt.Errorf("RATE_LIMIT_ROUTES entry %q must be [METHOD ]/path=limit/window", entry)
+		}
+	}
+	for _, proxy := range c.TrustedProxies {
+		if _, err := parsePrefix(proxy); err != nil {
+			return fmt.Errorf("RATE_LIMIT_TRUSTED_PROXIES entry %q must be an IP address or CIDR", proxy)
+		}
+	}
+	return nil
+}
+
+func (c CORSConfig) validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("CORS_ALLOWED_ORIGINS must list origins, not *, with CORS_ALLOW_CREDENTIALS")
+			}
+			continue
+		}
+		if err := validateOrigin(origin); err != nil {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS entry %q %w", origin, err)
+		}
+	}
+	for _, method := range c.AllowedMethods {
+		if !isToken(method) {
+			return fmt.Errorf("CORS_ALLOWED_METHODS entry %q is not a method", method)
+		}
+	}
+	for _, header := range c.AllowedHeaders {
+		if !isToken(header) {
+			return fmt.Errorf("CORS_ALLOWED_HEADERS entry %q is not a header name", header)
+		}
+	}
+	for _, header := range c.ExposedHeaders {
+		if !isToken(header) {
+			return fmt.Errorf("CORS_EXPOSED_HEADERS entry %q is not a header name", header)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("CORS_MAX_AGE must not be negative")
+	}
+	return nil
+}
+
+// validateOrigin accepts scheme://host[:port], where the host may start with
+// a "*." wildcard label.
+func validateOrigin(origin string) error {
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || strings.HasSuffix(origin, "?") {
+		return fmt.Errorf("must be scheme://host[:port]")
+	}
+	if strings.Contains(u.Host, "*") {
+		return fmt.Errorf("may only use * as the first label of the host")
+	}
+	return nil
+}
+
+// isToken reports whether s is an HTTP token, as method and header names are.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r > 0x7e || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c WebhooksConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("WEBHOOKS_TIMEOUT must be greater than 0")
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("WEBHOOKS_POLL_INTERVAL must be greater than 0")
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("WEBHOOKS_BATCH_SIZE must be greater than 0")
+	}
+	if c.MaxAttempts <= 0 {
+		return fmt.Errorf("WEBHOOKS_MAX_ATTEMPTS must be greater than 0")
+	}
+	if c.Lease <= c.Timeout {
+		return fmt.Errorf("WEBHOOKS_LEASE must exceed WEBHOOKS_TIMEOUT")
+	}
+	if c.InitialBackoff <= 0 || c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("WEBHOOKS_INITIAL_BACKOFF must be greater than 0 and at most WEBHOOKS_MAX_BACKOFF")
+	}
+	return nil
+}
+
+func (c PolicyConfig) validate() error {
+	if c.MaxSizes < 0 {
+		return fmt.Errorf("PACK_POLICY_MAX_SIZES must not be negative")
+	}
+	if c.MinRatio != 0 && c.MinRatio <= 1 {
+		return fmt.Errorf("PACK_POLICY_MIN_RATIO must be greater than 1, or 0 to disable")
+	}
+	for _, size := range c.RequiredSizes {
+		if size <= 0 {
+			return fmt.Errorf("PACK_POLICY_REQUIRED_SIZES must list positive integers")
+		}
+	}
+	if c.MaxSizes > 0 && len(c.RequiredSizes) > c.MaxSizes {
+		return fmt.Errorf("PACK_POLICY_REQUIRED_SIZES cannot exceed PACK_POLICY_MAX_SIZES")
+	}
 	return nil
 }
 
@@ -104,3 +932,83 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return value
 }
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsDate parses a YYYY-MM-DD date in UTC; "none" yields the zero time.
+func getEnvAsDate(key string, defaultValue time.Time) time.Time {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	if valueStr == "none" {
+		return time.Time{}
+	}
+	value, err := time.Parse(time.DateOnly, valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	var values []string
+	for _, part := range strings.Split(valueStr, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// getEnvAsIntSlice parses a comma-separated list; invalid entries become 0 so
+// validation rejects them instead of silently dropping them.
+func getEnvAsIntSlice(key string) []int {
+	var values []int
+	for _, part := range getEnvAsSlice(key, nil) {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			value = 0
+		}
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,477 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func validConfig() *Config {
+	return &Config{
+		DB: DBConfig{Host: "localhost", User: "packcalc", Name: "packcalc"},
+		Redis: RedisConfig{
+			Mode: RedisModeSingle,
+			Host: "localhost",
+			Port: 6379,
+		},
+		Cache: CacheConfig{
+			Enabled:          true,
+			TTL:              time.Hour,
+			KeyPrefix:        "pack-calculator",
+			Codec:            CacheCodecJSON,
+			FailureThreshold: 3,
+			RetryInterval:    10 * time.Second,
+		},
+		Retry:  RetryConfig{MaxAttempts: 3, InitialBackoff: 50 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2},
+		Server: ServerConfig{Port: 8080},
+	}
+}
+
+func validJWTConfig() JWTConfig {
+	return JWTConfig{
+		JWKSURL:     "https://sso.example.com/.well-known/jwks.json",
+		JWKSRefresh: 5 * time.Minute,
+		Issuer:      "https://sso.example.com",
+		Audience:    "pack-calculator",
+		RoleClaim:   "roles",
+		RoleMap:     []string{"pack-admins=admin", "pack-readers = reader"},
+	}
+}
+
+func validRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Enabled:        true,
+		Backend:        RateLimitBackendRedis,
+		Anonymous:      "100/1m",
+		Authenticated:  "600/1m",
+		Clients:        []string{"api-key:12=6000/1m"},
+		Routes:         []string{"post /calculate=30/1m", "/webhooks*=60/1m"},
+		TrustedProxies: []string{"10.0.0.0/8", "127.0.0.1"},
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			modify: func(c *Config) {},
+		},
+		{
+			name:    "cache ttl below one second",
+			modify:  func(c *Config) { c.Cache.TTL = 500 * time.Millisecond },
+			wantErr: true,
+		},
+		{
+			name:    "key prefix with whitespace",
+			modify:  func(c *Config) { c.Cache.KeyPrefix = "pack calculator" },
+			wantErr: true,
+		},
+		{
+			name:   "empty key prefix",
+			modify: func(c *Config) { c.Cache.KeyPrefix = "" },
+		},
+		{
+			name:   "msgpack codec",
+			modify: func(c *Config) { c.Cache.Codec = CacheCodecMsgpack },
+		},
+		{
+			name:    "unknown codec",
+			modify:  func(c *Config) { c.Cache.Codec = "gob" },
+			wantErr: true,
+		},
+		{
+			name:   "replica hosts",
+			modify: func(c *Config) { c.DB.ReplicaHosts = []string{"replica-1", "replica-2:5433"} },
+		},
+		{
+			name:    "replica with invalid port",
+			modify:  func(c *Config) { c.DB.ReplicaHosts = []string{"replica-1:abc"} },
+			wantErr: true,
+		},
+		{
+			name: "outbox sinks",
+			modify: func(c *Config) {
+				c.Outbox = OutboxConfig{
+					Sinks:          []string{OutboxSinkLog, OutboxSinkWebhook},
+					WebhookURL:     "http://wms.local/events",
+					WebhookTimeout: 10 * time.Second,
+					PollInterval:   time.Second,
+					BatchSize:      100,
+					MaxAttempts:    10,
+					Lease:          30 * time.Second,
+					Retention:      7 * 24 * time.Hour,
+				}
+			},
+		},
+		{
+			name: "outbox without retention",
+			modify: func(c *Config) {
+				c.Outbox = OutboxConfig{
+					Sinks:        []string{OutboxSinkLog},
+					PollInterval: time.Second,
+					BatchSize:    100,
+					MaxAttempts:  10,
+					Lease:        30 * time.Second,
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name:    "webhook sink without url",
+			modify:  func(c *Config) { c.Outbox.Sinks = []string{OutboxSinkWebhook} },
+			wantErr: true,
+		},
+		{
+			name:    "unknown outbox sink",
+			modify:  func(c *Config) { c.Outbox.Sinks = []string{"kafka"} },
+			wantErr: true,
+		},
+		{
+			name: "webhooks",
+			modify: func(c *Config) {
+				c.Webhooks = WebhooksConfig{
+					Enabled:        true,
+					Timeout:        10 * time.Second,
+					PollInterval:   time.Second,
+					BatchSize:      100,
+					MaxAttempts:    8,
+					Lease:          30 * time.Second,
+					InitialBackoff: 5 * time.Second,
+					MaxBackoff:     time.Hour,
+				}
+			},
+		},
+		{
+			name: "webhook lease not above timeout",
+			modify: func(c *Config) {
+				c.Webhooks = WebhooksConfig{Enabled: true, Timeout: time.Minute, PollInterval: time.Second, BatchSize: 1, MaxAttempts: 1, Lease: time.Minute}
+			},
+			wantErr: true,
+		},
+		{
+			name: "pack policy",
+			modify: func(c *Config) {
+				c.Policy = PolicyConfig{MaxSizes: 5, MinRatio: 1.5, ForbidMultiples: true, RequiredSizes: []int{1}}
+			},
+		},
+		{
+			name:    "policy ratio not above one",
+			modify:  func(c *Config) { c.Policy.MinRatio = 0.5 },
+			wantErr: true,
+		},
+		{
+			name:    "invalid required size",
+			modify:  func(c *Config) { c.Policy.RequiredSizes = []int{0} },
+			wantErr: true,
+		},
+		{
+			name: "sunset before deprecation",
+			modify: func(c *Config) {
+				c.Server.LegacyDeprecatedAt = time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+				c.Server.LegacySunset = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			},
+			wantErr: true,
+		},
+		{
+			name: "auth requiring a key everywhere",
+			modify: func(c *Config) {
+				c.Auth = AuthConfig{Enabled: true, AnonymousRole: AuthRoleNone, BootstrapKey: "0123456789abcdef0123456789abcdef"}
+			},
+		},
+		{
+			name:    "anonymous admin",
+			modify:  func(c *Config) { c.Auth = AuthConfig{Enabled: true, AnonymousRole: "admin"} },
+			wantErr: true,
+		},
+		{
+			name: "short bootstrap key",
+			modify: func(c *Config) {
+				c.Auth = AuthConfig{Enabled: true, AnonymousRole: AuthRoleReader, BootstrapKey: "admin"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "jwt with a jwks url and role map",
+			modify: func(c *Config) {
+				c.Auth = AuthConfig{Enabled: true, AnonymousRole: AuthRoleReader, JWT: validJWTConfig()}
+			},
+		},
+		{
+			name: "jwt without audience",
+			modify: func(c *Config) {
+				jwt := validJWTConfig()
+				jwt.Audience = ""
+				c.Auth = AuthConfig{Enabled: true, AnonymousRole: AuthRoleReader, JWT: jwt}
+			},
+			wantErr: true,
+		},
+		{
+			name: "jwt with both jwks file and url",
+			modify: func(c *Config) {
+				jwt := validJWTConfig()
+				jwt.JWKSFile = "/etc/jwks.json"
+				c.Auth = AuthConfig{Enabled: true, AnonymousRole: AuthRoleReader, JWT: jwt}
+			},
+			wantErr: true,
+		},
+		{
+			name: "jwt role map to unknown role",
+			modify: func(c *Config) {
+				jwt := validJWTConfig()
+				jwt.RoleMap = []string{"pack-admins=owner"}
+				c.Auth = AuthConfig{Enabled: true, AnonymousRole: AuthRoleReader, JWT: jwt}
+			},
+			wantErr: true,
+		},
+		{
+			name:   "rate limiting",
+			modify: func(c *Config) { c.RateLimit = validRateLimitConfig() },
+		},
+		{
+			name: "redis rate limiting requires redis",
+			modify: func(c *Config) {
+				c.Cache = CacheConfig{Enabled: false}
+				c.Redis = RedisConfig{}
+				c.RateLimit = validRateLimitConfig()
+			},
+			wantErr: true,
+		},
+		{
+			name: "memory rate limiting without redis",
+			modify: func(c *Config) {
+				c.Cache = CacheConfig{Enabled: false}
+				c.Redis = RedisConfig{}
+				c.RateLimit = validRateLimitConfig()
+				c.RateLimit.Backend = RateLimitBackendMemory
+			},
+		},
+		{
+			name: "unknown rate limit backend",
+			modify: func(c *Config) {
+				c.RateLimit = validRateLimitConfig()
+				c.RateLimit.Backend = "etcd"
+			},
+			wantErr: true,
+		},
+		{
+			name: "rate without window",
+			modify: func(c *Config) {
+				c.RateLimit = validRateLimitConfig()
+				c.RateLimit.Anonymous = "100"
+			},
+			wantErr: true,
+		},
+		{
+			name: "client rate without subject",
+			modify: func(c *Config) {
+				c.RateLimit = validRateLimitConfig()
+				c.RateLimit.Clients = []string{"=10/1s"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "route rate without leading slash",
+			modify: func(c *Config) {
+				c.RateLimit = validRateLimitConfig()
+				c.RateLimit.Routes = []string{"GET calculate=10/1s"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid trusted proxy",
+			modify: func(c *Config) {
+				c.RateLimit = validRateLimitConfig()
+				c.RateLimit.TrustedProxies = []string{"nginx"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "cors origins",
+			modify: func(c *Config) {
+				c.CORS = CORSConfig{AllowedOrigins: []string{"https://app.example.com", "https://*.example.org:8443"}, AllowCredentials: true}
+			},
+		},
+		{
+			name:   "cors any origin",
+			modify: func(c *Config) { c.CORS = CORSConfig{AllowedOrigins: []string{"*"}} },
+		},
+		{
+			name:    "cors any origin with credentials",
+			modify:  func(c *Config) { c.CORS = CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true} },
+			wantErr: true,
+		},
+		{
+			name:    "cors origin with path",
+			modify:  func(c *Config) { c.CORS = CORSConfig{AllowedOrigins: []string{"https://app.example.com/"}} },
+			wantErr: true,
+		},
+		{
+			name:    "cors origin without scheme",
+			modify:  func(c *Config) { c.CORS = CORSConfig{AllowedOrigins: []string{"app.example.com"}} },
+			wantErr: true,
+		},
+		{
+			name:    "cors wildcard inside host",
+			modify:  func(c *Config) { c.CORS = CORSConfig{AllowedOrigins: []string{"https://app.*.example.com"}} },
+			wantErr: true,
+		},
+		{
+			name:    "cors header with space",
+			modify:  func(c *Config) { c.CORS = CORSConfig{AllowedHeaders: []string{"X API Key"}} },
+			wantErr: true,
+		},
+		{
+			name: "idempotency",
+			modify: func(c *Config) {
+				c.Idempotency = IdempotencyConfig{Enabled: true, TTL: 24 * time.Hour, LockTimeout: time.Minute}
+			},
+		},
+		{
+			name: "idempotency lock outlasting responses",
+			modify: func(c *Config) {
+				c.Idempotency = IdempotencyConfig{Enabled: true, TTL: time.Hour, LockTimeout: 2 * time.Hour}
+			},
+			wantErr: true,
+		},
+		{
+			name: "idempotency ttl too short",
+			modify: func(c *Config) {
+				c.Idempotency = IdempotencyConfig{Enabled: true, TTL: time.Second, LockTimeout: time.Second}
+			},
+			wantErr: true,
+		},
+		{
+			name:   "retries disabled",
+			modify: func(c *Config) { c.Retry.MaxAttempts = 1 },
+		},
+		{
+			name:    "no attempts",
+			modify:  func(c *Config) { c.Retry.MaxAttempts = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "max backoff below initial backoff",
+			modify:  func(c *Config) { c.Retry.MaxBackoff = time.Millisecond },
+			wantErr: true,
+		},
+		{
+			name:    "jitter above one",
+			modify:  func(c *Config) { c.Retry.Jitter = 1.5 },
+			wantErr: true,
+		},
+		{
+			name: "disabled cache skips cache and redis checks",
+			modify: func(c *Config) {
+				c.Cache = CacheConfig{Enabled: false}
+				c.Redis = RedisConfig{}
+			},
+		},
+		{
+			name:    "sentinel without master name",
+			modify:  func(c *Config) { c.Redis.Mode = RedisModeSentinel },
+			wantErr: true,
+		},
+		{
+			name:    "unknown redis mode",
+			modify:  func(c *Config) { c.Redis.Mode = "ring" },
+			wantErr: true,
+		},
+		{
+			name: "local tier without size",
+			modify: func(c *Config) {
+				c.Cache.Local = true
+				c.Cache.LocalTTL = time.Second
+				c.Cache.InvalidationChannel = "ch"
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			err := cfg.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDBConfig_ReplicaDSNs(t *testing.T) {
+	cfg := DBConfig{Port: 5432, User: "packcalc", Password: "secret", Name: "packcalc", ReplicaHosts: []string{"replica-1", "replica-2:5433"}}
+
+	want := []string{
+		"host=replica-1 port=5432 user=packcalc password=secret dbname=packcalc sslmode=disable",
+		"host=replica-2 port=5433 user=packcalc password=secret dbname=packcalc sslmode=disable",
+	}
+	got := cfg.ReplicaDSNs()
+	if len(got) != len(want) {
+		t.Fatalf("ReplicaDSNs() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ReplicaDSNs()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestJWTConfig_Roles(t *testing.T) {
+	roles := validJWTConfig().Roles()
+	if len(roles) != 2 || roles["pack-admins"] != "admin" || roles["pack-readers"] != "reader" {
+		t.Errorf("Roles() = %v", roles)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rate
+		wantErr bool
+	}{
+		{in: "100/1m", want: Rate{Limit: 100, Window: time.Minute}},
+		{in: " 5/30s ", want: Rate{Limit: 5, Window: 30 * time.Second}},
+		{in: "0/1m", wantErr: true},
+		{in: "10/500ms", wantErr: true},
+		{in: "10/minute", wantErr: true},
+		{in: "10", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRate(%q) = %v, %v; want %v, wantErr %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRateLimitConfig_Parsed(t *testing.T) {
+	cfg := validRateLimitConfig()
+
+	if got := cfg.ClientRates(); got["api-key:12"] != (Rate{Limit: 6000, Window: time.Minute}) {
+		t.Errorf("ClientRates() = %v", got)
+	}
+
+	routes := cfg.RouteRates()
+	want := []RouteRate{
+		{Method: "POST", Path: "/calculate", Rate: Rate{Limit: 30, Window: time.Minute}},
+		{Path: "/webhooks*", Rate: Rate{Limit: 60, Window: time.Minute}},
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("RouteRates() = %v, want %v", routes, want)
+	}
+	for i := range want {
+		if routes[i] != want[i] {
+			t.Errorf("RouteRates()[%d] = %v, want %v", i, routes[i], want[i])
+		}
+	}
+
+	proxies := cfg.Proxies()
+	if len(proxies) != 2 || proxies[0].String() != "10.0.0.0/8" || proxies[1].String() != "127.0.0.1/32" {
+		t.Errorf("Proxies() = %v", proxies)
+	}
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Roles are ordered: each role may do everything the previous one may.
+const (
+	RoleReader = "reader"
+	RoleAdmin  = "admin"
+)
+
+var roleRank = map[string]int{RoleReader: 1, RoleAdmin: 2}
+
+// ValidRole reports whether role is a known role.
+func ValidRole(role string) bool {
+	return roleRank[role] > 0
+}
+
+// HighestRole returns the most privileged known role in roles, or "".
+func HighestRole(roles []string) string {
+	highest := ""
+	for _, role := range roles {
+		if roleRank[role] > roleRank[highest] {
+			highest = role
+		}
+	}
+	return highest
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject names the caller, e.g. "api-key:12" or "jwt:alice"; empty for
+	// anonymous callers.
+	Subject string
+	Role    string
+	// Tenant is the organisation the caller acts for, when its credential
+	// names one.
+	Tenant string
+}
+
+// Allows reports whether the principal's role includes required.
+func (p Principal) Allows(required string) bool {
+	return ValidRole(p.Role) && roleRank[p.Role] >= roleRank[required]
+}
+
+type principalKey struct{}
+
+// WithPrincipal attaches the caller of a request to ctx.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the caller attached to ctx, if any.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// APIKey is a stored API key. Only the hash of the key is kept; Prefix
+// identifies the key to humans.
+type APIKey struct {
+	ID        int64
+	Name      string
+	Prefix    string
+	Hash      string
+	Role      string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
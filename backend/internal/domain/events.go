@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// PackSizesChanged is published after a new pack-size version becomes active.
+type PackSizesChanged struct {
+	Version   int       `json:"version"`
+	Sizes     []int     `json:"sizes"`
+	ChangedAt time.Time `json:"changed_at"`
+	// ChangedBy and Tenant identify the principal that made the change, when
+	// the request was authenticated.
+	ChangedBy string `json:"changed_by,omitempty"`
+	Tenant    string `json:"tenant,omitempty"`
+}
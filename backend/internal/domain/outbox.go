@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventPackSizesChanged is the outbox event type for PackSizesChanged.
+const EventPackSizesChanged = "pack_sizes.changed"
+
+// OutboxEvent is an integration event recorded in the same transaction as the
+// change it describes.
+type OutboxEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// OutboxDelivery is one attempt to deliver an event to one sink. Attempt
+// counts from 1.
+type OutboxDelivery struct {
+	Event   OutboxEvent
+	Sink    string
+	Attempt int
+}
@@ -1,6 +1,12 @@
 package domain
 
 type Pack struct {
-	Size     int
-	Quantity int
+	Size     int `json:"size"`
+	Quantity int `json:"quantity"`
+}
+
+// PackSizeSet is a versioned set of pack sizes.
+type PackSizeSet struct {
+	Version int
+	Sizes   []int
 }
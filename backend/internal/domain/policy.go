@@ -0,0 +1,16 @@
+package domain
+
+// PackSizePolicy holds the operational rules a pack-size set must satisfy on
+// top of the hard range and duplicate checks. Zero values disable a rule.
+type PackSizePolicy struct {
+	// MaxSizes caps how many sizes a set may contain.
+	MaxSizes int
+	// MinRatio is the smallest allowed ratio between neighbouring sizes,
+	// e.g. 1.5 rejects 250 next to 300.
+	MinRatio float64
+	// ForbidMultiples rejects sizes that are an exact multiple of a smaller
+	// size in the set.
+	ForbidMultiples bool
+	// RequiredSizes must all be present, e.g. [1] for a unit pack.
+	RequiredSizes []int
+}
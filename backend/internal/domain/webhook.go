@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventPacksCalculated is the webhook event type for PacksCalculated.
+const EventPacksCalculated = "packs.calculated"
+
+// WebhookEventTypes lists the events a webhook can subscribe to.
+var WebhookEventTypes = []string{EventPackSizesChanged, EventPacksCalculated}
+
+// PacksCalculated describes a completed pack calculation.
+type PacksCalculated struct {
+	Items        int       `json:"items"`
+	Packs        []Pack    `json:"packs"`
+	CalculatedAt time.Time `json:"calculated_at"`
+}
+
+// WebhookSubscription is an endpoint that receives signed event payloads.
+type WebhookSubscription struct {
+	ID          int64
+	URL         string
+	Events      []string
+	Secret      string
+	Description string
+	Active      bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Subscribes reports whether the subscription wants events of eventType.
+func (s WebhookSubscription) Subscribes(eventType string) bool {
+	for _, event := range s.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event queued for one subscription, together with
+// the outcome of its latest attempt.
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID int64
+	EventType      string
+	// EventKey identifies the event across retries, so a receiver can drop
+	// duplicates.
+	EventKey       string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int
+	ResponseStatus int
+	LastError      string
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// WebhookDispatch is a claimed delivery with the endpoint it goes to.
+type WebhookDispatch struct {
+	Delivery WebhookDelivery
+	URL      string
+	Secret   string
+}
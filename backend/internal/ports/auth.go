@@ -0,0 +1,26 @@
+package ports
+
+import (
+	"context"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/pkg/jwt"
+)
+
+// APIKeyStore persists API keys by hash.
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key domain.APIKey) (domain.APIKey, error)
+	// ListAPIKeys returns every key, revoked ones included.
+	ListAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	// GetActiveAPIKey returns the unrevoked key with hash, or ErrNotFound.
+	GetActiveAPIKey(ctx context.Context, hash string) (domain.APIKey, error)
+	// RevokeAPIKey returns ErrNotFound for an unknown or already revoked id.
+	RevokeAPIKey(ctx context.Context, id int64) error
+}
+
+// KeySet provides the public keys bearer tokens are signed with.
+type KeySet interface {
+	// Key returns the key with id kid, or ErrNotFound. An empty kid matches
+	// the only key of a single-key set.
+	Key(ctx context.Context, kid string) (jwt.Key, error)
+}
@@ -1,7 +1,47 @@
 package ports
 
+import (
+	"context"
+	"time"
+)
+
+// Cache is a byte-oriented key/value store. Typed access goes through
+// TypedCache, which adds serialization on top of any Cache.
 type Cache interface {
-	Get(key string) ([]int, error)
-	Set(key string, value []int, ttl int) error
-	Delete(key string) error
+	// Get returns the value stored under key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// GetMany returns the values found; missing keys are absent from the map.
+	GetMany(ctx context.Context, keys []string) (map[string][]byte, error)
+	// Set stores value for ttl; a ttl of 0 means no expiry.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	SetMany(ctx context.Context, items map[string][]byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	VersionStore
+}
+
+// VersionStore keeps monotonically increasing version pointers.
+type VersionStore interface {
+	// GetVersion returns the version stored under key, or ErrNotFound.
+	GetVersion(ctx context.Context, key string) (int, error)
+	// AdvanceVersion stores version under key unless the key already holds
+	// a newer one, so a slow writer can never move the pointer backwards.
+	// Storing the current version again refreshes its TTL.
+	AdvanceVersion(ctx context.Context, key string, version int, ttl time.Duration) error
+}
+
+// Codec serializes cached values.
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// TypedCache stores values of a single type.
+type TypedCache[T any] interface {
+	Get(ctx context.Context, key string) (T, error)
+	GetMany(ctx context.Context, keys []string) (map[string]T, error)
+	Set(ctx context.Context, key string, value T, ttl time.Duration) error
+	SetMany(ctx context.Context, items map[string]T, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	VersionStore
 }
@@ -0,0 +1,9 @@
+package ports
+
+import "context"
+
+// HealthChecker reports the status of a dependency for health endpoints.
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
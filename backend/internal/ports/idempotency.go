@@ -0,0 +1,18 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyStore remembers the outcome of requests by idempotency key. A
+// shared store catches retries that reach another instance.
+type IdempotencyStore interface {
+	// Reserve stores pending under key for ttl unless the key is taken, in
+	// which case it returns the value already stored and reserved false.
+	Reserve(ctx context.Context, key string, pending []byte, ttl time.Duration) (stored []byte, reserved bool, err error)
+	// Complete replaces a reservation with the final value.
+	Complete(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Release drops a reservation, so the request may be retried.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	"pack-calculator/internal/domain"
+)
+
+// ChangeNotifier delivers pack-size change events to every application instance.
+type ChangeNotifier interface {
+	Publish(ctx context.Context, event domain.PackSizesChanged) error
+	// Subscribe calls handle for each event until ctx is cancelled or the
+	// subscription fails.
+	Subscribe(ctx context.Context, handle func(domain.PackSizesChanged)) error
+}
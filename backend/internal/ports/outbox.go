@@ -0,0 +1,31 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"pack-calculator/internal/domain"
+)
+
+// OutboxStore tracks delivery of outbox events to each sink.
+type OutboxStore interface {
+	// ClaimDeliveries returns up to limit due deliveries for the given sinks
+	// and hides them from other claimers for lease. A delivery that is neither
+	// completed nor failed before the lease ends is claimed again.
+	ClaimDeliveries(ctx context.Context, sinks []string, limit int, lease time.Duration) ([]domain.OutboxDelivery, error)
+	MarkDelivered(ctx context.Context, eventID int64, sink string) error
+	// MarkFailed records a failed attempt. The delivery is retried at retryAt
+	// unless final is set, in which case it is given up.
+	MarkFailed(ctx context.Context, eventID int64, sink string, cause error, retryAt time.Time, final bool) error
+	// Prune deletes up to limit events created before before whose
+	// deliveries to sinks are all delivered or given up, and returns how
+	// many it deleted.
+	Prune(ctx context.Context, sinks []string, before time.Time, limit int) (int, error)
+}
+
+// EventSink delivers outbox events to an external system. Delivery is
+// at-least-once, so sinks and their consumers must tolerate duplicates.
+type EventSink interface {
+	Name() string
+	Deliver(ctx context.Context, event domain.OutboxEvent) error
+}
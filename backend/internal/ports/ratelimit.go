@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// RateCounter counts requests per key in fixed windows. A shared counter
+// makes limits hold across instances.
+type RateCounter interface {
+	// Increment adds n to key's count in the window starting at window and
+	// returns the counts of that window and of the one before it.
+	Increment(ctx context.Context, key string, window time.Time, length time.Duration, n int) (current, previous int, err error)
+}
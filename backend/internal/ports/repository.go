@@ -1,6 +1,15 @@
 package ports
 
+import (
+	"context"
+
+	"pack-calculator/internal/domain"
+)
+
 type PackSizeRepository interface {
-	GetAllActive() ([]int, error)
-	Create(sizes []int) error
+	// GetAllActive returns the active pack sizes and their version. Version
+	// is 0 when no sizes have been stored yet.
+	GetAllActive(ctx context.Context) (domain.PackSizeSet, error)
+	// Create stores sizes as the new active set and returns its version.
+	Create(ctx context.Context, sizes []int) (int, error)
 }
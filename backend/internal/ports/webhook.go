@@ -0,0 +1,39 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"pack-calculator/internal/domain"
+)
+
+// WebhookStore persists webhook subscriptions and their delivery log.
+type WebhookStore interface {
+	CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	// GetSubscription returns ErrNotFound for an unknown id.
+	GetSubscription(ctx context.Context, id int64) (domain.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+
+	// EnqueueDeliveries queues the event for every active subscription to
+	// eventType and returns how many were queued. Enqueuing the same
+	// eventKey again is a no-op.
+	EnqueueDeliveries(ctx context.Context, eventType, eventKey string, payload []byte) (int, error)
+	// ClaimDeliveries returns up to limit due deliveries and hides them from
+	// other claimers for lease.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDispatch, error)
+	MarkDelivered(ctx context.Context, id int64, responseStatus int) error
+	// MarkFailed records a failed attempt; the delivery is retried at retryAt
+	// unless final is set.
+	MarkFailed(ctx context.Context, id int64, responseStatus int, cause error, retryAt time.Time, final bool) error
+	// ListDeliveries returns the most recent deliveries of a subscription,
+	// newest first.
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]domain.WebhookDelivery, error)
+}
+
+// WebhookSender posts a signed delivery to its endpoint and returns the HTTP
+// status, or 0 when no response was received.
+type WebhookSender interface {
+	Send(ctx context.Context, dispatch domain.WebhookDispatch) (int, error)
+}
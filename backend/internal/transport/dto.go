@@ -1,5 +1,10 @@
 package transport
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type PackSizesResponse struct {
 	Sizes []int `json:"sizes"`
 }
@@ -9,7 +14,8 @@ type UpdatePackSizesRequest struct {
 }
 
 type CalculateRequest struct {
-	Items int `json:"items"`
+	// Items is a pointer so a missing value can be told apart from 0.
+	Items *int `json:"items"`
 }
 
 type PackResponse struct {
@@ -21,6 +27,148 @@ type CalculateResponse struct {
 	Packs []PackResponse `json:"packs"`
 }
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+type PreviewRequest struct {
+	Sizes []int `json:"sizes"`
+	// Orders is a sample of order quantities; repeat a quantity to weight it.
+	Orders []int `json:"orders"`
+}
+
+type PackSizeImpactResponse struct {
+	Sizes            []int   `json:"sizes"`
+	TotalItems       int     `json:"total_items"`
+	TotalShipped     int     `json:"total_shipped"`
+	TotalOvershoot   int     `json:"total_overshoot"`
+	TotalPacks       int     `json:"total_packs"`
+	AverageOvershoot float64 `json:"average_overshoot"`
+	AveragePacks     float64 `json:"average_packs"`
+}
+
+type OrderChangeResponse struct {
+	Items     int            `json:"items"`
+	Current   []PackResponse `json:"current"`
+	Candidate []PackResponse `json:"candidate"`
+}
+
+type PreviewResponse struct {
+	Orders     int                    `json:"orders"`
+	Current    PackSizeImpactResponse `json:"current"`
+	Candidate  PackSizeImpactResponse `json:"candidate"`
+	Changed    []OrderChangeResponse  `json:"changed"`
+	Violations []ViolationResponse    `json:"violations,omitempty"`
+}
+
+type DemandRequest struct {
+	Items     int `json:"items"`
+	Frequency int `json:"frequency"`
+}
+
+type RecommendConstraintsRequest struct {
+	Sizes   int `json:"sizes"`
+	MinSize int `json:"min_size"`
+	MaxSize int `json:"max_size"`
+}
+
+type RecommendRequest struct {
+	Demand      []DemandRequest             `json:"demand"`
+	Constraints RecommendConstraintsRequest `json:"constraints"`
+}
+
+type RecommendResponse struct {
+	Sizes       []int                  `json:"sizes"`
+	Impact      PackSizeImpactResponse `json:"impact"`
+	Evaluations int                    `json:"evaluations"`
+	Converged   bool                   `json:"converged"`
+}
+
+// ProblemContentType is the media type of Problem bodies.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 error body. Code is a stable identifier clients can
+// switch on; Type is derived from it.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+	// Violations lists every failed rule when a request breaks several.
+	Violations []ViolationResponse `json:"violations,omitempty"`
+}
+
+type ViolationResponse struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+)
+
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+type APIKeyRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type APIKeyResponse struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Role      string     `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// Key is only returned when the key is created.
+	Key string `json:"key,omitempty"`
+}
+
+type APIKeysResponse struct {
+	Keys []APIKeyResponse `json:"keys"`
+}
+
+type WebhookRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	// Active defaults to true when omitted.
+	Active *bool `json:"active"`
+}
+
+type WebhookResponse struct {
+	ID          int64     `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Secret is only returned when the subscription is created.
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhooksResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             int64           `json:"id"`
+	EventType      string          `json:"event_type"`
+	EventKey       string          `json:"event_key"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
 }
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/transport"
+	pkgerrors "pack-calculator/pkg/errors"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeys.ListKeys(r.Context())
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response := transport.APIKeysResponse{Keys: make([]transport.APIKeyResponse, len(keys))}
+	for i, key := range keys {
+		response.Keys[i] = apiKeyToResponse(key, "")
+	}
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req transport.APIKeyRequest
+	if err := decodeStrict(r, &req); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	created, plaintext, err := h.apiKeys.CreateKey(r.Context(), req.Name, req.Role)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, apiKeyToResponse(created, plaintext))
+}
+
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		h.writeError(w, http.StatusNotFound, pkgerrors.ErrNotFound)
+		return
+	}
+
+	if err := h.apiKeys.RevokeKey(r.Context(), id); err != nil {
+		h.handleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func apiKeyToResponse(key domain.APIKey, plaintext string) transport.APIKeyResponse {
+	return transport.APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Role:      key.Role,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+		Key:       plaintext,
+	}
+}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/transport"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+type mockAPIKeyService struct {
+	anonymousRole string
+	principals    map[string]domain.Principal
+	keys          []domain.APIKey
+}
+
+// newMockAPIKeyService knows a "reader-key" and an "admin-key" and grants
+// anonymousRole to callers without a key.
+func newMockAPIKeyService(anonymousRole string) *mockAPIKeyService {
+	return &mockAPIKeyService{
+		anonymousRole: anonymousRole,
+		principals: map[string]domain.Principal{
+			"reader-key": {Subject: "api-key:1", Role: domain.RoleReader},
+			"admin-key":  {Subject: "api-key:2", Role: domain.RoleAdmin},
+		},
+	}
+}
+
+func (m *mockAPIKeyService) Authenticate(ctx context.Context, key string) (domain.Principal, error) {
+	if key == "" && m.anonymousRole != "" {
+		return domain.Principal{Role: m.anonymousRole}, nil
+	}
+	principal, ok := m.principals[key]
+	if !ok {
+		return domain.Principal{}, pkgerrors.ErrUnauthorized
+	}
+	return principal, nil
+}
+
+func (m *mockAPIKeyService) CreateKey(ctx context.Context, name, role string) (domain.APIKey, string, error) {
+	if name == "" || !domain.ValidRole(role) {
+		return domain.APIKey{}, "", pkgerrors.ErrAPIKeyInvalid
+	}
+	key := domain.APIKey{ID: int64(len(m.keys) + 1), Name: name, Prefix: "pk_12345678", Role: role, CreatedAt: time.Now()}
+	m.keys = append(m.keys, key)
+	return key, "pk_1234567890", nil
+}
+
+func (m *mockAPIKeyService) ListKeys(ctx context.Context) ([]domain.APIKey, error) {
+	return m.keys, nil
+}
+
+func (m *mockAPIKeyService) RevokeKey(ctx context.Context, id int64) error {
+	for i := range m.keys {
+		if m.keys[i].ID == id && m.keys[i].RevokedAt == nil {
+			now := time.Now()
+			m.keys[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return pkgerrors.ErrNotFound
+}
+
+func TestSetupRoutes_RoleBasedAccess(t *testing.T) {
+	router := SetupRoutes(NewHandler(&mockPackService{
+		getPackSizesFunc: func() ([]int, error) { return []int{250}, nil },
+		calculatePacksFunc: func(items int) ([]domain.Pack, error) {
+			return []domain.Pack{{Size: 250, Quantity: 1}}, nil
+		},
+	}).WithAPIKeys(newMockAPIKeyService(domain.RoleReader)))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		key        string
+		wantStatus int
+	}{
+		{"anonymous reads", "GET", "/api/v1/pack-sizes", "", "", http.StatusOK},
+		{"anonymous calculates", "POST", "/api/v1/calculate", `{"items":1}`, "", http.StatusOK},
+		{"anonymous update needs a key", "POST", "/api/v1/pack-sizes", `{"sizes":[250]}`, "", http.StatusUnauthorized},
+		{"unknown key", "GET", "/api/v1/pack-sizes", "", "nope", http.StatusUnauthorized},
+		{"reader cannot update", "POST", "/api/v1/pack-sizes", `{"sizes":[250]}`, "reader-key", http.StatusForbidden},
+		{"reader cannot manage keys", "GET", "/api/v1/api-keys", "", "reader-key", http.StatusForbidden},
+		{"forbidden before validation", "POST", "/api/v1/pack-sizes", `{"sizes":"x"}`, "reader-key", http.StatusForbidden},
+		{"admin updates", "POST", "/api/v1/pack-sizes", `{"sizes":[250]}`, "admin-key", http.StatusNoContent},
+		{"admin reads", "GET", "/api/v1/pack-sizes", "", "admin-key", http.StatusOK},
+		{"legacy routes are protected too", "POST", "/api/pack-sizes", `{"sizes":[250]}`, "reader-key", http.StatusForbidden},
+		{"anonymous debug vars", "GET", "/debug/vars", "", "", http.StatusUnauthorized},
+		{"reader debug vars", "GET", "/debug/vars", "", "reader-key", http.StatusForbidden},
+		{"admin debug vars", "GET", "/debug/vars", "", "admin-key", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code >= http.StatusBadRequest {
+				var problem transport.Problem
+				if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+					t.Fatalf("invalid body: %v", err)
+				}
+				want := map[int]string{http.StatusUnauthorized: pkgerrors.CodeUnauthorized, http.StatusForbidden: pkgerrors.CodeForbidden}[tt.wantStatus]
+				if problem.Code != want {
+					t.Errorf("code = %q, want %q", problem.Code, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetupRoutes_AnonymousAccessDisabled(t *testing.T) {
+	router := SetupRoutes(NewHandler(&mockPackService{}).WithAPIKeys(newMockAPIKeyService("")))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/pack-sizes", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/openapi.json", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("openapi.json status = %d, want %d without anonymous access", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandler_APIKeyLifecycle(t *testing.T) {
+	keys := newMockAPIKeyService("")
+	router := SetupRoutes(NewHandler(&mockPackService{}).WithAPIKeys(keys))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set(APIKeyHeader, "admin-key")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("POST", "/api/v1/api-keys", `{"name":"ci","role":"reader"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", w.Code, w.Body.String())
+	}
+	var created transport.APIKeyResponse
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.Key == "" || created.Role != domain.RoleReader {
+		t.Errorf("created = %+v, want the key returned once", created)
+	}
+
+	w = do("GET", "/api/v1/api-keys", "")
+	var list transport.APIKeysResponse
+	json.NewDecoder(w.Body).Decode(&list)
+	if len(list.Keys) != 1 || list.Keys[0].Key != "" {
+		t.Errorf("list = %+v, want one key without its secret", list)
+	}
+
+	if w := do("POST", "/api/v1/api-keys", `{"name":"ci","role":"owner"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid role status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := do("DELETE", "/api/v1/api-keys/1", ""); w.Code != http.StatusNoContent {
+		t.Errorf("revoke status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w := do("DELETE", "/api/v1/api-keys/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("second revoke status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
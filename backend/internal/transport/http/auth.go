@@ -0,0 +1,105 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// APIKeyHeader carries the caller's API key.
+const APIKeyHeader = "X-API-Key"
+
+// bearerChallenge is sent with 401 responses when bearer tokens are accepted.
+const bearerChallenge = `Bearer realm="pack-calculator"`
+
+func (h *Handler) authEnabled() bool {
+	return h.apiKeys != nil || h.bearer != nil
+}
+
+// authenticate resolves the caller from its bearer token or API key, and
+// attaches it to the request context for the services to audit. Without
+// any authenticator configured it does nothing and every route is open.
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.authEnabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := h.principal(r)
+		if err != nil {
+			// Rejected credentials count against the client IP, so guessing
+			// keys or tokens is rate limited like anonymous use.
+			reject := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { h.handleAuthError(w, err) })
+			if h.rateLimiter != nil {
+				h.rateLimiter.Limit(reject).ServeHTTP(w, r)
+				return
+			}
+			reject(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(domain.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// principal prefers a bearer token. A request with neither credential is
+// anonymous, which only the API key service knows how to treat.
+func (h *Handler) principal(r *http.Request) (domain.Principal, error) {
+	if token, ok := bearerToken(r); ok {
+		if h.bearer == nil {
+			return domain.Principal{}, pkgerrors.ErrUnauthorized
+		}
+		return h.bearer.Authenticate(r.Context(), token)
+	}
+	if h.apiKeys == nil {
+		return domain.Principal{}, pkgerrors.ErrUnauthorized
+	}
+	return h.apiKeys.Authenticate(r.Context(), r.Header.Get(APIKeyHeader))
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// requireRole rejects callers whose role does not include role with 403.
+func (h *Handler) requireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !h.authEnabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, ok := domain.PrincipalFrom(r.Context())
+			if !ok {
+				h.handleAuthError(w, pkgerrors.ErrUnauthorized)
+				return
+			}
+			if !principal.Allows(role) {
+				// Anonymous callers may retry with a key; others may not.
+				if principal.Subject == "" {
+					h.handleAuthError(w, pkgerrors.ErrUnauthorized)
+					return
+				}
+				h.handleError(w, pkgerrors.ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// handleAuthError challenges for a bearer token on 401 when tokens are
+// accepted.
+func (h *Handler) handleAuthError(w http.ResponseWriter, err error) {
+	if h.bearer != nil && errorStatus(err) == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", bearerChallenge)
+	}
+	h.handleError(w, err)
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// mockBearerAuth knows a "reader-token" and an "admin-token".
+type mockBearerAuth struct{}
+
+func (mockBearerAuth) Authenticate(ctx context.Context, token string) (domain.Principal, error) {
+	switch token {
+	case "reader-token":
+		return domain.Principal{Subject: "jwt:bob", Role: domain.RoleReader, Tenant: "acme"}, nil
+	case "admin-token":
+		return domain.Principal{Subject: "jwt:alice", Role: domain.RoleAdmin, Tenant: "acme"}, nil
+	case "roleless-token":
+		return domain.Principal{Subject: "jwt:carol"}, nil
+	}
+	return domain.Principal{}, pkgerrors.ErrUnauthorized
+}
+
+func TestSetupRoutes_BearerTokens(t *testing.T) {
+	packService := &mockPackService{}
+	router := SetupRoutes(NewHandler(packService).
+		WithAPIKeys(newMockAPIKeyService(domain.RoleReader)).
+		WithBearerAuth(mockBearerAuth{}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantChallenge bool
+	}{
+		{"admin token updates", "Bearer admin-token", http.StatusNoContent, false},
+		{"scheme is case-insensitive", "bearer admin-token", http.StatusNoContent, false},
+		{"reader token is forbidden", "Bearer reader-token", http.StatusForbidden, false},
+		{"token without a role is forbidden", "Bearer roleless-token", http.StatusForbidden, false},
+		{"invalid token is challenged", "Bearer forged", http.StatusUnauthorized, true},
+		{"invalid token does not fall back to anonymous", "Bearer ", http.StatusUnauthorized, true},
+		{"anonymous caller is challenged", "", http.StatusUnauthorized, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/pack-sizes", bytes.NewBufferString(`{"sizes":[250]}`))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get("WWW-Authenticate") != ""; got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want challenge %v", w.Header().Get("WWW-Authenticate"), tt.wantChallenge)
+			}
+		})
+	}
+}
+
+func TestSetupRoutes_PrincipalReachesService(t *testing.T) {
+	packService := &mockPackService{}
+	router := SetupRoutes(NewHandler(packService).
+		WithAPIKeys(newMockAPIKeyService(domain.RoleReader)).
+		WithBearerAuth(mockBearerAuth{}))
+
+	req := httptest.NewRequest("POST", "/api/v1/pack-sizes", bytes.NewBufferString(`{"sizes":[250]}`))
+	req.Header.Set("Authorization", "Bearer admin-token")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	principal, ok := domain.PrincipalFrom(packService.ctx)
+	if !ok || principal.Subject != "jwt:alice" || principal.Tenant != "acme" {
+		t.Errorf("principal = %+v, %v, want jwt:alice of acme", principal, ok)
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIP finds the address of the client behind trusted reverse proxies.
+type ClientIP struct {
+	trusted []netip.Prefix
+}
+
+// NewClientIP trusts X-Forwarded-For only when added by proxies in trusted.
+// With none trusted, the connection's peer is the client.
+func NewClientIP(trusted []netip.Prefix) ClientIP {
+	return ClientIP{trusted: trusted}
+}
+
+// Of walks X-Forwarded-For from the nearest hop back while hops are trusted
+// proxies; the first untrusted hop is the client. Entries before it may be
+// forged by the client and are never used.
+func (c ClientIP) Of(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	addr = addr.Unmap()
+
+	hops := forwardedFor(r)
+	for c.isTrusted(addr) && len(hops) > 0 {
+		hop, err := netip.ParseAddr(hops[len(hops)-1])
+		if err != nil {
+			break
+		}
+		addr, hops = hop.Unmap(), hops[:len(hops)-1]
+	}
+	return addr.String()
+}
+
+func (c ClientIP) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range c.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor returns the X-Forwarded-For hops, farthest first, across
+// repeated headers.
+func forwardedFor(r *http.Request) []string {
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
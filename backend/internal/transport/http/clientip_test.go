@@ -0,0 +1,74 @@
+package http
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP_Of(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		trusted    []netip.Prefix
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{
+			name:       "no proxies trusted",
+			remoteAddr: "10.0.0.2:4000",
+			forwarded:  []string{"203.0.113.7"},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "behind a trusted proxy",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:4000",
+			forwarded:  []string{"203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "forged entries before the client are ignored",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:4000",
+			forwarded:  []string{"198.51.100.1, 203.0.113.7", "10.0.0.3"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "untrusted peer",
+			trusted:    trusted,
+			remoteAddr: "192.0.2.9:4000",
+			forwarded:  []string{"203.0.113.7"},
+			want:       "192.0.2.9",
+		},
+		{
+			name:       "unparseable hop stops the walk",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:4000",
+			forwarded:  []string{"203.0.113.7, unknown"},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "ipv4-mapped peer",
+			trusted:    trusted,
+			remoteAddr: "[::ffff:10.0.0.2]:4000",
+			forwarded:  []string{"203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if got := NewClientIP(tt.trusted).Of(req); got != tt.want {
+				t.Errorf("Of() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions is the cross-origin policy of the API.
+type CORSOptions struct {
+	// AllowedOrigins are scheme://host[:port] origins; "https://*.example.com"
+	// matches any subdomain of example.com but not example.com itself, and
+	// "*" matches every origin. Empty disables CORS.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are response headers scripts may read besides the
+	// CORS-safelisted ones.
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight; zero omits it.
+	MaxAge time.Duration
+}
+
+func DefaultCORSOptions() CORSOptions {
+	return CORSOptions{
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", APIKeyHeader, IdempotencyKeyHeader},
+		ExposedHeaders: []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", "Deprecation", "Sunset", "Link", IdempotentReplayedHeader},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// originPattern matches an exact origin, or with a wildcard any origin with
+// the same scheme and port on a subdomain of the host.
+type originPattern struct {
+	prefix, suffix string
+	wildcard       bool
+}
+
+func newOriginPattern(origin string) originPattern {
+	origin = strings.ToLower(origin)
+	if scheme, rest, ok := strings.Cut(origin, "://*."); ok {
+		return originPattern{prefix: scheme + "://", suffix: "." + rest, wildcard: true}
+	}
+	return originPattern{prefix: origin}
+}
+
+func (p originPattern) matches(origin string) bool {
+	if !p.wildcard {
+		return origin == p.prefix
+	}
+	if len(origin) <= len(p.prefix)+len(p.suffix) || !strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+	// The subdomain must not smuggle in a path, port or credentials.
+	sub := origin[len(p.prefix) : len(origin)-len(p.suffix)]
+	return !strings.ContainsAny(sub, "/:@") && !strings.HasPrefix(sub, ".")
+}
+
+// CORS applies options to cross-origin requests. Responses from allowed
+// origins name that origin, so every response varies by Origin; preflights
+// are answered only for allowed origins, and others reach the router.
+func CORS(options CORSOptions) func(http.Handler) http.Handler {
+	anyOrigin := false
+	patterns := make([]originPattern, 0, len(options.AllowedOrigins))
+	for _, origin := range options.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
+			continue
+		}
+		patterns = append(patterns, newOriginPattern(origin))
+	}
+	allowed := func(origin string) bool {
+		if anyOrigin {
+			return true
+		}
+		origin = strings.ToLower(origin)
+		for _, pattern := range patterns {
+			if pattern.matches(origin) {
+				return true
+			}
+		}
+		return false
+	}
+
+	methods := strings.Join(options.AllowedMethods, ", ")
+	headers := strings.Join(options.AllowedHeaders, ", ")
+	exposed := strings.Join(options.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(options.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		if len(options.AllowedOrigins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			// A wildcard answer without credentials is the same for every
+			// origin; anything else must not be served from a shared cache
+			// to another origin.
+			reflect := !anyOrigin || options.AllowCredentials
+			if reflect {
+				h.Add("Vary", "Origin")
+			}
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+			if origin == "" || !allowed(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if reflect {
+				h.Set("Access-Control-Allow-Origin", origin)
+			} else {
+				h.Set("Access-Control-Allow-Origin", "*")
+			}
+			if options.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if preflight {
+				h.Set("Access-Control-Allow-Methods", methods)
+				if headers != "" {
+					h.Set("Access-Control-Allow-Headers", headers)
+				}
+				if options.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if exposed != "" {
+				h.Set("Access-Control-Expose-Headers", exposed)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func corsRouter(configure func(*CORSOptions)) http.Handler {
+	options := DefaultCORSOptions()
+	options.AllowedOrigins = []string{"https://app.example.com", "https://*.example.org"}
+	if configure != nil {
+		configure(&options)
+	}
+	return SetupRoutes(NewHandler(&mockPackService{}).WithCORS(options))
+}
+
+func TestCORS_OriginMatching(t *testing.T) {
+	router := corsRouter(nil)
+
+	tests := []struct {
+		origin    string
+		wantAllow string
+	}{
+		{origin: "https://app.example.com", wantAllow: "https://app.example.com"},
+		{origin: "https://APP.example.com", wantAllow: "https://APP.example.com"},
+		{origin: "http://app.example.com"},
+		{origin: "https://app.example.com:8443"},
+		{origin: "https://evil.com"},
+		{origin: "https://shop.example.org", wantAllow: "https://shop.example.org"},
+		{origin: "https://eu.shop.example.org", wantAllow: "https://eu.shop.example.org"},
+		{origin: "https://example.org"},
+		{origin: "https://evilexample.org"},
+		{origin: "https://x.example.org.evil.com"},
+		{origin: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/pack-sizes", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllow)
+			}
+			if got := w.Header().Get("Vary"); got != "Origin" {
+				t.Errorf("Vary = %q, want Origin", got)
+			}
+			if exposed := w.Header().Get("Access-Control-Expose-Headers"); (exposed != "") != (tt.wantAllow != "") {
+				t.Errorf("Access-Control-Expose-Headers = %q", exposed)
+			}
+		})
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	router := corsRouter(func(o *CORSOptions) { o.AllowCredentials = true })
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("OPTIONS", "/api/v1/calculate", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "content-type")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := preflight("https://app.example.com")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST, PUT, DELETE",
+		"Access-Control-Allow-Headers":     "Content-Type, Authorization, X-API-Key, Idempotency-Key",
+		"Access-Control-Max-Age":           "600",
+	}
+	for header, value := range want {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+	if got := w.Header().Values("Vary"); len(got) != 3 {
+		t.Errorf("Vary = %v, want Origin and the request headers", got)
+	}
+
+	// A preflight from another origin is not answered.
+	w = preflight("https://evil.com")
+	if w.Code == http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed preflight status = %d, headers = %v", w.Code, w.Header())
+	}
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	router := corsRouter(func(o *CORSOptions) { o.AllowedOrigins = []string{"*"} })
+
+	req := httptest.NewRequest("GET", "/api/v1/pack-sizes", nil)
+	req.Header.Set("Origin", "https://anywhere.example")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := w.Header().Get("Vary"); got != "" {
+		t.Errorf("Vary = %q, want none for a wildcard answer", got)
+	}
+}
+
+func TestCORS_DisabledWithoutOrigins(t *testing.T) {
+	router := SetupRoutes(NewHandler(&mockPackService{}))
+
+	req := httptest.NewRequest("GET", "/api/v1/pack-sizes", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
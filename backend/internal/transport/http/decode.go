@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// decodeStrict decodes a JSON request body into v, rejecting unknown fields
+// and anything after the first JSON value. Failures are *ViolationsError
+// matching ErrInvalidInput.
+func decodeStrict(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return pkgerrors.Violations(pkgerrors.ErrInvalidInput, []pkgerrors.FieldViolation{decodeViolation(err)})
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return pkgerrors.Violations(pkgerrors.ErrInvalidInput, []pkgerrors.FieldViolation{{
+			Rule:    "syntax",
+			Message: "unexpected data after the JSON value",
+		}})
+	}
+	return nil
+}
+
+func decodeViolation(err error) pkgerrors.FieldViolation {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := ""
+		if typeErr.Field != "" {
+			for _, name := range strings.Split(typeErr.Field, ".") {
+				field += pkgerrors.Pointer(name)
+			}
+		}
+		return pkgerrors.FieldViolation{Field: field, Rule: "type", Message: "must be " + jsonTypeName(typeErr.Type)}
+	}
+
+	// encoding/json has no typed error for unknown fields.
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return pkgerrors.FieldViolation{
+			Field:   pkgerrors.Pointer(strings.Trim(name, `"`)),
+			Rule:    "additionalProperties",
+			Message: "is not allowed",
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		return pkgerrors.FieldViolation{Rule: "syntax", Message: "request body is empty"}
+	}
+	return pkgerrors.FieldViolation{Rule: "syntax", Message: "is not valid JSON"}
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	}
+	return "an object"
+}
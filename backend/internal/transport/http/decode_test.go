@@ -0,0 +1,78 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/transport"
+)
+
+func TestHandler_CalculatePacks_StrictDecoding(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+		wantRule  string
+	}{
+		{"missing items", `{}`, "/items", "required"},
+		{"zero items", `{"items":0}`, "/items", "minimum"},
+		{"unknown field", `{"items":1,"itmes":2}`, "/itmes", "additionalProperties"},
+		{"wrong type", `{"items":"1"}`, "/items", "type"},
+		{"trailing data", `{"items":1}{"items":2}`, "", "syntax"},
+		{"empty body", ``, "", "syntax"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := NewHandler(&mockPackService{
+				calculatePacksFunc: func(items int) ([]domain.Pack, error) {
+					called = true
+					return nil, nil
+				},
+			})
+			req := httptest.NewRequest("POST", "/api/v1/calculate", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.CalculatePacks(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			if called {
+				t.Error("service called with an invalid request")
+			}
+			var problem transport.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("invalid body: %v", err)
+			}
+			if len(problem.Violations) != 1 || problem.Violations[0].Field != tt.wantField || problem.Violations[0].Rule != tt.wantRule {
+				t.Errorf("violations = %+v, want %s %s", problem.Violations, tt.wantField, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestHandler_UpdatePackSizes_ReportsEveryViolation(t *testing.T) {
+	handler := NewHandler(&mockPackService{})
+	req := httptest.NewRequest("POST", "/api/v1/pack-sizes", bytes.NewBufferString(`{"sizes":[250,-1,250]}`))
+	w := httptest.NewRecorder()
+
+	handler.UpdatePackSizes(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	var problem transport.Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if problem.Code != "invalid_input" || len(problem.Violations) != 2 ||
+		problem.Violations[0].Field != "/sizes/1" || problem.Violations[1].Field != "/sizes/2" {
+		t.Errorf("problem = %+v", problem)
+	}
+}
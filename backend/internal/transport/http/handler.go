@@ -1,28 +1,99 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
 
 	"pack-calculator/internal/app"
 	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
 	"pack-calculator/internal/transport"
 	pkgerrors "pack-calculator/pkg/errors"
 )
 
+const healthCheckTimeout = 2 * time.Second
+
 type Handler struct {
-	packService app.PackServiceInterface
+	packService  app.PackServiceInterface
+	healthChecks []ports.HealthChecker
+	webhooks     app.WebhookServiceInterface
+	recommender  app.RecommenderInterface
+	deprecation  Deprecation
+	apiKeys      app.APIKeyServiceInterface
+	bearer       app.Authenticator
+	rateLimiter  *RateLimiter
+	cors         CORSOptions
+	idempotency  *Idempotency
 }
 
-func NewHandler(packService app.PackServiceInterface) *Handler {
+// NewHandler creates the HTTP handler. Health checks are reported by the
+// health endpoint; a failing check marks the service as degraded but does not
+// make it unhealthy, since every checked dependency is optional.
+func NewHandler(packService app.PackServiceInterface, healthChecks ...ports.HealthChecker) *Handler {
 	return &Handler{
-		packService: packService,
+		packService:  packService,
+		healthChecks: healthChecks,
 	}
 }
 
+// WithWebhooks enables the webhook subscription endpoints.
+func (h *Handler) WithWebhooks(webhooks app.WebhookServiceInterface) *Handler {
+	h.webhooks = webhooks
+	return h
+}
+
+// WithRecommender enables the pack-size recommendation endpoint.
+func (h *Handler) WithRecommender(recommender app.RecommenderInterface) *Handler {
+	h.recommender = recommender
+	return h
+}
+
+// WithAPIKeys makes every API route require a role, authenticating callers
+// by API key, and enables the key management endpoints.
+func (h *Handler) WithAPIKeys(apiKeys app.APIKeyServiceInterface) *Handler {
+	h.apiKeys = apiKeys
+	return h
+}
+
+// WithBearerAuth also authenticates callers by an Authorization: Bearer
+// token, verified by bearer, and makes every API route require a role.
+func (h *Handler) WithBearerAuth(bearer app.Authenticator) *Handler {
+	h.bearer = bearer
+	return h
+}
+
+// WithRateLimiter limits API requests per caller.
+func (h *Handler) WithRateLimiter(rateLimiter *RateLimiter) *Handler {
+	h.rateLimiter = rateLimiter
+	return h
+}
+
+// WithIdempotency replays responses to retried POST /pack-sizes and
+// /calculate requests that carry an Idempotency-Key.
+func (h *Handler) WithIdempotency(idempotency *Idempotency) *Handler {
+	h.idempotency = idempotency
+	return h
+}
+
+// WithCORS sets the cross-origin policy; without it cross-origin requests
+// get no CORS headers.
+func (h *Handler) WithCORS(options CORSOptions) *Handler {
+	h.cors = options
+	return h
+}
+
+// WithDeprecation sets the Deprecation and Sunset headers of the legacy
+// unversioned routes.
+func (h *Handler) WithDeprecation(deprecation Deprecation) *Handler {
+	h.deprecation = deprecation
+	return h
+}
+
 func (h *Handler) GetPackSizes(w http.ResponseWriter, r *http.Request) {
-	sizes, err := h.packService.GetPackSizes()
+	sizes, err := h.packService.GetPackSizes(r.Context())
 	if err != nil {
 		h.handleError(w, err)
 		return
@@ -34,12 +105,16 @@ func (h *Handler) GetPackSizes(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) UpdatePackSizes(w http.ResponseWriter, r *http.Request) {
 	var req transport.UpdatePackSizesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, pkgerrors.ErrInvalidInput)
+	if err := decodeStrict(r, &req); err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		h.handleError(w, err)
 		return
 	}
 
-	if err := h.packService.UpdatePackSizes(req.Sizes); err != nil {
+	if err := h.packService.UpdatePackSizes(r.Context(), req.Sizes); err != nil {
 		h.handleError(w, err)
 		return
 	}
@@ -49,12 +124,16 @@ func (h *Handler) UpdatePackSizes(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) CalculatePacks(w http.ResponseWriter, r *http.Request) {
 	var req transport.CalculateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, pkgerrors.ErrInvalidInput)
+	if err := decodeStrict(r, &req); err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		h.handleError(w, err)
 		return
 	}
 
-	packs, err := h.packService.CalculatePacks(req.Items)
+	packs, err := h.packService.CalculatePacks(r.Context(), *req.Items)
 	if err != nil {
 		h.handleError(w, err)
 		return
@@ -67,6 +146,90 @@ func (h *Handler) CalculatePacks(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, response)
 }
 
+func (h *Handler) PreviewPackSizes(w http.ResponseWriter, r *http.Request) {
+	var req transport.PreviewRequest
+	if err := decodeStrict(r, &req); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	preview, err := h.packService.PreviewPackSizes(r.Context(), req.Sizes, req.Orders)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response := transport.PreviewResponse{
+		Orders:    len(req.Orders),
+		Current:   impactToResponse(preview.Current),
+		Candidate: impactToResponse(preview.Candidate),
+		Changed:   make([]transport.OrderChangeResponse, len(preview.Changed)),
+	}
+	for i, c := range preview.Changed {
+		response.Changed[i] = transport.OrderChangeResponse{
+			Items:     c.Items,
+			Current:   h.domainPacksToResponse(c.Current),
+			Candidate: h.domainPacksToResponse(c.Candidate),
+		}
+	}
+	for _, v := range preview.Violations {
+		response.Violations = append(response.Violations, transport.ViolationResponse{Field: v.Field, Rule: v.Rule, Message: v.Message})
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) RecommendPackSizes(w http.ResponseWriter, r *http.Request) {
+	var req transport.RecommendRequest
+	if err := decodeStrict(r, &req); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	demand := make([]app.Demand, len(req.Demand))
+	for i, d := range req.Demand {
+		demand[i] = app.Demand{Items: d.Items, Frequency: d.Frequency}
+	}
+	constraints := app.RecommendConstraints{
+		Sizes:   req.Constraints.Sizes,
+		MinSize: req.Constraints.MinSize,
+		MaxSize: req.Constraints.MaxSize,
+	}
+
+	recommendation, err := h.recommender.Recommend(r.Context(), demand, constraints)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, transport.RecommendResponse{
+		Sizes:       nonNilSizes(recommendation.Sizes),
+		Impact:      impactToResponse(recommendation.Impact),
+		Evaluations: recommendation.Evaluations,
+		Converged:   recommendation.Converged,
+	})
+}
+
+func impactToResponse(impact app.PackSizeImpact) transport.PackSizeImpactResponse {
+	return transport.PackSizeImpactResponse{
+		Sizes:            nonNilSizes(impact.Sizes),
+		TotalItems:       impact.TotalItems,
+		TotalShipped:     impact.TotalShipped,
+		TotalOvershoot:   impact.TotalOvershoot,
+		TotalPacks:       impact.TotalPacks,
+		AverageOvershoot: impact.AverageOvershoot,
+		AveragePacks:     impact.AveragePacks,
+	}
+}
+
+// nonNilSizes keeps empty size lists encoding as [] rather than null.
+func nonNilSizes(sizes []int) []int {
+	if sizes == nil {
+		return []int{}
+	}
+	return sizes
+}
+
 func (h *Handler) domainPacksToResponse(packs []domain.Pack) []transport.PackResponse {
 	result := make([]transport.PackResponse, len(packs))
 	for i, p := range packs {
@@ -85,12 +248,23 @@ func (h *Handler) writeJSON(w http.ResponseWriter, status int, data interface{})
 }
 
 func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	h.writeError(w, errorStatus(err), err)
+}
+
+func errorStatus(err error) int {
 	var status int
 
 	switch {
 	case errors.Is(err, pkgerrors.ErrNotFound):
 		status = http.StatusNotFound
-	case errors.Is(err, pkgerrors.ErrInvalidInput) || errors.Is(err, pkgerrors.ErrPackSizesEmpty) || errors.Is(err, pkgerrors.ErrItemsInvalid) || errors.Is(err, pkgerrors.ErrPackSizeOutOfRange) || errors.Is(err, pkgerrors.ErrItemsOutOfRange) || errors.Is(err, pkgerrors.ErrDuplicatePackSizes):
+	case errors.Is(err, pkgerrors.ErrUnauthorized):
+		status = http.StatusUnauthorized
+	case errors.Is(err, pkgerrors.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, pkgerrors.ErrPolicyViolation):
+		// Rule failures get 422 so clients can tell them from malformed input.
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, pkgerrors.ErrInvalidInput) || errors.Is(err, pkgerrors.ErrPackSizesEmpty) || errors.Is(err, pkgerrors.ErrItemsInvalid) || errors.Is(err, pkgerrors.ErrPackSizeOutOfRange) || errors.Is(err, pkgerrors.ErrItemsOutOfRange) || errors.Is(err, pkgerrors.ErrDuplicatePackSizes) || errors.Is(err, pkgerrors.ErrWebhookURLInvalid) || errors.Is(err, pkgerrors.ErrWebhookEventsInvalid) || errors.Is(err, pkgerrors.ErrPreviewOrdersInvalid) || errors.Is(err, pkgerrors.ErrDemandInvalid) || errors.Is(err, pkgerrors.ErrConstraintsInvalid) || errors.Is(err, pkgerrors.ErrAPIKeyInvalid):
 		status = http.StatusBadRequest
 	case errors.Is(err, pkgerrors.ErrRepository) || errors.Is(err, pkgerrors.ErrCache):
 		status = http.StatusInternalServerError
@@ -98,17 +272,29 @@ func (h *Handler) handleError(w http.ResponseWriter, err error) {
 		status = http.StatusInternalServerError
 	}
 
-	h.writeError(w, status, err)
+	return status
 }
 
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	response := transport.HealthResponse{Status: transport.HealthStatusOK}
+	if len(h.healthChecks) > 0 {
+		response.Checks = make(map[string]string, len(h.healthChecks))
+	}
+	for _, check := range h.healthChecks {
+		if err := check.Check(ctx); err != nil {
+			response.Status = transport.HealthStatusDegraded
+			response.Checks[check.Name()] = err.Error()
+			continue
+		}
+		response.Checks[check.Name()] = transport.HealthStatusOK
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
 }
 
 func (h *Handler) writeError(w http.ResponseWriter, status int, err error) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(transport.ErrorResponse{Error: err.Error()})
+	writeProblem(w, status, err)
 }
@@ -2,11 +2,15 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"pack-calculator/internal/app"
 	"pack-calculator/internal/domain"
 	pkgerrors "pack-calculator/pkg/errors"
 )
@@ -15,29 +19,43 @@ type mockPackService struct {
 	getPackSizesFunc    func() ([]int, error)
 	updatePackSizesFunc func(sizes []int) error
 	calculatePacksFunc  func(items int) ([]domain.Pack, error)
+	previewFunc         func(candidate, orders []int) (app.PackSizePreview, error)
+	// ctx is the context of the most recent call.
+	ctx context.Context
 }
 
-func (m *mockPackService) GetPackSizes() ([]int, error) {
+func (m *mockPackService) GetPackSizes(ctx context.Context) ([]int, error) {
+	m.ctx = ctx
 	if m.getPackSizesFunc != nil {
 		return m.getPackSizesFunc()
 	}
 	return nil, nil
 }
 
-func (m *mockPackService) UpdatePackSizes(sizes []int) error {
+func (m *mockPackService) UpdatePackSizes(ctx context.Context, sizes []int) error {
+	m.ctx = ctx
 	if m.updatePackSizesFunc != nil {
 		return m.updatePackSizesFunc(sizes)
 	}
 	return nil
 }
 
-func (m *mockPackService) CalculatePacks(items int) ([]domain.Pack, error) {
+func (m *mockPackService) CalculatePacks(ctx context.Context, items int) ([]domain.Pack, error) {
+	m.ctx = ctx
 	if m.calculatePacksFunc != nil {
 		return m.calculatePacksFunc(items)
 	}
 	return nil, nil
 }
 
+func (m *mockPackService) PreviewPackSizes(ctx context.Context, candidate []int, orders []int) (app.PackSizePreview, error) {
+	m.ctx = ctx
+	if m.previewFunc != nil {
+		return m.previewFunc(candidate, orders)
+	}
+	return app.PackSizePreview{}, nil
+}
+
 func TestHandler_GetPackSizes(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -265,6 +283,37 @@ func TestHandler_CalculatePacks(t *testing.T) {
 	}
 }
 
+func TestHandler_PropagatesRequestContext(t *testing.T) {
+	service := &mockPackService{}
+	handler := NewHandler(service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	requests := []struct {
+		name   string
+		method string
+		body   string
+		serve  http.HandlerFunc
+	}{
+		{"get pack sizes", "GET", "", handler.GetPackSizes},
+		{"update pack sizes", "PUT", `{"sizes":[250]}`, handler.UpdatePackSizes},
+		{"calculate", "POST", `{"items":1}`, handler.CalculatePacks},
+	}
+
+	for _, tt := range requests {
+		t.Run(tt.name, func(t *testing.T) {
+			service.ctx = nil
+			req := httptest.NewRequest(tt.method, "/", bytes.NewBufferString(tt.body)).WithContext(ctx)
+			tt.serve(httptest.NewRecorder(), req)
+
+			if service.ctx == nil || service.ctx.Err() == nil {
+				t.Error("service did not receive the cancelled request context")
+			}
+		})
+	}
+}
+
 func TestHandler_Health(t *testing.T) {
 	handler := NewHandler(&mockPackService{})
 	req := httptest.NewRequest("GET", "/health", nil)
@@ -286,6 +335,209 @@ func TestHandler_Health(t *testing.T) {
 	}
 }
 
+type stubHealthCheck struct {
+	name string
+	err  error
+}
+
+func (s stubHealthCheck) Name() string                    { return s.name }
+func (s stubHealthCheck) Check(ctx context.Context) error { return s.err }
+
+func TestHandler_HealthDegraded(t *testing.T) {
+	handler := NewHandler(&mockPackService{}, stubHealthCheck{name: "cache", err: pkgerrors.ErrCache})
+	req := httptest.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+
+	handler.Health(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Health() status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Health() invalid JSON response: %v", err)
+	}
+	if response.Status != "degraded" {
+		t.Errorf("Health() status = %v, want degraded", response.Status)
+	}
+	if response.Checks["cache"] == "" || response.Checks["cache"] == "ok" {
+		t.Errorf("Health() cache check = %q, want error detail", response.Checks["cache"])
+	}
+}
+
+func TestHandler_UpdatePackSizes_PolicyViolations(t *testing.T) {
+	handler := NewHandler(&mockPackService{
+		updatePackSizesFunc: func(sizes []int) error {
+			return &pkgerrors.ViolationsError{Violations: []pkgerrors.FieldViolation{
+				{Field: "sizes", Rule: "max_sizes", Message: "too many"},
+				{Field: "/sizes/1", Rule: "no_multiples", Message: "500 is a multiple of 250"},
+			}}
+		},
+	})
+	req := httptest.NewRequest("POST", "/api/pack-sizes", bytes.NewBufferString(`{"sizes":[250,500]}`))
+	w := httptest.NewRecorder()
+
+	handler.UpdatePackSizes(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	var body struct {
+		Violations []struct {
+			Field string `json:"field"`
+			Rule  string `json:"rule"`
+		} `json:"violations"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if len(body.Violations) != 2 || body.Violations[1].Field != "/sizes/1" || body.Violations[1].Rule != "no_multiples" {
+		t.Errorf("violations = %+v", body.Violations)
+	}
+}
+
+func TestHandler_PreviewPackSizes(t *testing.T) {
+	var gotCandidate, gotOrders []int
+	handler := NewHandler(&mockPackService{
+		previewFunc: func(candidate, orders []int) (app.PackSizePreview, error) {
+			gotCandidate, gotOrders = candidate, orders
+			return app.PackSizePreview{
+				Current:   app.PackSizeImpact{Sizes: []int{250}, AverageOvershoot: 249},
+				Candidate: app.PackSizeImpact{Sizes: []int{1, 250}},
+				Changed: []app.OrderChange{{
+					Items:     1,
+					Current:   []domain.Pack{{Size: 250, Quantity: 1}},
+					Candidate: []domain.Pack{{Size: 1, Quantity: 1}},
+				}},
+			}, nil
+		},
+	})
+	req := httptest.NewRequest("POST", "/api/pack-sizes/preview", bytes.NewBufferString(`{"sizes":[1,250],"orders":[1]}`))
+	w := httptest.NewRecorder()
+
+	handler.PreviewPackSizes(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if len(gotCandidate) != 2 || len(gotOrders) != 1 {
+		t.Errorf("service got sizes %v and orders %v", gotCandidate, gotOrders)
+	}
+	var body map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&body)
+	if body["orders"] != 1.0 || body["current"].(map[string]interface{})["average_overshoot"] != 249.0 {
+		t.Errorf("body = %v", body)
+	}
+	if changed := body["changed"].([]interface{}); len(changed) != 1 {
+		t.Errorf("changed = %v, want one order", changed)
+	}
+}
+
+func TestHandler_PreviewPackSizesRejectsUnknownFields(t *testing.T) {
+	handler := NewHandler(&mockPackService{})
+	req := httptest.NewRequest("POST", "/api/pack-sizes/preview", bytes.NewBufferString(`{"sizes":[250],"orderz":[1]}`))
+	w := httptest.NewRecorder()
+
+	handler.PreviewPackSizes(w, req)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"/orderz"`) {
+		t.Errorf("response = %d %s, want 400 naming /orderz", w.Code, w.Body)
+	}
+}
+
+type stubRecommender struct {
+	demand      []app.Demand
+	constraints app.RecommendConstraints
+}
+
+func (s *stubRecommender) Recommend(ctx context.Context, demand []app.Demand, constraints app.RecommendConstraints) (app.Recommendation, error) {
+	s.demand, s.constraints = demand, constraints
+	if constraints.Sizes == 0 {
+		return app.Recommendation{}, pkgerrors.ErrConstraintsInvalid
+	}
+	return app.Recommendation{Sizes: []int{250, 500}, Evaluations: 12, Converged: true}, nil
+}
+
+func TestHandler_RecommendPackSizes(t *testing.T) {
+	recommender := &stubRecommender{}
+	router := SetupRoutes(NewHandler(&mockPackService{}).WithRecommender(recommender))
+
+	body := `{"demand":[{"items":250,"frequency":3}],"constraints":{"sizes":2,"min_size":100,"max_size":1000}}`
+	req := httptest.NewRequest("POST", "/api/pack-sizes/recommend", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if len(recommender.demand) != 1 || recommender.demand[0] != (app.Demand{Items: 250, Frequency: 3}) || recommender.constraints.MaxSize != 1000 {
+		t.Errorf("recommender got demand %v and constraints %+v", recommender.demand, recommender.constraints)
+	}
+	var response map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&response)
+	if response["converged"] != true || len(response["sizes"].([]interface{})) != 2 {
+		t.Errorf("body = %v", response)
+	}
+
+	req = httptest.NewRequest("POST", "/api/pack-sizes/recommend", bytes.NewBufferString(`{"demand":[{"items":1,"frequency":1}]}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid constraints status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	req = httptest.NewRequest("POST", "/api/pack-sizes/recommend", bytes.NewBufferString(body[:len(body)-1]+`,"budget":1}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"/budget"`) {
+		t.Errorf("unknown field response = %d %s, want 400 naming /budget", w.Code, w.Body)
+	}
+}
+
+func TestSetupRoutes_LegacyAPIDeprecation(t *testing.T) {
+	since := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)
+	router := SetupRoutes(NewHandler(&mockPackService{
+		getPackSizesFunc: func() ([]int, error) { return []int{250}, nil },
+	}).WithDeprecation(Deprecation{Since: since, Sunset: sunset}))
+
+	t.Run("versioned route", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/pack-sizes", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if w.Header().Get("Deprecation") != "" || w.Header().Get("Sunset") != "" {
+			t.Errorf("versioned route has deprecation headers: %v", w.Header())
+		}
+	})
+
+	t.Run("legacy alias", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/pack-sizes", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get("Deprecation"); got != "@1792281600" {
+			t.Errorf("Deprecation = %q, want @1792281600", got)
+		}
+		if got := w.Header().Get("Sunset"); got != "Fri, 30 Apr 2027 00:00:00 GMT" {
+			t.Errorf("Sunset = %q", got)
+		}
+		if got := w.Header().Get("Link"); got != `</api/v1/pack-sizes>; rel="successor-version"` {
+			t.Errorf("Link = %q", got)
+		}
+	})
+}
+
 func TestHandler_handleError(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -327,6 +579,11 @@ func TestHandler_handleError(t *testing.T) {
 			err:            pkgerrors.ErrDuplicatePackSizes,
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:           "preview orders invalid",
+			err:            pkgerrors.ErrPreviewOrdersInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:           "repository error",
 			err:            pkgerrors.ErrRepository,
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/logger"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response replayed for a retry.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKey        = 255
+)
+
+// IdempotencyOptions configures Idempotency.
+type IdempotencyOptions struct {
+	// TTL is how long a response is replayed for retries.
+	TTL time.Duration
+	// LockTimeout is how long a request in progress holds its key, should
+	// its instance die before storing the response.
+	LockTimeout time.Duration
+	// ClientIP scopes the keys of anonymous callers, which have no subject.
+	ClientIP ClientIP
+}
+
+func DefaultIdempotencyOptions() IdempotencyOptions {
+	return IdempotencyOptions{TTL: 24 * time.Hour, LockTimeout: time.Minute}
+}
+
+// Idempotency stores the response to the first request with an
+// Idempotency-Key and replays it for retries with the same key and body.
+// Keys are scoped to the caller and route. Server errors are not stored, so
+// those requests may be retried; if the store fails the request is handled
+// as if it had no key.
+type Idempotency struct {
+	store   ports.IdempotencyStore
+	options IdempotencyOptions
+}
+
+func NewIdempotency(store ports.IdempotencyStore, options IdempotencyOptions) *Idempotency {
+	return &Idempotency{store: store, options: options}
+}
+
+// idempotentRecord is a stored outcome, or a reservation while Pending.
+type idempotentRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Pending     bool        `json:"pending,omitempty"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Handle must run after authentication, as keys are scoped by principal.
+func (i *Idempotency) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validIdempotencyKey(key) {
+			writeProblem(w, http.StatusBadRequest, pkgerrors.ErrIdempotencyKeyInvalid)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxValidatedBody+1))
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, pkgerrors.ErrInvalidInput)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+		storeKey := i.storeKey(r, key)
+
+		pending, _ := json.Marshal(idempotentRecord{Fingerprint: fingerprint, Pending: true})
+		stored, reserved, err := i.store.Reserve(r.Context(), storeKey, pending, i.options.LockTimeout)
+		if err != nil {
+			logger.Default().Warn("Idempotency key check failed, handling request", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !reserved {
+			i.replay(w, r, stored, fingerprint, next)
+			return
+		}
+
+		// The response is stored even if the client gave up waiting, since
+		// that is when it retries.
+		ctx := context.WithoutCancel(r.Context())
+		completed := false
+		defer func() {
+			if !completed {
+				if err := i.store.Release(ctx, storeKey); err != nil {
+					logger.Default().Warn("Failed to release idempotency key", "error", err)
+				}
+			}
+		}()
+
+		rec := &recordingWriter{ResponseWriter: w, before: w.Header().Clone()}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+
+		value, err := json.Marshal(idempotentRecord{
+			Fingerprint: fingerprint,
+			Status:      rec.status,
+			Header:      rec.handlerHeader(),
+			Body:        rec.body.Bytes(),
+		})
+		if err == nil {
+			err = i.store.Complete(ctx, storeKey, value, i.options.TTL)
+		}
+		if err != nil {
+			logger.Default().Warn("Failed to store idempotent response", "error", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// replay answers a request whose key is taken.
+func (i *Idempotency) replay(w http.ResponseWriter, r *http.Request, stored []byte, fingerprint string, next http.Handler) {
+	var record idempotentRecord
+	if err := json.Unmarshal(stored, &record); err != nil {
+		logger.Default().Warn("Unreadable idempotency record, handling request", "error", err)
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	switch {
+	case record.Fingerprint != fingerprint:
+		writeProblem(w, http.StatusUnprocessableEntity, pkgerrors.ErrIdempotencyKeyReused)
+	case record.Pending:
+		w.Header().Set("Retry-After", "1")
+		writeProblem(w, http.StatusConflict, pkgerrors.ErrIdempotencyInProgress)
+	default:
+		h := w.Header()
+		for name, values := range record.Header {
+			h[name] = values
+		}
+		h.Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(record.Status)
+		w.Write(record.Body)
+	}
+}
+
+// storeKey scopes key to the caller and route, so callers cannot replay each
+// other's responses. Anonymous callers are told apart by client IP.
+func (i *Idempotency) storeKey(r *http.Request, key string) string {
+	caller := "ip:" + i.options.ClientIP.Of(r)
+	if principal, ok := domain.PrincipalFrom(r.Context()); ok && principal.Subject != "" {
+		caller = "subject:" + principal.Subject
+	}
+	sum := sha256.Sum256([]byte(caller + "\x00" + r.Method + " " + apiPath(r.URL.Path) + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKey {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// recordingWriter copies the response as it is written.
+type recordingWriter struct {
+	http.ResponseWriter
+	before http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// handlerHeader returns the headers set after the writer was wrapped, leaving
+// those of outer middleware, such as rate limits, to be set afresh on replay.
+func (w *recordingWriter) handlerHeader() http.Header {
+	header := make(http.Header)
+	for name, values := range w.Header() {
+		if !slices.Equal(w.before[name], values) {
+			header[name] = values
+		}
+	}
+	return header
+}
@@ -0,0 +1,224 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// fakeIdempotencyStore keeps values in a map, ignoring TTLs, or fails with
+// err.
+type fakeIdempotencyStore struct {
+	values map[string][]byte
+	err    error
+}
+
+func newFakeIdempotencyStore() *fakeIdempotencyStore {
+	return &fakeIdempotencyStore{values: make(map[string][]byte)}
+}
+
+func (f *fakeIdempotencyStore) Reserve(ctx context.Context, key string, pending []byte, ttl time.Duration) ([]byte, bool, error) {
+	if f.err != nil {
+		return nil, false, f.err
+	}
+	if stored, ok := f.values[key]; ok {
+		return stored, false, nil
+	}
+	f.values[key] = pending
+	return nil, true, nil
+}
+
+func (f *fakeIdempotencyStore) Complete(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	f.values[key] = value
+	return nil
+}
+
+func (f *fakeIdempotencyStore) Release(ctx context.Context, key string) error {
+	delete(f.values, key)
+	return nil
+}
+
+func postWithKey(router http.Handler, path, body, idempotencyKey, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	}
+	if apiKey != "" {
+		req.Header.Set(APIKeyHeader, apiKey)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysRetries(t *testing.T) {
+	calls := 0
+	packService := &mockPackService{calculatePacksFunc: func(items int) ([]domain.Pack, error) {
+		calls++
+		return []domain.Pack{{Size: 250, Quantity: calls}}, nil
+	}}
+	store := newFakeIdempotencyStore()
+	router := SetupRoutes(NewHandler(packService).WithIdempotency(NewIdempotency(store, DefaultIdempotencyOptions())))
+
+	first := postWithKey(router, "/api/v1/calculate", `{"items": 1}`, "order-42", "")
+	retry := postWithKey(router, "/api/calculate", `{"items": 1}`, "order-42", "")
+
+	if calls != 1 {
+		t.Errorf("service calls = %d, want 1", calls)
+	}
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if got := retry.Header().Get(IdempotentReplayedHeader); got != "true" {
+		t.Errorf("%s = %q, want true", IdempotentReplayedHeader, got)
+	}
+	if got := retry.Header().Get("Content-Type"); got != first.Header().Get("Content-Type") {
+		t.Errorf("replayed Content-Type = %q, want %q", got, first.Header().Get("Content-Type"))
+	}
+	// Headers of outer middleware are set afresh rather than replayed.
+	if got := retry.Header().Get("Link"); got == "" {
+		t.Error("legacy route retry lost its own Link header")
+	}
+
+	if w := postWithKey(router, "/api/v1/calculate", `{"items": 1}`, "order-43", ""); w.Header().Get(IdempotentReplayedHeader) != "" || calls != 2 {
+		t.Errorf("new key replayed = %q, service calls = %d; want a fresh call", w.Header().Get(IdempotentReplayedHeader), calls)
+	}
+	if postWithKey(router, "/api/v1/calculate", `{"items": 1}`, "", ""); calls != 3 {
+		t.Errorf("service calls = %d, want requests without a key always handled", calls)
+	}
+}
+
+func TestIdempotency_RejectsMisuse(t *testing.T) {
+	var router http.Handler
+	var nested *httptest.ResponseRecorder
+	packService := &mockPackService{calculatePacksFunc: func(items int) ([]domain.Pack, error) {
+		if items == 7 {
+			// A retry arriving while the first request is in progress.
+			nested = postWithKey(router, "/api/v1/calculate", `{"items": 7}`, "slow", "")
+		}
+		return nil, nil
+	}}
+	router = SetupRoutes(NewHandler(packService).WithIdempotency(NewIdempotency(newFakeIdempotencyStore(), DefaultIdempotencyOptions())))
+
+	postWithKey(router, "/api/v1/calculate", `{"items": 1}`, "order-42", "")
+
+	tests := []struct {
+		name       string
+		body       string
+		key        string
+		wantStatus int
+		wantCode   string
+	}{
+		{"different body", `{"items": 2}`, "order-42", http.StatusUnprocessableEntity, pkgerrors.CodeIdempotencyKeyReused},
+		{"key too long", `{"items": 1}`, strings.Repeat("k", 256), http.StatusBadRequest, pkgerrors.CodeIdempotencyKeyInvalid},
+		{"control character", `{"items": 1}`, "order\t42", http.StatusBadRequest, pkgerrors.CodeIdempotencyKeyInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postWithKey(router, "/api/v1/calculate", tt.body, tt.key, "")
+			if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantCode) {
+				t.Errorf("response = %d %s, want %d %s", w.Code, w.Body, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+
+	postWithKey(router, "/api/v1/calculate", `{"items": 7}`, "slow", "")
+	if nested == nil || nested.Code != http.StatusConflict || nested.Header().Get("Retry-After") == "" {
+		t.Errorf("concurrent retry = %v, want 409 with Retry-After", nested)
+	}
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	calls := 0
+	packService := &mockPackService{updatePackSizesFunc: func(sizes []int) error {
+		calls++
+		if calls == 1 {
+			return pkgerrors.ErrRepository
+		}
+		return nil
+	}}
+	router := SetupRoutes(NewHandler(packService).
+		WithAPIKeys(newMockAPIKeyService(domain.RoleReader)).
+		WithIdempotency(NewIdempotency(newFakeIdempotencyStore(), DefaultIdempotencyOptions())))
+
+	if w := postWithKey(router, "/api/v1/pack-sizes", `{"sizes": [250]}`, "sizes-1", "admin-key"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if w := postWithKey(router, "/api/v1/pack-sizes", `{"sizes": [250]}`, "sizes-1", "admin-key"); w.Code != http.StatusNoContent || calls != 2 {
+		t.Errorf("retry status = %d, service calls = %d; want %d, 2", w.Code, calls, http.StatusNoContent)
+	}
+	if w := postWithKey(router, "/api/v1/pack-sizes", `{"sizes": [250]}`, "sizes-1", "admin-key"); w.Header().Get(IdempotentReplayedHeader) != "true" || calls != 2 {
+		t.Errorf("second retry replayed = %q, service calls = %d; want a replay", w.Header().Get(IdempotentReplayedHeader), calls)
+	}
+}
+
+func TestIdempotency_KeysAreScopedToCaller(t *testing.T) {
+	calls := 0
+	packService := &mockPackService{calculatePacksFunc: func(items int) ([]domain.Pack, error) {
+		calls++
+		return nil, nil
+	}}
+	router := SetupRoutes(NewHandler(packService).
+		WithAPIKeys(newMockAPIKeyService(domain.RoleReader)).
+		WithIdempotency(NewIdempotency(newFakeIdempotencyStore(), DefaultIdempotencyOptions())))
+
+	postWithKey(router, "/api/v1/calculate", `{"items": 1}`, "shared", "reader-key")
+	if w := postWithKey(router, "/api/v1/calculate", `{"items": 1}`, "shared", "admin-key"); w.Header().Get(IdempotentReplayedHeader) != "" || calls != 2 {
+		t.Errorf("other caller replayed = %q, service calls = %d; want a fresh call", w.Header().Get(IdempotentReplayedHeader), calls)
+	}
+}
+
+func TestIdempotency_AnonymousKeysAreScopedToClientIP(t *testing.T) {
+	calls := 0
+	packService := &mockPackService{calculatePacksFunc: func(items int) ([]domain.Pack, error) {
+		calls++
+		return nil, nil
+	}}
+	router := SetupRoutes(NewHandler(packService).WithIdempotency(NewIdempotency(newFakeIdempotencyStore(), DefaultIdempotencyOptions())))
+
+	post := func(remoteAddr, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/calculate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "order-1")
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	post("203.0.113.1:5000", `{"items": 1}`)
+	if w := post("203.0.113.2:5000", `{"items": 2}`); w.Code != http.StatusOK || w.Header().Get(IdempotentReplayedHeader) != "" || calls != 2 {
+		t.Errorf("other client = %d replayed %q, service calls = %d; want a fresh call", w.Code, w.Header().Get(IdempotentReplayedHeader), calls)
+	}
+	if w := post("203.0.113.1:6000", `{"items": 1}`); w.Header().Get(IdempotentReplayedHeader) != "true" || calls != 2 {
+		t.Errorf("same client replayed = %q, service calls = %d; want a replay", w.Header().Get(IdempotentReplayedHeader), calls)
+	}
+}
+
+func TestIdempotency_FailsOpen(t *testing.T) {
+	calls := 0
+	packService := &mockPackService{calculatePacksFunc: func(items int) ([]domain.Pack, error) {
+		calls++
+		return nil, nil
+	}}
+	store := newFakeIdempotencyStore()
+	store.err = errors.New("connection refused")
+	router := SetupRoutes(NewHandler(packService).WithIdempotency(NewIdempotency(store, DefaultIdempotencyOptions())))
+
+	for range 2 {
+		if w := postWithKey(router, "/api/v1/calculate", `{"items": 1}`, "order-42", ""); w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("service calls = %d, want 2 while the store is down", calls)
+	}
+}
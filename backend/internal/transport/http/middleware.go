@@ -1,35 +1,46 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
-	"github.com/go-chi/httprate"
+	"pack-calculator/pkg/consistency"
 )
 
-func CORS(next http.Handler) http.Handler {
+// ReadYourWrites starts a consistency session per request so reads issued
+// after a write in the same request are served by the primary database.
+func ReadYourWrites(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		// Handle preflight OPTIONS requests: browsers send these before actual requests
-		// to check CORS permissions. We respond immediately without calling next handler.
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
-
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(consistency.WithSession(r.Context())))
 	})
 }
 
-// RateLimit applies rate limiting middleware: 100 requests per minute per IP.
-// Uses token bucket algorithm to prevent abuse while allowing legitimate traffic.
-func RateLimit(next http.Handler) http.Handler {
-	return httprate.Limit(
-		100,
-		1*time.Minute,
-		httprate.WithKeyFuncs(httprate.KeyByIP),
-	)(next)
+// Deprecation describes the retirement of the unversioned /api routes.
+type Deprecation struct {
+	// Since is when the routes were deprecated; zero omits the Deprecation
+	// header.
+	Since time.Time
+	// Sunset is when they stop being served; zero omits the Sunset header.
+	Sunset time.Time
+}
+
+// Deprecated marks responses from legacy /api routes with Deprecation
+// (RFC 9745) and Sunset (RFC 8594) headers and links the /api/v1 successor.
+func Deprecated(d Deprecation) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !d.Since.IsZero() {
+				w.Header().Set("Deprecation", fmt.Sprintf("@%d", d.Since.Unix()))
+			}
+			if !d.Sunset.IsZero() {
+				w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+			}
+			successor := apiPrefix + strings.TrimPrefix(r.URL.Path, legacyAPIPrefix)
+			w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,430 @@
+package http
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pack-calculator/internal/transport"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// openAPIDocument describes every route in SetupRoutes; TestOpenAPI_InSyncWithRoutes
+// fails when they drift apart.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+var openAPI = mustParseOpenAPI(openAPIDocument)
+
+// maxValidatedBody caps how much of a request body is buffered for
+// validation.
+const maxValidatedBody = 1 << 20
+
+// OpenAPI serves the API description.
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
+
+// ValidateRequests rejects JSON bodies that do not match the operation's
+// request schema, listing every mismatch. Paths or methods missing from the
+// document are left to the router.
+func ValidateRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op := openAPI.find(r.Method, r.URL.Path)
+		if op == nil || op.request == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxValidatedBody+1))
+		if err != nil || len(body) > maxValidatedBody {
+			writeValidationError(w, nil)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			writeValidationError(w, nil)
+			return
+		}
+		if violations := op.request.validate(value, ""); len(violations) > 0 {
+			writeValidationError(w, violations)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeValidationError(w http.ResponseWriter, violations []pkgerrors.FieldViolation) {
+	problem := newProblem(http.StatusBadRequest, pkgerrors.ErrInvalidInput)
+	problem.Violations = violationsToResponse(violations)
+	writeProblemBody(w, problem)
+}
+
+// openAPISpec is the subset of an OpenAPI 3 document the validator needs.
+type openAPISpec struct {
+	operations []*openAPIOperation
+}
+
+type openAPIOperation struct {
+	method    string
+	path      string
+	segments  []string
+	request   *schema
+	responses map[string]*schema
+}
+
+type rawSpec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas   map[string]*schema     `json:"schemas"`
+		Responses map[string]rawResponse `json:"responses"`
+	} `json:"components"`
+}
+
+type rawOperation struct {
+	RequestBody *struct {
+		Content rawContent `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]rawResponse `json:"responses"`
+}
+
+type rawResponse struct {
+	Ref     string     `json:"$ref"`
+	Content rawContent `json:"content"`
+}
+
+type rawContent map[string]struct {
+	Schema *schema `json:"schema"`
+}
+
+// schema returns the schema of the JSON media type; errors use
+// application/problem+json.
+func (c rawContent) schema() *schema {
+	if media, ok := c["application/json"]; ok {
+		return media.Schema
+	}
+	return c[transport.ProblemContentType].Schema
+}
+
+func mustParseOpenAPI(document []byte) *openAPISpec {
+	spec, err := parseOpenAPI(document)
+	if err != nil {
+		panic(fmt.Sprintf("invalid OpenAPI document: %v", err))
+	}
+	return spec
+}
+
+func parseOpenAPI(document []byte) (*openAPISpec, error) {
+	var raw rawSpec
+	if err := json.Unmarshal(document, &raw); err != nil {
+		return nil, err
+	}
+
+	resolver := &schemaResolver{schemas: raw.Components.Schemas}
+	for name, s := range raw.Components.Schemas {
+		if err := resolver.resolve(s); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	spec := &openAPISpec{}
+	for path, item := range raw.Paths {
+		for method, rawOp := range item {
+			if method == "parameters" {
+				continue
+			}
+			var op rawOperation
+			if err := json.Unmarshal(rawOp, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+
+			parsed := &openAPIOperation{
+				method:    strings.ToUpper(method),
+				path:      path,
+				segments:  strings.Split(strings.Trim(path, "/"), "/"),
+				responses: make(map[string]*schema),
+			}
+			if op.RequestBody != nil {
+				parsed.request = op.RequestBody.Content.schema()
+				if err := resolver.resolve(parsed.request); err != nil {
+					return nil, fmt.Errorf("%s %s request: %w", method, path, err)
+				}
+			}
+			for status, response := range op.Responses {
+				if ref := strings.TrimPrefix(response.Ref, "#/components/responses/"); ref != response.Ref {
+					var ok bool
+					if response, ok = raw.Components.Responses[ref]; !ok {
+						return nil, fmt.Errorf("%s %s: unknown response %s", method, path, ref)
+					}
+				}
+				s := response.Content.schema()
+				if err := resolver.resolve(s); err != nil {
+					return nil, fmt.Errorf("%s %s response %s: %w", method, path, status, err)
+				}
+				parsed.responses[status] = s
+			}
+			spec.operations = append(spec.operations, parsed)
+		}
+	}
+
+	// Literal segments win over parameters, e.g. /a/preview over /a/{id}.
+	sort.Slice(spec.operations, func(i, j int) bool {
+		return strings.Count(spec.operations[i].path, "{") < strings.Count(spec.operations[j].path, "{")
+	})
+	return spec, nil
+}
+
+// find returns the operation serving path; legacy unversioned paths resolve
+// to their /api/v1 operation.
+func (s *openAPISpec) find(method, path string) *openAPIOperation {
+	if rest, ok := strings.CutPrefix(path, legacyAPIPrefix+"/"); ok && !strings.HasPrefix(path, apiPrefix+"/") {
+		path = apiPrefix + "/" + rest
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, op := range s.operations {
+		if op.method == method && matchSegments(op.segments, segments) {
+			return op
+		}
+	}
+	return nil
+}
+
+func matchSegments(template, segments []string) bool {
+	if len(template) != len(segments) {
+		return false
+	}
+	for i, t := range template {
+		if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if t != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// validateResponse checks a response body against the documented schema for
+// its status.
+func (s *openAPISpec) validateResponse(method, path string, status int, body []byte) []pkgerrors.FieldViolation {
+	op := s.find(method, path)
+	if op == nil {
+		return []pkgerrors.FieldViolation{{Rule: "path", Message: fmt.Sprintf("%s %s is not documented", method, path)}}
+	}
+	responseSchema, ok := op.responses[strconv.Itoa(status)]
+	if !ok {
+		return []pkgerrors.FieldViolation{{Rule: "status", Message: fmt.Sprintf("status %d is not documented for %s %s", status, method, op.path)}}
+	}
+	if responseSchema == nil {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return []pkgerrors.FieldViolation{{Rule: "body", Message: "documented without a body"}}
+		}
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []pkgerrors.FieldViolation{{Rule: "json", Message: err.Error()}}
+	}
+	return responseSchema.validate(value, "")
+}
+
+// schema is the JSON Schema subset used by the document.
+type schema struct {
+	Ref                  string               `json:"$ref"`
+	Type                 string               `json:"type"`
+	Required             []string             `json:"required"`
+	Properties           map[string]*schema   `json:"properties"`
+	AdditionalProperties additionalProperties `json:"additionalProperties"`
+	Items                *schema              `json:"items"`
+	UniqueItems          bool                 `json:"uniqueItems"`
+	Enum                 []interface{}        `json:"enum"`
+	Minimum              *float64             `json:"minimum"`
+	Maximum              *float64             `json:"maximum"`
+	MinItems             *int                 `json:"minItems"`
+	MaxItems             *int                 `json:"maxItems"`
+	MinLength            *int                 `json:"minLength"`
+
+	target *schema
+}
+
+// additionalProperties is a schema for undeclared properties, or false to
+// forbid them.
+type additionalProperties struct {
+	forbidden bool
+	schema    *schema
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "false":
+		a.forbidden = true
+		return nil
+	case "true":
+		return nil
+	}
+	return json.Unmarshal(data, &a.schema)
+}
+
+type schemaResolver struct {
+	schemas map[string]*schema
+}
+
+// resolve links every $ref below s to its component schema.
+func (r *schemaResolver) resolve(s *schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		target, ok := r.schemas[name]
+		if !ok {
+			return fmt.Errorf("unknown schema %s", s.Ref)
+		}
+		s.target = target
+		return nil
+	}
+	for _, property := range s.Properties {
+		if err := r.resolve(property); err != nil {
+			return err
+		}
+	}
+	if err := r.resolve(s.AdditionalProperties.schema); err != nil {
+		return err
+	}
+	return r.resolve(s.Items)
+}
+
+func (s *schema) validate(value interface{}, field string) []pkgerrors.FieldViolation {
+	if s.target != nil {
+		return s.target.validate(value, field)
+	}
+
+	violation := func(rule, format string, args ...interface{}) []pkgerrors.FieldViolation {
+		return []pkgerrors.FieldViolation{{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if allowed == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return violation("enum", "must be one of %v", s.Enum)
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return violation("type", "must be an object")
+		}
+		var violations []pkgerrors.FieldViolation
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				violations = append(violations, pkgerrors.FieldViolation{Field: field + pkgerrors.Pointer(name), Rule: "required", Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			path := field + pkgerrors.Pointer(name)
+			if property, ok := s.Properties[name]; ok {
+				violations = append(violations, property.validate(object[name], path)...)
+			} else if s.AdditionalProperties.forbidden {
+				violations = append(violations, pkgerrors.FieldViolation{Field: path, Rule: "additionalProperties", Message: "is not allowed"})
+			} else if s.AdditionalProperties.schema != nil {
+				violations = append(violations, s.AdditionalProperties.schema.validate(object[name], path)...)
+			}
+		}
+		return violations
+
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return violation("type", "must be an array")
+		}
+		if s.MinItems != nil && len(array) < *s.MinItems {
+			return violation("minItems", "must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(array) > *s.MaxItems {
+			return violation("maxItems", "must have at most %d items", *s.MaxItems)
+		}
+		var violations []pkgerrors.FieldViolation
+		first := make(map[string]int)
+		for i, item := range array {
+			path := field + pkgerrors.Pointer(i)
+			if s.Items != nil {
+				if itemViolations := s.Items.validate(item, path); len(itemViolations) > 0 {
+					violations = append(violations, itemViolations...)
+					continue
+				}
+			}
+			if s.UniqueItems {
+				key, _ := json.Marshal(item)
+				if j, seen := first[string(key)]; seen {
+					violations = append(violations, pkgerrors.FieldViolation{Field: path, Rule: "uniqueItems", Message: "duplicates " + field + pkgerrors.Pointer(j)})
+					continue
+				}
+				first[string(key)] = i
+			}
+		}
+		return violations
+
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok {
+			return violation("type", "must be a %s", s.Type)
+		}
+		if s.Type == "integer" && number != math.Trunc(number) {
+			return violation("type", "must be an integer")
+		}
+		if s.Minimum != nil && number < *s.Minimum {
+			return violation("minimum", "must be at least %s", formatNumber(*s.Minimum))
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			return violation("maximum", "must be at most %s", formatNumber(*s.Maximum))
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return violation("type", "must be a string")
+		}
+		if s.MinLength != nil && len(str) < *s.MinLength {
+			return violation("minLength", "must have at least %d characters", *s.MinLength)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return violation("type", "must be a boolean")
+		}
+	}
+	return nil
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"pack-calculator/internal/domain"
+)
+
+// undocumentedPaths are served on purpose without being part of the public
+// API description.
+var undocumentedPaths = map[string]bool{
+	"/debug/vars": true,
+}
+
+func fullRouter() http.Handler {
+	return SetupRoutes(NewHandler(&mockPackService{}).
+		WithWebhooks(newMockWebhookService()).
+		WithRecommender(&stubRecommender{}).
+		WithAPIKeys(newMockAPIKeyService(domain.RoleAdmin)))
+}
+
+func TestOpenAPI_InSyncWithRoutes(t *testing.T) {
+	routed := make(map[string]bool)
+	legacy := make(map[string]bool)
+	err := chi.Walk(fullRouter().(chi.Routes), func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		if undocumentedPaths[route] {
+			return nil
+		}
+		if rest, ok := strings.CutPrefix(route, legacyAPIPrefix+"/"); ok && !strings.HasPrefix(route, apiPrefix+"/") {
+			legacy[method+" "+apiPrefix+"/"+rest] = true
+			return nil
+		}
+		routed[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("chi.Walk() error = %v", err)
+	}
+
+	documented := make(map[string]bool)
+	for _, op := range openAPI.operations {
+		documented[op.method+" "+op.path] = true
+	}
+
+	var missing, stale []string
+	for route := range routed {
+		if !documented[route] {
+			missing = append(missing, route)
+		}
+	}
+	for route := range documented {
+		if !routed[route] {
+			stale = append(stale, route)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+
+	if len(missing) > 0 {
+		t.Errorf("routes missing from openapi.json: %v", missing)
+	}
+	if len(stale) > 0 {
+		t.Errorf("openapi.json documents routes that SetupRoutes does not serve: %v", stale)
+	}
+	versioned := make(map[string]bool)
+	for route := range routed {
+		if strings.Contains(route, " "+apiPrefix+"/") {
+			versioned[route] = true
+		}
+	}
+	if !reflect.DeepEqual(legacy, versioned) {
+		t.Errorf("legacy %s routes %v do not mirror %s routes %v", legacyAPIPrefix, legacy, apiPrefix, versioned)
+	}
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/openapi.json", nil)
+	w := httptest.NewRecorder()
+	fullRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil || doc["openapi"] != "3.0.3" {
+		t.Errorf("served document is not the OpenAPI spec: %v", err)
+	}
+}
+
+func TestValidateRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+		wantFields     []string
+	}{
+		{"valid", "/api/calculate", `{"items":251}`, http.StatusOK, nil},
+		{"missing field", "/api/calculate", `{}`, http.StatusBadRequest, []string{"/items"}},
+		{"string instead of integer", "/api/calculate", `{"items":"251"}`, http.StatusBadRequest, []string{"/items"}},
+		{"fractional items", "/api/calculate", `{"items":2.5}`, http.StatusBadRequest, []string{"/items"}},
+		{"every bad entry is reported", "/api/pack-sizes", `{"sizes":[0,250,"x"]}`, http.StatusBadRequest, []string{"/sizes/0", "/sizes/2"}},
+		{"out of range and duplicate", "/api/pack-sizes", `{"sizes":[250,-1,250]}`, http.StatusBadRequest, []string{"/sizes/1", "/sizes/2"}},
+		{"unknown field", "/api/calculate", `{"items":1,"item":2}`, http.StatusBadRequest, []string{"/item"}},
+		{"nested field", "/api/pack-sizes/recommend", `{"demand":[{"items":1,"frequency":1}],"constraints":{"sizes":3,"min_size":1,"max_size":200000}}`, http.StatusBadRequest, []string{"/constraints/max_size"}},
+		{"invalid json", "/api/calculate", `{`, http.StatusBadRequest, nil},
+		{"trailing data", "/api/calculate", `{"items":1} {"items":2}`, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			fullRouter().ServeHTTP(w, req)
+
+			if tt.expectedStatus == http.StatusOK {
+				if w.Code == http.StatusBadRequest {
+					t.Fatalf("valid request rejected: %s", w.Body.String())
+				}
+				return
+			}
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+
+			var body struct {
+				Violations []struct {
+					Field string `json:"field"`
+				} `json:"violations"`
+			}
+			json.NewDecoder(w.Body).Decode(&body)
+			var fields []string
+			for _, v := range body.Violations {
+				fields = append(fields, v.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("violation fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestOpenAPI_ResponsesMatchSchemas(t *testing.T) {
+	packService := &mockPackService{
+		getPackSizesFunc: func() ([]int, error) { return []int{250, 500}, nil },
+		calculatePacksFunc: func(items int) ([]domain.Pack, error) {
+			return []domain.Pack{{Size: 500, Quantity: 1}}, nil
+		},
+	}
+	router := SetupRoutes(NewHandler(packService).
+		WithWebhooks(newMockWebhookService()).
+		WithRecommender(&stubRecommender{}).
+		WithAPIKeys(newMockAPIKeyService(domain.RoleReader)))
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+		key    string
+	}{
+		{"GET", "/health", "", ""},
+		{"GET", "/api/v1/pack-sizes", "", ""},
+		{"POST", "/api/v1/pack-sizes", `{"sizes":[250]}`, "admin-key"},
+		{"POST", "/api/v1/pack-sizes", `{"sizes":[250]}`, ""},
+		{"POST", "/api/v1/pack-sizes", `{"sizes":[250]}`, "reader-key"},
+		{"POST", "/api/v1/calculate", `{"items":251}`, ""},
+		{"POST", "/api/v1/pack-sizes/preview", `{"sizes":[250],"orders":[1]}`, ""},
+		{"POST", "/api/v1/pack-sizes/recommend", `{"demand":[{"items":1,"frequency":1}],"constraints":{"sizes":2,"min_size":1,"max_size":10}}`, ""},
+		{"POST", "/api/v1/webhooks", `{"url":"http://wms.local/hook","events":["pack_sizes.changed"]}`, "admin-key"},
+		{"GET", "/api/v1/webhooks", "", "admin-key"},
+		{"GET", "/api/v1/webhooks/1", "", "admin-key"},
+		{"GET", "/api/v1/webhooks/1/deliveries", "", "admin-key"},
+		{"GET", "/api/v1/webhooks/99", "", "admin-key"},
+		{"POST", "/api/v1/api-keys", `{"name":"ci","role":"reader"}`, "admin-key"},
+		{"GET", "/api/v1/api-keys", "", "admin-key"},
+		{"DELETE", "/api/v1/api-keys/1", "", "admin-key"},
+		{"POST", "/api/v1/calculate", `{"items":0}`, ""},
+	}
+
+	for _, req := range requests {
+		r := httptest.NewRequest(req.method, req.path, bytes.NewBufferString(req.body))
+		if req.key != "" {
+			r.Header.Set(APIKeyHeader, req.key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if violations := openAPI.validateResponse(req.method, req.path, w.Code, w.Body.Bytes()); len(violations) > 0 {
+			t.Errorf("%s %s -> %d does not match openapi.json: %+v", req.method, req.path, w.Code, violations)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"pack-calculator/internal/transport"
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/logger"
+)
+
+// problemTypePrefix turns an error code into an RFC 7807 problem type URI.
+const problemTypePrefix = "urn:pack-calculator:problem:"
+
+// internalErrorDetail replaces the detail of every 5xx problem; the cause is
+// logged instead, since it may name tables, hosts or queries.
+const internalErrorDetail = "an internal error occurred"
+
+// newProblem describes err as a problem with the given status. Only the
+// domain error's own message is exposed, never the wrapping context.
+func newProblem(status int, err error) transport.Problem {
+	code := pkgerrors.Code(err)
+	detail := pkgerrors.Message(err)
+	if status >= http.StatusInternalServerError {
+		if code != pkgerrors.CodeRepository && code != pkgerrors.CodeCache {
+			code = pkgerrors.CodeInternal
+		}
+		detail = internalErrorDetail
+	}
+
+	problem := transport.Problem{
+		Type:   problemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+
+	var violations *pkgerrors.ViolationsError
+	if errors.As(err, &violations) {
+		problem.Violations = violationsToResponse(violations.Violations)
+	}
+	return problem
+}
+
+func violationsToResponse(violations []pkgerrors.FieldViolation) []transport.ViolationResponse {
+	if len(violations) == 0 {
+		return nil
+	}
+	response := make([]transport.ViolationResponse, len(violations))
+	for i, v := range violations {
+		response[i] = transport.ViolationResponse{Field: v.Field, Rule: v.Rule, Message: v.Message}
+	}
+	return response
+}
+
+// writeProblem writes err as application/problem+json, logging the cause of
+// server errors.
+func writeProblem(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		logger.Default().Error("Request failed", "status", status, "error", err)
+	}
+	writeProblemBody(w, newProblem(status, err))
+}
+
+func writeProblemBody(w http.ResponseWriter, problem transport.Problem) {
+	w.Header().Set("Content-Type", transport.ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pack-calculator/internal/transport"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+func TestHandler_handleError_Problem(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{
+			name:       "wrapped domain error exposes only its message",
+			err:        pkgerrors.Wrap(pkgerrors.ErrItemsOutOfRange, "failed to calculate"),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   pkgerrors.CodeItemsOutOfRange,
+			wantDetail: pkgerrors.ErrItemsOutOfRange.Error(),
+		},
+		{
+			name:       "repository error hides its cause",
+			err:        pkgerrors.WrapWithDomain(errors.New("dial tcp 10.0.0.5:5432: connection refused"), pkgerrors.ErrRepository, "failed to get pack sizes"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   pkgerrors.CodeRepository,
+			wantDetail: internalErrorDetail,
+		},
+		{
+			name:       "unknown error",
+			err:        errors.New("pq: relation \"pack_sizes\" does not exist"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   pkgerrors.CodeInternal,
+			wantDetail: internalErrorDetail,
+		},
+		{
+			name:       "policy violations",
+			err:        &pkgerrors.ViolationsError{Violations: []pkgerrors.FieldViolation{{Field: "sizes", Rule: "max_sizes", Message: "too many"}}},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   pkgerrors.CodePolicyViolation,
+			wantDetail: "pack sizes violate policy: too many",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			NewHandler(&mockPackService{}).handleError(w, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != transport.ProblemContentType {
+				t.Errorf("Content-Type = %q, want %q", got, transport.ProblemContentType)
+			}
+			var problem transport.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("invalid body: %v", err)
+			}
+			if problem.Code != tt.wantCode || problem.Type != problemTypePrefix+tt.wantCode {
+				t.Errorf("code = %q, type = %q, want %q", problem.Code, problem.Type, tt.wantCode)
+			}
+			if problem.Status != tt.wantStatus || problem.Title != http.StatusText(tt.wantStatus) {
+				t.Errorf("status = %d, title = %q", problem.Status, problem.Title)
+			}
+			if problem.Detail != tt.wantDetail {
+				t.Errorf("detail = %q, want %q", problem.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestSetupRoutes_RouterErrorsAreProblems(t *testing.T) {
+	router := SetupRoutes(NewHandler(&mockPackService{}))
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"GET", "/api/v1/unknown", http.StatusNotFound, pkgerrors.CodeNotFound},
+		{"GET", "/api/unknown", http.StatusNotFound, pkgerrors.CodeNotFound},
+		{"DELETE", "/api/v1/pack-sizes", http.StatusMethodNotAllowed, pkgerrors.CodeMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.HasPrefix(w.Header().Get("Content-Type"), transport.ProblemContentType) {
+				t.Errorf("Content-Type = %q", w.Header().Get("Content-Type"))
+			}
+			var problem transport.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("invalid body: %v", err)
+			}
+			if problem.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", problem.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/ports"
+	pkgerrors "pack-calculator/pkg/errors"
+	"pack-calculator/pkg/logger"
+)
+
+// Rate allows Limit requests per Window.
+type Rate struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RouteRate limits requests to matching routes separately from, and in
+// addition to, the caller's overall rate.
+type RouteRate struct {
+	// Method matches any method when empty.
+	Method string
+	// Path is relative to the API version prefix, e.g. "/calculate"; a
+	// trailing "*" matches any path with that prefix.
+	Path string
+	Rate Rate
+}
+
+func (r RouteRate) matches(method, path string) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(r.Path, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == r.Path
+}
+
+// RateLimitOptions configures RateLimiter.
+type RateLimitOptions struct {
+	// Anonymous is the rate of each client IP without credentials.
+	Anonymous Rate
+	// Authenticated is the rate of each authenticated principal, unless
+	// Clients overrides it by subject, e.g. "api-key:12" or "jwt:ci-bot".
+	Authenticated Rate
+	Clients       map[string]Rate
+	// Routes are checked in order; the first match applies.
+	Routes   []RouteRate
+	ClientIP ClientIP
+}
+
+func DefaultRateLimitOptions() RateLimitOptions {
+	return RateLimitOptions{
+		Anonymous:     Rate{Limit: 100, Window: time.Minute},
+		Authenticated: Rate{Limit: 600, Window: time.Minute},
+	}
+}
+
+// RateLimiter limits each caller with a sliding window approximated from
+// the counts of the current and previous fixed windows. Authenticated
+// callers are limited by principal, so clients behind one proxy or NAT do
+// not share a budget; anonymous callers by IP. If counting fails the request
+// is let through.
+type RateLimiter struct {
+	counter ports.RateCounter
+	options RateLimitOptions
+	now     func() time.Time
+}
+
+func NewRateLimiter(counter ports.RateCounter, options RateLimitOptions) *RateLimiter {
+	return &RateLimiter{counter: counter, options: options, now: time.Now}
+}
+
+// rateCheck is the outcome of one rate for a request.
+type rateCheck struct {
+	rate      Rate
+	remaining int
+	reset     time.Time
+	exceeded  bool
+}
+
+// Limit must run after authentication, as it limits by principal.
+func (l *RateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, rate := l.identify(r)
+
+		var tightest *rateCheck
+		check := func(key string, rate Rate) {
+			c, ok := l.check(r, key, rate)
+			if !ok {
+				return
+			}
+			if tightest == nil || c.exceeded && !tightest.exceeded || c.exceeded == tightest.exceeded && c.remaining < tightest.remaining {
+				tightest = &c
+			}
+		}
+
+		check(identity, rate)
+		path := apiPath(r.URL.Path)
+		for i, route := range l.options.Routes {
+			if route.matches(r.Method, path) {
+				check("route:"+strconv.Itoa(i)+":"+identity, route.Rate)
+				break
+			}
+		}
+
+		if tightest != nil {
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(tightest.rate.Limit))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(tightest.remaining))
+			h.Set("X-RateLimit-Reset", strconv.FormatInt(tightest.reset.Unix(), 10))
+			if tightest.exceeded {
+				retryAfter := int(math.Ceil(tightest.reset.Sub(l.now()).Seconds()))
+				h.Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+				writeProblem(w, http.StatusTooManyRequests, pkgerrors.ErrRateLimited)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// identify returns the counter key and overall rate of the caller.
+func (l *RateLimiter) identify(r *http.Request) (string, Rate) {
+	if principal, ok := domain.PrincipalFrom(r.Context()); ok && principal.Subject != "" {
+		if rate, ok := l.options.Clients[principal.Subject]; ok {
+			return "subject:" + principal.Subject, rate
+		}
+		return "subject:" + principal.Subject, l.options.Authenticated
+	}
+	return "ip:" + l.options.ClientIP.Of(r), l.options.Anonymous
+}
+
+func (l *RateLimiter) check(r *http.Request, key string, rate Rate) (rateCheck, bool) {
+	if rate.Limit <= 0 || rate.Window <= 0 {
+		return rateCheck{}, false
+	}
+
+	now := l.now()
+	window := now.Truncate(rate.Window)
+	current, previous, err := l.counter.Increment(r.Context(), key, window, rate.Window, 1)
+	if err != nil {
+		logger.Default().Warn("Rate limit check failed, allowing request", "error", err)
+		return rateCheck{}, false
+	}
+
+	// Weigh the previous window by how much of it still overlaps the
+	// sliding window ending now.
+	elapsed := float64(now.Sub(window)) / float64(rate.Window)
+	count := int(math.Ceil(float64(previous)*(1-elapsed))) + current
+	c := rateCheck{
+		rate:      rate,
+		remaining: max(rate.Limit-count, 0),
+		reset:     window.Add(rate.Window),
+		exceeded:  count > rate.Limit,
+	}
+	if c.exceeded {
+		c.reset = window.Add(time.Duration(allowedAt(rate.Limit, current, previous, elapsed) * float64(rate.Window)))
+	}
+	return c, true
+}
+
+// allowedAt returns when, in windows from the start of the current one, the
+// next request fits the limit again, assuming none are made meanwhile.
+func allowedAt(limit, current, previous int, elapsed float64) float64 {
+	// Later in this window, as the previous window's weight decays.
+	if current < limit && previous > 0 {
+		if at := 1 - float64(limit-current-1)/float64(previous); at < 1 {
+			return max(at, elapsed)
+		}
+	}
+	// In the next window, as this window's weight decays.
+	return 2 - float64(limit-1)/float64(current)
+}
+
+// apiPath strips the version prefix, so one route rate covers both the
+// versioned and legacy routes.
+func apiPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, apiPrefix); ok {
+		return rest
+	}
+	if rest, ok := strings.CutPrefix(path, legacyAPIPrefix); ok {
+		return rest
+	}
+	return path
+}
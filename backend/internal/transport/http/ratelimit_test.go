@@ -0,0 +1,207 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"pack-calculator/internal/domain"
+)
+
+// fakeRateCounter counts per key and window, or fails with err.
+type fakeRateCounter struct {
+	counts map[string]int
+	err    error
+}
+
+func newFakeRateCounter() *fakeRateCounter {
+	return &fakeRateCounter{counts: make(map[string]int)}
+}
+
+func (f *fakeRateCounter) Increment(ctx context.Context, key string, window time.Time, length time.Duration, n int) (int, int, error) {
+	if f.err != nil {
+		return 0, 0, f.err
+	}
+	current := key + "@" + strconv.FormatInt(window.Unix(), 10)
+	previous := key + "@" + strconv.FormatInt(window.Add(-length).Unix(), 10)
+	f.counts[current] += n
+	return f.counts[current], f.counts[previous], nil
+}
+
+func newTestRateLimiter(counter *fakeRateCounter, options RateLimitOptions, now *time.Time) *RateLimiter {
+	limiter := NewRateLimiter(counter, options)
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func rateLimitedRouter(limiter *RateLimiter) http.Handler {
+	return SetupRoutes(NewHandler(&mockPackService{}).
+		WithAPIKeys(newMockAPIKeyService(domain.RoleReader)).
+		WithRateLimiter(limiter))
+}
+
+func get(router http.Handler, path, key, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	req.RemoteAddr = remoteAddr
+	if key != "" {
+		req.Header.Set(APIKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiter_LimitsAndReportsHeaders(t *testing.T) {
+	now := time.Unix(1_700_000_040, 0)
+	limiter := newTestRateLimiter(newFakeRateCounter(), RateLimitOptions{
+		Anonymous:     Rate{Limit: 2, Window: time.Minute},
+		Authenticated: Rate{Limit: 10, Window: time.Minute},
+	}, &now)
+	router := rateLimitedRouter(limiter)
+
+	for want := 1; want >= 0; want-- {
+		w := get(router, "/api/v1/pack-sizes", "", "192.0.2.1:1234")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != strconv.Itoa(want) {
+			t.Errorf("X-RateLimit-Remaining = %q, want %d", got, want)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("X-RateLimit-Limit = %q, want 2", got)
+		}
+		if got := w.Header().Get("X-RateLimit-Reset"); got != "1700000100" {
+			t.Errorf("X-RateLimit-Reset = %q, want the end of the window", got)
+		}
+	}
+
+	w := get(router, "/api/v1/pack-sizes", "", "192.0.2.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	// Three requests in this window weigh 1 after two thirds of the next, so
+	// one more then fits.
+	if got := w.Header().Get("Retry-After"); got != "100" {
+		t.Errorf("Retry-After = %q, want 100", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", got)
+	}
+
+	// The legacy alias shares the budget; other clients and health checks
+	// are unaffected.
+	if w := get(router, "/api/pack-sizes", "", "192.0.2.1:1234"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("legacy route status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w := get(router, "/api/v1/pack-sizes", "", "192.0.2.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("other IP status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := get(router, "/health", "", "192.0.2.1:1234"); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("health status = %d with rate limit headers %v", w.Code, w.Header())
+	}
+}
+
+func TestRateLimiter_SlidingWindowCountsPreviousWindow(t *testing.T) {
+	now := time.Unix(1_700_000_040, 0)
+	limiter := newTestRateLimiter(newFakeRateCounter(), RateLimitOptions{
+		Anonymous: Rate{Limit: 4, Window: time.Minute},
+	}, &now)
+	router := rateLimitedRouter(limiter)
+
+	for range 4 {
+		get(router, "/api/v1/pack-sizes", "", "192.0.2.1:1234")
+	}
+	// A quarter into the next window, three quarters of the previous count
+	// still apply: ceil(4*0.75) + 1 = 4.
+	now = time.Unix(1_700_000_115, 0)
+	if w := get(router, "/api/v1/pack-sizes", "", "192.0.2.1:1234"); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("status = %d, remaining = %q; want 200, 0", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+	w := get(router, "/api/v1/pack-sizes", "", "192.0.2.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	// By three quarters in, the previous window weighs 1: 1 + 2 + 1 = 4.
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+	now = now.Add(30 * time.Second)
+	if w := get(router, "/api/v1/pack-sizes", "", "192.0.2.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("status after Retry-After = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimiter_LimitsByPrincipal(t *testing.T) {
+	now := time.Unix(1_700_000_040, 0)
+	limiter := newTestRateLimiter(newFakeRateCounter(), RateLimitOptions{
+		Anonymous:     Rate{Limit: 1, Window: time.Minute},
+		Authenticated: Rate{Limit: 2, Window: time.Minute},
+		Clients:       map[string]Rate{"api-key:2": {Limit: 5, Window: time.Minute}},
+		ClientIP:      NewClientIP([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}),
+	}, &now)
+	router := rateLimitedRouter(limiter)
+
+	// Both keys arrive through the same proxy but have their own budgets.
+	if w := get(router, "/api/v1/pack-sizes", "reader-key", "10.0.0.2:1234"); w.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Errorf("reader key limit = %q, want 2", w.Header().Get("X-RateLimit-Limit"))
+	}
+	if w := get(router, "/api/v1/pack-sizes", "admin-key", "10.0.0.2:1234"); w.Header().Get("X-RateLimit-Limit") != "5" {
+		t.Errorf("admin key limit = %q, want the client override 5", w.Header().Get("X-RateLimit-Limit"))
+	}
+	get(router, "/api/v1/pack-sizes", "reader-key", "10.0.0.2:1234")
+	if w := get(router, "/api/v1/pack-sizes", "reader-key", "10.0.0.2:1234"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("reader key status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w := get(router, "/api/v1/pack-sizes", "admin-key", "10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("admin key status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Rejected keys are counted against the client IP.
+	if w := get(router, "/api/v1/pack-sizes", "guess-1", "10.0.0.2:1234"); w.Code != http.StatusUnauthorized {
+		t.Errorf("first bad key status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if w := get(router, "/api/v1/pack-sizes", "guess-2", "10.0.0.2:1234"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second bad key status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimiter_RouteRates(t *testing.T) {
+	now := time.Unix(1_700_000_040, 0)
+	limiter := newTestRateLimiter(newFakeRateCounter(), RateLimitOptions{
+		Anonymous: Rate{Limit: 100, Window: time.Minute},
+		Routes: []RouteRate{
+			{Method: "GET", Path: "/pack-*", Rate: Rate{Limit: 1, Window: time.Minute}},
+			{Path: "/pack-sizes", Rate: Rate{Limit: 50, Window: time.Minute}},
+		},
+	}, &now)
+	router := rateLimitedRouter(limiter)
+
+	if w := get(router, "/api/v1/pack-sizes", "", "192.0.2.1:1234"); w.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Errorf("X-RateLimit-Limit = %q, want the tighter route rate", w.Header().Get("X-RateLimit-Limit"))
+	}
+	if w := get(router, "/api/v1/pack-sizes", "", "192.0.2.1:1234"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w := get(router, "/api/v1/openapi.json", "", "192.0.2.1:1234"); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "100" {
+		t.Errorf("unmatched route status = %d, limit = %q", w.Code, w.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestRateLimiter_FailsOpen(t *testing.T) {
+	now := time.Unix(1_700_000_040, 0)
+	counter := newFakeRateCounter()
+	counter.err = errors.New("connection refused")
+	limiter := newTestRateLimiter(counter, RateLimitOptions{Anonymous: Rate{Limit: 1, Window: time.Minute}}, &now)
+	router := rateLimitedRouter(limiter)
+
+	for range 3 {
+		if w := get(router, "/api/v1/pack-sizes", "", "192.0.2.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+	}
+}
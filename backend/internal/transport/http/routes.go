@@ -1,27 +1,100 @@
 package http
 
 import (
+	"expvar"
 	"net/http"
 
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+const (
+	apiPrefix = "/api/v1"
+	// legacyAPIPrefix serves the same routes unversioned until its sunset.
+	legacyAPIPrefix = "/api"
+)
+
 func SetupRoutes(handler *Handler) http.Handler {
 	r := chi.NewRouter()
 
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(CORS)
-	r.Use(RateLimit)
+	r.Use(CORS(handler.cors))
+	r.Use(ReadYourWrites)
+
+	// Set before the API routes are mounted so their sub-routers inherit them.
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, http.StatusNotFound, pkgerrors.ErrNotFound)
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, http.StatusMethodNotAllowed, pkgerrors.ErrMethodNotAllowed)
+	})
 
 	r.Get("/health", handler.Health)
+	// Runtime and retry counters are for operators only.
+	r.With(handler.authenticate, handler.requireRole(domain.RoleAdmin)).Handle("/debug/vars", expvar.Handler())
 
-	r.Route("/api", func(r chi.Router) {
-		r.Get("/pack-sizes", handler.GetPackSizes)
-		r.Post("/pack-sizes", handler.UpdatePackSizes)
-		r.Post("/calculate", handler.CalculatePacks)
+	r.Route(apiPrefix, handler.apiRoutes)
+	r.Route(legacyAPIPrefix, func(r chi.Router) {
+		r.Use(Deprecated(handler.deprecation))
+		handler.apiRoutes(r)
 	})
 
 	return r
 }
+
+// apiRoutes registers the API. Callers are authenticated first, then rate
+// limited by identity, and their role checked before the body is validated,
+// so unauthorized callers learn nothing about the request schema.
+func (h *Handler) apiRoutes(r chi.Router) {
+	r.Use(h.authenticate)
+	if h.rateLimiter != nil {
+		r.Use(h.rateLimiter.Limit)
+	}
+	r.Get("/openapi.json", OpenAPI)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireRole(domain.RoleReader), ValidateRequests)
+
+		r.Get("/pack-sizes", h.GetPackSizes)
+		r.Post("/pack-sizes/preview", h.PreviewPackSizes)
+		if h.recommender != nil {
+			r.Post("/pack-sizes/recommend", h.RecommendPackSizes)
+		}
+		r.With(h.idempotent).Post("/calculate", h.CalculatePacks)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireRole(domain.RoleAdmin), ValidateRequests)
+
+		r.With(h.idempotent).Post("/pack-sizes", h.UpdatePackSizes)
+		if h.webhooks != nil {
+			r.Route("/webhooks", func(r chi.Router) {
+				r.Get("/", h.ListWebhooks)
+				r.Post("/", h.CreateWebhook)
+				r.Get("/{id}", h.GetWebhook)
+				r.Put("/{id}", h.UpdateWebhook)
+				r.Delete("/{id}", h.DeleteWebhook)
+				r.Get("/{id}/deliveries", h.ListWebhookDeliveries)
+			})
+		}
+		if h.apiKeys != nil {
+			r.Route("/api-keys", func(r chi.Router) {
+				r.Get("/", h.ListAPIKeys)
+				r.Post("/", h.CreateAPIKey)
+				r.Delete("/{id}", h.RevokeAPIKey)
+			})
+		}
+	})
+}
+
+// idempotent applies h.idempotency, if set, after the request is validated.
+func (h *Handler) idempotent(next http.Handler) http.Handler {
+	if h.idempotency == nil {
+		return next
+	}
+	return h.idempotency.Handle(next)
+}
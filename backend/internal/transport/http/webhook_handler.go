@@ -0,0 +1,165 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"pack-calculator/internal/domain"
+	"pack-calculator/internal/transport"
+	pkgerrors "pack-calculator/pkg/errors"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.webhooks.ListSubscriptions(r.Context())
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response := transport.WebhooksResponse{Webhooks: make([]transport.WebhookResponse, len(subs))}
+	for i, sub := range subs {
+		response.Webhooks[i] = webhookToResponse(sub, false)
+	}
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.decodeWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.webhooks.CreateSubscription(r.Context(), sub)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, webhookToResponse(created, true))
+}
+
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.webhooks.GetSubscription(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, webhookToResponse(sub, false))
+}
+
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+	sub, ok := h.decodeWebhook(w, r)
+	if !ok {
+		return
+	}
+	sub.ID = id
+
+	updated, err := h.webhooks.UpdateSubscription(r.Context(), sub)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, webhookToResponse(updated, false))
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhooks.DeleteSubscription(r.Context(), id); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.webhooks.ListDeliveries(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response := transport.WebhookDeliveriesResponse{Deliveries: make([]transport.WebhookDeliveryResponse, len(deliveries))}
+	for i, d := range deliveries {
+		response.Deliveries[i] = transport.WebhookDeliveryResponse{
+			ID:             d.ID,
+			EventType:      d.EventType,
+			EventKey:       d.EventKey,
+			Payload:        d.Payload,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			ResponseStatus: d.ResponseStatus,
+			LastError:      d.LastError,
+			NextAttemptAt:  d.NextAttemptAt,
+			CreatedAt:      d.CreatedAt,
+			DeliveredAt:    d.DeliveredAt,
+		}
+	}
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) webhookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		h.writeError(w, http.StatusNotFound, pkgerrors.ErrNotFound)
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) decodeWebhook(w http.ResponseWriter, r *http.Request) (domain.WebhookSubscription, bool) {
+	var req transport.WebhookRequest
+	if err := decodeStrict(r, &req); err != nil {
+		h.handleError(w, err)
+		return domain.WebhookSubscription{}, false
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	return domain.WebhookSubscription{
+		URL:         req.URL,
+		Events:      req.Events,
+		Description: req.Description,
+		Active:      active,
+	}, true
+}
+
+func webhookToResponse(sub domain.WebhookSubscription, withSecret bool) transport.WebhookResponse {
+	response := transport.WebhookResponse{
+		ID:          sub.ID,
+		URL:         sub.URL,
+		Events:      sub.Events,
+		Description: sub.Description,
+		Active:      sub.Active,
+		CreatedAt:   sub.CreatedAt,
+		UpdatedAt:   sub.UpdatedAt,
+	}
+	if withSecret {
+		response.Secret = sub.Secret
+	}
+	return response
+}
@@ -0,0 +1,177 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pack-calculator/internal/domain"
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+type mockWebhookService struct {
+	subs       map[int64]domain.WebhookSubscription
+	deliveries []domain.WebhookDelivery
+	nextID     int64
+}
+
+func newMockWebhookService() *mockWebhookService {
+	return &mockWebhookService{subs: make(map[int64]domain.WebhookSubscription)}
+}
+
+func (m *mockWebhookService) CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	if sub.URL == "" {
+		return domain.WebhookSubscription{}, pkgerrors.ErrWebhookURLInvalid
+	}
+	m.nextID++
+	sub.ID = m.nextID
+	sub.Secret = "whsec_test"
+	m.subs[sub.ID] = sub
+	return sub, nil
+}
+
+func (m *mockWebhookService) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	subs := []domain.WebhookSubscription{}
+	for id := int64(1); id <= m.nextID; id++ {
+		if sub, ok := m.subs[id]; ok {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (m *mockWebhookService) GetSubscription(ctx context.Context, id int64) (domain.WebhookSubscription, error) {
+	sub, ok := m.subs[id]
+	if !ok {
+		return domain.WebhookSubscription{}, pkgerrors.ErrNotFound
+	}
+	return sub, nil
+}
+
+func (m *mockWebhookService) UpdateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	existing, ok := m.subs[sub.ID]
+	if !ok {
+		return domain.WebhookSubscription{}, pkgerrors.ErrNotFound
+	}
+	sub.Secret = existing.Secret
+	m.subs[sub.ID] = sub
+	return sub, nil
+}
+
+func (m *mockWebhookService) DeleteSubscription(ctx context.Context, id int64) error {
+	if _, ok := m.subs[id]; !ok {
+		return pkgerrors.ErrNotFound
+	}
+	delete(m.subs, id)
+	return nil
+}
+
+func (m *mockWebhookService) ListDeliveries(ctx context.Context, subscriptionID int64) ([]domain.WebhookDelivery, error) {
+	if _, ok := m.subs[subscriptionID]; !ok {
+		return nil, pkgerrors.ErrNotFound
+	}
+	return m.deliveries, nil
+}
+
+func TestHandler_Webhooks(t *testing.T) {
+	webhooks := newMockWebhookService()
+	router := SetupRoutes(NewHandler(&mockPackService{}).WithWebhooks(webhooks))
+
+	do := func(method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var decoded map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &decoded)
+		return w, decoded
+	}
+
+	w, body := do("POST", "/api/webhooks", `{"url":"http://wms.local/hook","events":["pack_sizes.changed"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if body["secret"] != "whsec_test" || body["active"] != true {
+		t.Errorf("create body = %v, want secret and active", body)
+	}
+
+	w, body = do("GET", "/api/webhooks/1", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("get status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if _, ok := body["secret"]; ok {
+		t.Errorf("get body exposes secret: %v", body)
+	}
+
+	w, body = do("PUT", "/api/webhooks/1", `{"url":"http://wms.local/v2","events":["packs.calculated"],"active":false}`)
+	if w.Code != http.StatusOK || body["url"] != "http://wms.local/v2" || body["active"] != false {
+		t.Errorf("update = %d %v", w.Code, body)
+	}
+
+	webhooks.deliveries = []domain.WebhookDelivery{{ID: 7, SubscriptionID: 1, EventType: domain.EventPackSizesChanged, Status: domain.WebhookDeliveryDelivered, Payload: json.RawMessage(`{}`)}}
+	w, body = do("GET", "/api/webhooks/1/deliveries", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("deliveries status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if deliveries, _ := body["deliveries"].([]interface{}); len(deliveries) != 1 {
+		t.Errorf("deliveries = %v, want one", body["deliveries"])
+	}
+
+	w, body = do("GET", "/api/webhooks", "")
+	if webhooks, _ := body["webhooks"].([]interface{}); w.Code != http.StatusOK || len(webhooks) != 1 {
+		t.Errorf("list = %d %v, want one webhook", w.Code, body)
+	}
+
+	if w, _ := do("DELETE", "/api/webhooks/1", ""); w.Code != http.StatusNoContent {
+		t.Errorf("delete status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w, _ := do("GET", "/api/webhooks/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("get deleted status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandler_WebhookErrors(t *testing.T) {
+	router := SetupRoutes(NewHandler(&mockPackService{}).WithWebhooks(newMockWebhookService()))
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"invalid json", "POST", "/api/webhooks", `{`, http.StatusBadRequest},
+		{"trailing data", "POST", "/api/webhooks", `{"url":"https://example.com/hook","events":["pack_sizes.changed"]} {}`, http.StatusBadRequest},
+		{"unknown field", "PUT", "/api/webhooks/1", `{"url":"https://example.com/hook","events":["pack_sizes.changed"],"secret":"x"}`, http.StatusBadRequest},
+		{"invalid url", "POST", "/api/webhooks", `{"events":["pack_sizes.changed"]}`, http.StatusBadRequest},
+		{"non-numeric id", "GET", "/api/webhooks/abc", "", http.StatusNotFound},
+		{"unknown id", "DELETE", "/api/webhooks/42", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestSetupRoutes_WebhooksDisabled(t *testing.T) {
+	router := SetupRoutes(NewHandler(&mockPackService{}))
+
+	req := httptest.NewRequest("GET", "/api/webhooks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"fmt"
+
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+// Validate reports every problem with the request at once, as a
+// *pkgerrors.ViolationsError matching ErrInvalidInput.
+func (r UpdatePackSizesRequest) Validate() error {
+	if r.Sizes == nil {
+		return pkgerrors.Violations(pkgerrors.ErrInvalidInput, []pkgerrors.FieldViolation{required("sizes")})
+	}
+	if len(r.Sizes) == 0 {
+		return pkgerrors.Violations(pkgerrors.ErrInvalidInput, []pkgerrors.FieldViolation{{
+			Field:   pkgerrors.Pointer("sizes"),
+			Rule:    "minItems",
+			Message: "must not be empty",
+		}})
+	}
+
+	var violations []pkgerrors.FieldViolation
+	first := make(map[int]int, len(r.Sizes))
+	for i, size := range r.Sizes {
+		field := pkgerrors.Pointer("sizes", i)
+		if v, ok := inRange(field, size, pkgerrors.MinPackSize, pkgerrors.MaxPackSize); !ok {
+			violations = append(violations, v)
+			continue
+		}
+		if j, seen := first[size]; seen {
+			violations = append(violations, pkgerrors.FieldViolation{
+				Field:   field,
+				Rule:    "uniqueItems",
+				Message: "duplicates " + pkgerrors.Pointer("sizes", j),
+			})
+			continue
+		}
+		first[size] = i
+	}
+	return pkgerrors.Violations(pkgerrors.ErrInvalidInput, violations)
+}
+
+// Validate reports a missing or out-of-range item count.
+func (r CalculateRequest) Validate() error {
+	if r.Items == nil {
+		return pkgerrors.Violations(pkgerrors.ErrInvalidInput, []pkgerrors.FieldViolation{required("items")})
+	}
+	if v, ok := inRange(pkgerrors.Pointer("items"), *r.Items, pkgerrors.MinItems, pkgerrors.MaxItems); !ok {
+		return pkgerrors.Violations(pkgerrors.ErrInvalidInput, []pkgerrors.FieldViolation{v})
+	}
+	return nil
+}
+
+func required(name string) pkgerrors.FieldViolation {
+	return pkgerrors.FieldViolation{Field: pkgerrors.Pointer(name), Rule: "required", Message: "is required"}
+}
+
+func inRange(field string, value, min, max int) (pkgerrors.FieldViolation, bool) {
+	switch {
+	case value < min:
+		return pkgerrors.FieldViolation{Field: field, Rule: "minimum", Message: fmt.Sprintf("must be at least %d", min)}, false
+	case value > max:
+		return pkgerrors.FieldViolation{Field: field, Rule: "maximum", Message: fmt.Sprintf("must be at most %d", max)}, false
+	}
+	return pkgerrors.FieldViolation{}, true
+}
@@ -0,0 +1,75 @@
+package transport
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	pkgerrors "pack-calculator/pkg/errors"
+)
+
+func TestUpdatePackSizesRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		sizes []int
+		want  []pkgerrors.FieldViolation
+	}{
+		{name: "valid", sizes: []int{250, 500}},
+		{name: "missing", sizes: nil, want: []pkgerrors.FieldViolation{{Field: "/sizes", Rule: "required", Message: "is required"}}},
+		{name: "empty", sizes: []int{}, want: []pkgerrors.FieldViolation{{Field: "/sizes", Rule: "minItems", Message: "must not be empty"}}},
+		{
+			name:  "every problem is reported",
+			sizes: []int{250, -1, 250, 0},
+			want: []pkgerrors.FieldViolation{
+				{Field: "/sizes/1", Rule: "minimum", Message: "must be at least 1"},
+				{Field: "/sizes/2", Rule: "uniqueItems", Message: "duplicates /sizes/0"},
+				{Field: "/sizes/3", Rule: "minimum", Message: "must be at least 1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertViolations(t, UpdatePackSizesRequest{Sizes: tt.sizes}.Validate(), tt.want)
+		})
+	}
+}
+
+func TestCalculateRequest_Validate(t *testing.T) {
+	zero, valid, tooMany := 0, 251, pkgerrors.MaxItems+1
+
+	tests := []struct {
+		name  string
+		items *int
+		want  []pkgerrors.FieldViolation
+	}{
+		{name: "valid", items: &valid},
+		{name: "missing", items: nil, want: []pkgerrors.FieldViolation{{Field: "/items", Rule: "required", Message: "is required"}}},
+		{name: "zero", items: &zero, want: []pkgerrors.FieldViolation{{Field: "/items", Rule: "minimum", Message: "must be at least 1"}}},
+		{name: "too many", items: &tooMany, want: []pkgerrors.FieldViolation{{Field: "/items", Rule: "maximum", Message: "must be at most 2147483647"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertViolations(t, CalculateRequest{Items: tt.items}.Validate(), tt.want)
+		})
+	}
+}
+
+func assertViolations(t *testing.T, err error, want []pkgerrors.FieldViolation) {
+	t.Helper()
+	if want == nil {
+		if err != nil {
+			t.Fatalf("Validate() error = %v, want nil", err)
+		}
+		return
+	}
+
+	var violations *pkgerrors.ViolationsError
+	if !errors.As(err, &violations) || !errors.Is(err, pkgerrors.ErrInvalidInput) {
+		t.Fatalf("Validate() error = %v, want invalid input violations", err)
+	}
+	if !reflect.DeepEqual(violations.Violations, want) {
+		t.Errorf("violations = %+v, want %+v", violations.Violations, want)
+	}
+}
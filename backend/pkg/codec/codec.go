@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// JSON encodes values with encoding/json.
+type JSON struct{}
+
+func (JSON) Name() string {
+	return "json"
+}
+
+func (JSON) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSON) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// Msgpack encodes values with MessagePack, which is more compact than JSON
+// and faster to decode for large values.
+type Msgpack struct{}
+
+func (Msgpack) Name() string {
+	return "msgpack"
+}
+
+func (Msgpack) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (Msgpack) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
@@ -0,0 +1,39 @@
+// Package consistency carries read-your-writes state through a context, so a
+// repository can send reads that follow a write in the same request to the
+// primary instead of a lagging replica.
+package consistency
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type sessionKey struct{}
+
+type session struct {
+	wrote atomic.Bool
+}
+
+// WithSession starts a session scoped to ctx, typically one request.
+// Contexts derived from the result share it.
+func WithSession(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(sessionKey{}).(*session); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// MarkWrite records that the session wrote to the primary. It is a no-op
+// without a session.
+func MarkWrite(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+// Wrote reports whether the session has written, in which case its reads
+// must see that write.
+func Wrote(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.wrote.Load()
+}
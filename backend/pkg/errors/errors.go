@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 const (
@@ -10,18 +11,73 @@ const (
 	MaxItems    = 2147483647
 	MinPackSize = 1
 	MinItems    = 1
+	// Preview limits cap the order sample and each order in it, since every
+	// order is calculated twice.
+	MaxPreviewOrders = 1000
+	MaxPreviewItems  = 100000
+	// Recommendation limits keep a search within a request's budget.
+	MaxDemandEntries  = 100
+	MaxDemandItems    = 100000
+	MaxRecommendSizes = 10
+	MaxRecommendSize  = 100000
+
+	// MaxDemandFrequency keeps the weighted impact totals far from overflow.
+	MaxDemandFrequency = 1000000
+)
+
+// Codes are stable, machine-readable identifiers of domain errors; clients may
+// switch on them, so existing codes must never change.
+const (
+	CodeNotFound              = "not_found"
+	CodeInvalidInput          = "invalid_input"
+	CodeRepository            = "repository_error"
+	CodeCache                 = "cache_error"
+	CodeInternal              = "internal_error"
+	CodePackSizesEmpty        = "pack_sizes_empty"
+	CodeItemsInvalid          = "items_invalid"
+	CodePackSizeOutOfRange    = "pack_size_out_of_range"
+	CodeItemsOutOfRange       = "items_out_of_range"
+	CodeDuplicatePackSizes    = "duplicate_pack_sizes"
+	CodeWebhookURLInvalid     = "webhook_url_invalid"
+	CodeWebhookEventsInvalid  = "webhook_events_invalid"
+	CodePolicyViolation       = "policy_violation"
+	CodePreviewOrdersInvalid  = "preview_orders_invalid"
+	CodeDemandInvalid         = "demand_invalid"
+	CodeConstraintsInvalid    = "constraints_invalid"
+	CodeMethodNotAllowed      = "method_not_allowed"
+	CodeRateLimited           = "rate_limited"
+	CodeUnauthorized          = "unauthorized"
+	CodeForbidden             = "forbidden"
+	CodeAPIKeyInvalid         = "api_key_invalid"
+	CodeIdempotencyKeyInvalid = "idempotency_key_invalid"
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
 )
 
 var (
-	ErrNotFound            = errors.New("resource not found")
-	ErrInvalidInput        = errors.New("invalid input")
-	ErrRepository          = errors.New("repository error")
-	ErrCache               = errors.New("cache error")
-	ErrPackSizesEmpty      = errors.New("pack sizes cannot be empty")
-	ErrItemsInvalid        = errors.New("items must be greater than 0")
-	ErrPackSizeOutOfRange  = errors.New("pack size is out of range (must be between 1 and 2147483647)")
-	ErrItemsOutOfRange     = errors.New("items value is out of range (must be between 1 and 2147483647)")
-	ErrDuplicatePackSizes  = errors.New("duplicate pack sizes are not allowed")
+	ErrNotFound              error = New(CodeNotFound, "resource not found")
+	ErrInvalidInput          error = New(CodeInvalidInput, "invalid input")
+	ErrRepository            error = New(CodeRepository, "repository error")
+	ErrCache                 error = New(CodeCache, "cache error")
+	ErrPackSizesEmpty        error = New(CodePackSizesEmpty, "pack sizes cannot be empty")
+	ErrItemsInvalid          error = New(CodeItemsInvalid, "items must be greater than 0")
+	ErrPackSizeOutOfRange    error = New(CodePackSizeOutOfRange, "pack size is out of range (must be between 1 and 2147483647)")
+	ErrItemsOutOfRange       error = New(CodeItemsOutOfRange, "items value is out of range (must be between 1 and 2147483647)")
+	ErrDuplicatePackSizes    error = New(CodeDuplicatePackSizes, "duplicate pack sizes are not allowed")
+	ErrWebhookURLInvalid     error = New(CodeWebhookURLInvalid, "webhook url must be an absolute http or https URL")
+	ErrWebhookEventsInvalid  error = New(CodeWebhookEventsInvalid, "webhook events must list at least one known event type")
+	ErrPolicyViolation       error = New(CodePolicyViolation, "pack sizes violate policy")
+	ErrPreviewOrdersInvalid  error = New(CodePreviewOrdersInvalid, "orders must list between 1 and 1000 order quantities")
+	ErrDemandInvalid         error = New(CodeDemandInvalid, "demand must list between 1 and 100 order quantities with frequencies between 1 and 1000000")
+	ErrConstraintsInvalid    error = New(CodeConstraintsInvalid, "constraints need 1 to 10 sizes and 1 <= min_size <= max_size <= 100000")
+	ErrMethodNotAllowed      error = New(CodeMethodNotAllowed, "method not allowed")
+	ErrRateLimited           error = New(CodeRateLimited, "too many requests")
+	ErrUnauthorized          error = New(CodeUnauthorized, "missing or invalid credentials")
+	ErrForbidden             error = New(CodeForbidden, "the credentials do not grant this operation")
+	ErrAPIKeyInvalid         error = New(CodeAPIKeyInvalid, "api key needs a name and a role of reader or admin")
+	ErrIdempotencyKeyInvalid error = New(CodeIdempotencyKeyInvalid, "Idempotency-Key must be 1 to 255 printable ASCII characters")
+	ErrIdempotencyKeyReused  error = New(CodeIdempotencyKeyReused, "Idempotency-Key was already used for a different request")
+	ErrIdempotencyInProgress error = New(CodeIdempotencyInProgress, "a request with this Idempotency-Key is still in progress")
 )
 
 type DomainError struct {
@@ -41,6 +97,87 @@ func (e *DomainError) Unwrap() error {
 	return e.Err
 }
 
+// New returns a sentinel domain error. Sentinels compare by identity, so
+// errors.Is matches them through any wrapping.
+func New(code, message string) *DomainError {
+	return &DomainError{Code: code, Message: message}
+}
+
+// Code returns the code of the outermost domain error in err's chain, or
+// CodeInternal when there is none.
+func Code(err error) string {
+	var domainErr *DomainError
+	if errors.As(err, &domainErr) && domainErr.Code != "" {
+		return domainErr.Code
+	}
+	return CodeInternal
+}
+
+// Message returns the message of the outermost domain error in err's chain
+// without the wrapping context or causes, which may carry internal details.
+func Message(err error) string {
+	var violations *ViolationsError
+	if errors.As(err, &violations) {
+		return violations.Error()
+	}
+	var domainErr *DomainError
+	if errors.As(err, &domainErr) {
+		return domainErr.Message
+	}
+	return ""
+}
+
+// FieldViolation describes one failed check of a request field. Field is a
+// JSON pointer (RFC 6901) into the request body, e.g. "/sizes/1".
+type FieldViolation struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+// Pointer builds a JSON pointer from reference tokens: Pointer("sizes", 1) is
+// "/sizes/1". Pointers concatenate, so Pointer("a")+Pointer("b") is "/a/b".
+func Pointer(tokens ...any) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(pointerEscaper.Replace(fmt.Sprint(token)))
+	}
+	return b.String()
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// ViolationsError reports every failed check at once. It matches Err, or
+// ErrPolicyViolation when Err is nil.
+type ViolationsError struct {
+	Err        error
+	Violations []FieldViolation
+}
+
+func (e *ViolationsError) Error() string {
+	if len(e.Violations) == 1 {
+		return fmt.Sprintf("%s: %s", e.Unwrap(), e.Violations[0].Message)
+	}
+	return fmt.Sprintf("%s: %d violations", e.Unwrap(), len(e.Violations))
+}
+
+func (e *ViolationsError) Unwrap() error {
+	if e.Err == nil {
+		return ErrPolicyViolation
+	}
+	return e.Err
+}
+
+// Violations returns a *ViolationsError matching kind, or nil when there are
+// no violations.
+func Violations(kind error, violations []FieldViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ViolationsError{Err: kind, Violations: violations}
+}
+
 func WrapDomainError(code, message string, err error) *DomainError {
 	return &DomainError{
 		Code:    code,
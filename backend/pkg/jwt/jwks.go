@@ -0,0 +1,137 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Key is a public verification key from a JSON Web Key Set.
+type Key struct {
+	ID string
+	// Alg restricts the key to one algorithm; empty allows any algorithm
+	// matching the key type.
+	Alg    string
+	Public crypto.PublicKey
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// ParseJWKS reads the signing keys of a JSON Web Key Set. Keys meant for
+// encryption and key types other than RSA and EC are skipped; a malformed
+// signing key fails the whole set.
+func ParseJWKS(data []byte) ([]Key, error) {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwt: invalid key set: %w", err)
+	}
+
+	keys := make([]Key, 0, len(set.Keys))
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var public crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			public, err = k.rsaKey()
+		case "EC":
+			public, err = k.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %d (%q): %w", i, k.Kid, err)
+		}
+		keys = append(keys, Key{ID: k.Kid, Alg: k.Alg, Public: public})
+	}
+	return keys, nil
+}
+
+func (k jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeInt(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus")
+	}
+	e, err := decodeInt(k.E)
+	if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+	if n.BitLen() < 2048 {
+		return nil, fmt.Errorf("modulus shorter than 2048 bits")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecKey() (*ecdsa.PublicKey, error) {
+	curve, ok := curves[k.Crv]
+	if !ok {
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, errX := decodeInt(k.X)
+	y, errY := decodeInt(k.Y)
+	if errX != nil || errY != nil || !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("invalid point")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := encoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, ErrMalformed
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// MarshalJWKS encodes keys as a JSON Web Key Set.
+func MarshalJWKS(keys []Key) ([]byte, error) {
+	set := jwks{Keys: make([]jwk, 0, len(keys))}
+	for _, key := range keys {
+		k := jwk{Kid: key.ID, Alg: key.Alg, Use: "sig"}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			k.Kty = "RSA"
+			k.N = encoding.EncodeToString(public.N.Bytes())
+			k.E = encoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			params := public.Curve.Params()
+			size := (params.BitSize + 7) / 8
+			k.Kty = "EC"
+			k.Crv = params.Name
+			k.X = encoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+			k.Y = encoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+		default:
+			return nil, fmt.Errorf("jwt: unsupported key type %T", key.Public)
+		}
+		set.Keys = append(set.Keys, k)
+	}
+	return json.Marshal(set)
+}
@@ -0,0 +1,281 @@
+// Package jwt parses and verifies JSON Web Tokens signed with public-key
+// algorithms (RS*, PS*, ES*), and reads the JSON Web Key Sets that publish
+// the verification keys. Symmetric and unsigned tokens are rejected.
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMalformed is returned for tokens that are not compact JWS.
+	ErrMalformed = errors.New("jwt: malformed token")
+	// ErrUnsupportedAlgorithm is returned for algorithms other than RS*,
+	// PS* and ES*.
+	ErrUnsupportedAlgorithm = errors.New("jwt: unsupported algorithm")
+	// ErrKeyMismatch is returned when the key cannot verify the token's
+	// algorithm.
+	ErrKeyMismatch = errors.New("jwt: key does not match algorithm")
+	// ErrSignature is returned when the signature does not verify.
+	ErrSignature = errors.New("jwt: invalid signature")
+)
+
+type algorithm struct {
+	hash crypto.Hash
+	// pss selects RSASSA-PSS for RSA keys.
+	pss bool
+	// curveBits is the ECDSA curve size; 0 for RSA.
+	curveBits int
+}
+
+var algorithms = map[string]algorithm{
+	"RS256": {hash: crypto.SHA256},
+	"RS384": {hash: crypto.SHA384},
+	"RS512": {hash: crypto.SHA512},
+	"PS256": {hash: crypto.SHA256, pss: true},
+	"PS384": {hash: crypto.SHA384, pss: true},
+	"PS512": {hash: crypto.SHA512, pss: true},
+	"ES256": {hash: crypto.SHA256, curveBits: 256},
+	"ES384": {hash: crypto.SHA384, curveBits: 384},
+	"ES512": {hash: crypto.SHA512, curveBits: 521},
+}
+
+// Header is the JOSE header of a token.
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Token is a parsed, not yet verified, token.
+type Token struct {
+	Header Header
+	Claims Claims
+
+	signingInput string
+	signature    []byte
+}
+
+var encoding = base64.RawURLEncoding
+
+// Parse decodes a compact JWS without verifying it.
+func Parse(token string) (*Token, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var t Token
+	if err := decodeSegment(parts[0], &t.Header); err != nil {
+		return nil, err
+	}
+	if err := decodeSegment(parts[1], &t.Claims); err != nil {
+		return nil, err
+	}
+	if t.Claims == nil {
+		return nil, ErrMalformed
+	}
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	t.signingInput = parts[0] + "." + parts[1]
+	t.signature = signature
+	return &t, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := encoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformed
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
+
+// Verify checks the signature with key. The key's algorithm, if it declares
+// one, must match the token's.
+func (t *Token) Verify(key Key) error {
+	alg, ok := algorithms[t.Header.Alg]
+	if !ok {
+		return ErrUnsupportedAlgorithm
+	}
+	if key.Alg != "" && key.Alg != t.Header.Alg {
+		return ErrKeyMismatch
+	}
+
+	h := alg.hash.New()
+	h.Write([]byte(t.signingInput))
+	digest := h.Sum(nil)
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		if alg.curveBits != 0 {
+			return ErrKeyMismatch
+		}
+		var err error
+		if alg.pss {
+			err = rsa.VerifyPSS(pub, alg.hash, digest, t.signature, nil)
+		} else {
+			err = rsa.VerifyPKCS1v15(pub, alg.hash, digest, t.signature)
+		}
+		if err != nil {
+			return ErrSignature
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg.curveBits == 0 || pub.Curve.Params().BitSize != alg.curveBits {
+			return ErrKeyMismatch
+		}
+		size := (alg.curveBits + 7) / 8
+		if len(t.signature) != 2*size {
+			return ErrSignature
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrSignature
+		}
+		return nil
+	}
+	return ErrKeyMismatch
+}
+
+// Sign returns a compact JWS of claims signed by key, which must be an
+// *rsa.PrivateKey or *ecdsa.PrivateKey suited to header.Alg.
+func Sign(header Header, claims any, key crypto.Signer) (string, error) {
+	alg, ok := algorithms[header.Alg]
+	if !ok {
+		return "", ErrUnsupportedAlgorithm
+	}
+	if header.Typ == "" {
+		header.Typ = "JWT"
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encoding.EncodeToString(headerJSON) + "." + encoding.EncodeToString(claimsJSON)
+
+	h := alg.hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	var signature []byte
+	switch priv := key.(type) {
+	case *rsa.PrivateKey:
+		if alg.curveBits != 0 {
+			return "", ErrKeyMismatch
+		}
+		if alg.pss {
+			signature, err = rsa.SignPSS(rand.Reader, priv, alg.hash, digest, nil)
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, priv, alg.hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		if priv.Curve.Params().BitSize != alg.curveBits {
+			return "", ErrKeyMismatch
+		}
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, priv, digest)
+		if err == nil {
+			size := (alg.curveBits + 7) / 8
+			signature = make([]byte, 2*size)
+			r.FillBytes(signature[:size])
+			s.FillBytes(signature[size:])
+		}
+	default:
+		return "", ErrKeyMismatch
+	}
+	if err != nil {
+		return "", fmt.Errorf("jwt: sign: %w", err)
+	}
+	return signingInput + "." + encoding.EncodeToString(signature), nil
+}
+
+// Claims is a token's claim set. Numbers are json.Number.
+type Claims map[string]any
+
+// Lookup returns the claim at path, where dots separate the names of nested
+// objects, e.g. "realm_access.roles".
+func (c Claims) Lookup(path string) (any, bool) {
+	var value any = map[string]any(c)
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// String returns the string claim at path, or "".
+func (c Claims) String(path string) string {
+	value, _ := c.Lookup(path)
+	s, _ := value.(string)
+	return s
+}
+
+// Strings returns the claim at path as a list, accepting a single string, an
+// array of strings, or a space-separated string as used by "scope".
+func (c Claims) Strings(path string) []string {
+	value, _ := c.Lookup(path)
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// Time returns a NumericDate claim. ok is false if the claim is absent; a
+// present claim that is not a number is an error.
+func (c Claims) Time(name string) (t time.Time, ok bool, err error) {
+	value, present := c[name]
+	if !present {
+		return time.Time{}, false, nil
+	}
+	number, isNumber := value.(json.Number)
+	if !isNumber {
+		return time.Time{}, true, fmt.Errorf("jwt: claim %q is not a number", name)
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("jwt: claim %q is not a number", name)
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second))).UTC(), true, nil
+}
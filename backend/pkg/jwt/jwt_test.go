@@ -0,0 +1,149 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify_RoundTripsThroughJWKS(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := MarshalJWKS([]Key{
+		{ID: "ec", Alg: "ES256", Public: &ecKey.PublicKey},
+		{ID: "rsa", Public: &rsaKey.PublicKey},
+	})
+	if err != nil {
+		t.Fatalf("MarshalJWKS() error = %v", err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("ParseJWKS() = %v, %v", keys, err)
+	}
+
+	for _, tt := range []struct {
+		alg    string
+		signer crypto.Signer
+		key    Key
+	}{
+		{"ES256", ecKey, keys[0]},
+		{"RS256", rsaKey, keys[1]},
+		{"PS384", rsaKey, keys[1]},
+	} {
+		t.Run(tt.alg, func(t *testing.T) {
+			token, err := Sign(Header{Alg: tt.alg, Kid: tt.key.ID}, map[string]any{"sub": "alice"}, tt.signer)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+
+			parsed, err := Parse(token)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if parsed.Header.Kid != tt.key.ID || parsed.Claims.String("sub") != "alice" {
+				t.Errorf("Parse() = %+v", parsed)
+			}
+			if err := parsed.Verify(tt.key); err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+
+			// Swap in another payload under the original signature.
+			forged, _ := Sign(Header{Alg: tt.alg, Kid: tt.key.ID}, map[string]any{"sub": "mallory"}, tt.signer)
+			parts, forgedParts := strings.Split(token, "."), strings.Split(forged, ".")
+			tampered, err := Parse(parts[0] + "." + forgedParts[1] + "." + parts[2])
+			if err != nil {
+				t.Fatalf("Parse(tampered) error = %v", err)
+			}
+			if err := tampered.Verify(tt.key); !errors.Is(err, ErrSignature) {
+				t.Errorf("Verify(tampered) error = %v, want ErrSignature", err)
+			}
+		})
+	}
+}
+
+func TestVerify_RejectsUnsafeAlgorithms(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key := Key{ID: "ec", Public: &ecKey.PublicKey}
+
+	for _, token := range []string{
+		// {"alg":"none"}.{"sub":"alice"}.
+		"eyJhbGciOiJub25lIn0.eyJzdWIiOiJhbGljZSJ9.",
+		// {"alg":"HS256"}.{"sub":"alice"}.sig
+		"eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJhbGljZSJ9.c2ln",
+	} {
+		parsed, err := Parse(token)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", token, err)
+		}
+		if err := parsed.Verify(key); !errors.Is(err, ErrUnsupportedAlgorithm) {
+			t.Errorf("Verify(%s) error = %v, want ErrUnsupportedAlgorithm", parsed.Header.Alg, err)
+		}
+	}
+
+	token, _ := Sign(Header{Alg: "ES256"}, map[string]any{}, ecKey)
+	parsed, _ := Parse(token)
+	if err := parsed.Verify(Key{Alg: "RS256", Public: &ecKey.PublicKey}); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("Verify(alg mismatch) error = %v, want ErrKeyMismatch", err)
+	}
+}
+
+func TestParse_RejectsMalformedTokens(t *testing.T) {
+	for _, token := range []string{"", "a.b", "a.b.c.d", "!!.e30.", "e30.bnVsbA.", "e30.e30.!!"} {
+		if _, err := Parse(token); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Parse(%q) error = %v, want ErrMalformed", token, err)
+		}
+	}
+}
+
+func TestClaims(t *testing.T) {
+	token, _ := Parse("e30." + encoding.EncodeToString([]byte(
+		`{"exp":1700000000.5,"scope":"read write","realm_access":{"roles":["admin",7]},"nbf":"soon"}`)) + ".")
+
+	if got := token.Claims.Strings("scope"); len(got) != 2 || got[1] != "write" {
+		t.Errorf(`Strings("scope") = %v`, got)
+	}
+	if got := token.Claims.Strings("realm_access.roles"); len(got) != 1 || got[0] != "admin" {
+		t.Errorf(`Strings("realm_access.roles") = %v`, got)
+	}
+	if got := token.Claims.Strings("realm_access.missing"); got != nil {
+		t.Errorf(`Strings("realm_access.missing") = %v`, got)
+	}
+
+	exp, ok, err := token.Claims.Time("exp")
+	if !ok || err != nil || !exp.Equal(time.Unix(1700000000, 5e8)) {
+		t.Errorf(`Time("exp") = %v, %v, %v`, exp, ok, err)
+	}
+	if _, ok, err := token.Claims.Time("nbf"); !ok || err == nil {
+		t.Errorf(`Time("nbf") = %v, %v, want an error`, ok, err)
+	}
+	if _, ok, _ := token.Claims.Time("iat"); ok {
+		t.Error(`Time("iat") reported a missing claim as present`)
+	}
+}
+
+func TestParseJWKS_SkipsEncryptionAndUnknownKeys(t *testing.T) {
+	keys, err := ParseJWKS([]byte(`{"keys":[{"kty":"oct","k":"c2VjcmV0"},{"kty":"RSA","use":"enc","n":"AQAB","e":"AQAB"}]}`))
+	if err != nil || len(keys) != 0 {
+		t.Errorf("ParseJWKS() = %v, %v, want no keys", keys, err)
+	}
+
+	if _, err := ParseJWKS([]byte(`{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`)); err == nil {
+		t.Error("ParseJWKS() accepted a point off the curve")
+	}
+	if _, err := ParseJWKS([]byte(`{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"}]}`)); err == nil {
+		t.Error("ParseJWKS() accepted a short RSA modulus")
+	}
+}
@@ -5,6 +5,7 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -22,27 +23,31 @@ type mockPackService struct {
 	calculatePacksFunc  func(items int) ([]domain.Pack, error)
 }
 
-func (m *mockPackService) GetPackSizes() ([]int, error) {
+func (m *mockPackService) GetPackSizes(ctx context.Context) ([]int, error) {
 	if m.getPackSizesFunc != nil {
 		return m.getPackSizesFunc()
 	}
 	return nil, nil
 }
 
-func (m *mockPackService) UpdatePackSizes(sizes []int) error {
+func (m *mockPackService) UpdatePackSizes(ctx context.Context, sizes []int) error {
 	if m.updatePackSizesFunc != nil {
 		return m.updatePackSizesFunc(sizes)
 	}
 	return nil
 }
 
-func (m *mockPackService) CalculatePacks(items int) ([]domain.Pack, error) {
+func (m *mockPackService) CalculatePacks(ctx context.Context, items int) ([]domain.Pack, error) {
 	if m.calculatePacksFunc != nil {
 		return m.calculatePacksFunc(items)
 	}
 	return nil, nil
 }
 
+func (m *mockPackService) PreviewPackSizes(ctx context.Context, candidate []int, orders []int) (app.PackSizePreview, error) {
+	return app.PackSizePreview{}, nil
+}
+
 func setupIntegrationTest(t *testing.T) (*httptransport.Handler, func()) {
 	if testing.Short() {
 		t.Skip("Skipping integration test")
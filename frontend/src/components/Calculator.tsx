@@ -31,7 +31,7 @@ export default function Calculator() {
       setPacks(result)
       setError('')
     } catch (err: any) {
-      setError(err.response?.data?.error || 'Failed to calculate packs')
+      setError(err.response?.data?.detail || 'Failed to calculate packs')
       setPacks([])
     } finally {
       setLoading(false)
@@ -6,6 +6,7 @@ export default function PackSizes() {
   const [sizes, setSizes] = useState<string[]>(['23', '31', '53'])
   const [loading, setLoading] = useState(false)
   const [message, setMessage] = useState<string>('')
+  const [apiKey, setApiKey] = useState<string>('')
 
   useEffect(() => {
     loadPackSizes()
@@ -58,11 +59,11 @@ export default function PackSizes() {
         return
       }
 
-      await updatePackSizes(packSizesArray)
+      await updatePackSizes(packSizesArray, apiKey.trim())
       setMessage('Pack sizes updated successfully')
       setTimeout(() => setMessage(''), 3000)
     } catch (error: any) {
-      setMessage(error.response?.data?.error || 'Failed to update pack sizes')
+      setMessage(error.response?.data?.detail || 'Failed to update pack sizes')
     } finally {
       setLoading(false)
     }
@@ -97,6 +98,14 @@ export default function PackSizes() {
               </div>
             ))}
           </div>
+          <input
+            type="password"
+            className="api-key-input"
+            placeholder="Admin API key"
+            value={apiKey}
+            onChange={(e: React.ChangeEvent<HTMLInputElement>) => setApiKey(e.target.value)}
+            autoComplete="off"
+          />
           <button
             type="button"
             className="add-button"
@@ -1,6 +1,6 @@
 import axios from 'axios'
 
-const API_URL = (import.meta.env.VITE_API_URL as string) || '/api'
+const API_URL = (import.meta.env.VITE_API_URL as string) || '/api/v1'
 
 const api = axios.create({
   baseURL: API_URL,
@@ -27,8 +27,9 @@ export const getPackSizes = async (): Promise<number[]> => {
   return response.data.sizes
 }
 
-export const updatePackSizes = async (sizes: number[]): Promise<void> => {
-  await api.post('/pack-sizes', { sizes })
+// Updating pack sizes needs an API key with the admin role.
+export const updatePackSizes = async (sizes: number[], apiKey: string): Promise<void> => {
+  await api.post('/pack-sizes', { sizes }, { headers: { 'X-API-Key': apiKey } })
 }
 
 export const calculatePacks = async (items: number): Promise<Pack[]> => {